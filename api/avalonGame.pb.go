@@ -71,21 +71,61 @@ func (GameSession_GameState) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_5befea5ed4f8cd8c, []int{1, 0}
 }
 
+type EndgameOutcome_Reason int32
+
+const (
+	EndgameOutcome_REASON_UNSPECIFIED      EndgameOutcome_Reason = 0
+	EndgameOutcome_MISSIONS_PASSED         EndgameOutcome_Reason = 1
+	EndgameOutcome_MISSIONS_FAILED         EndgameOutcome_Reason = 2
+	EndgameOutcome_TEAM_PROPOSALS_REJECTED EndgameOutcome_Reason = 3
+	EndgameOutcome_MERLIN_ASSASSINATED     EndgameOutcome_Reason = 4
+	EndgameOutcome_ASSASSINATION_FAILED    EndgameOutcome_Reason = 5
+)
+
+var EndgameOutcome_Reason_name = map[int32]string{
+	0: "REASON_UNSPECIFIED",
+	1: "MISSIONS_PASSED",
+	2: "MISSIONS_FAILED",
+	3: "TEAM_PROPOSALS_REJECTED",
+	4: "MERLIN_ASSASSINATED",
+	5: "ASSASSINATION_FAILED",
+}
+
+var EndgameOutcome_Reason_value = map[string]int32{
+	"REASON_UNSPECIFIED":      0,
+	"MISSIONS_PASSED":         1,
+	"MISSIONS_FAILED":         2,
+	"TEAM_PROPOSALS_REJECTED": 3,
+	"MERLIN_ASSASSINATED":     4,
+	"ASSASSINATION_FAILED":    5,
+}
+
+func (x EndgameOutcome_Reason) String() string {
+	return proto.EnumName(EndgameOutcome_Reason_name, int32(x))
+}
+
+func (EndgameOutcome_Reason) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_5befea5ed4f8cd8c, []int{2, 0}
+}
+
 type VoteContext_VoteOption int32
 
 const (
 	VoteContext_NEGATIVE VoteContext_VoteOption = 0
 	VoteContext_POSITIVE VoteContext_VoteOption = 1
+	VoteContext_ABSTAIN  VoteContext_VoteOption = 2
 )
 
 var VoteContext_VoteOption_name = map[int32]string{
 	0: "NEGATIVE",
 	1: "POSITIVE",
+	2: "ABSTAIN",
 }
 
 var VoteContext_VoteOption_value = map[string]int32{
 	"NEGATIVE": 0,
 	"POSITIVE": 1,
+	"ABSTAIN":  2,
 }
 
 func (x VoteContext_VoteOption) String() string {
@@ -93,7 +133,7 @@ func (x VoteContext_VoteOption) String() string {
 }
 
 func (VoteContext_VoteOption) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{11, 0}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{16, 0}
 }
 
 //UUID v4 as in RFC 4122 for identifying game sessions
@@ -147,10 +187,14 @@ type GameSession struct {
 	GameId            *UUID                 `protobuf:"bytes,1,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty" bson:"game_id,omitempty"`
 	State             GameSession_GameState `protobuf:"varint,10,opt,name=state,proto3,enum=proto.GameSession_GameState" json:"state,omitempty" bson:"state,omitempty"`
 	EndgameReason     string                `protobuf:"bytes,15,opt,name=endgame_reason,json=endgameReason,proto3" json:"endgame_reason,omitempty" bson:"endgame_reason,omitempty"`
+	EndgameOutcome    *EndgameOutcome       `protobuf:"bytes,16,opt,name=endgame_outcome,json=endgameOutcome,proto3" json:"endgame_outcome,omitempty" bson:"endgame_outcome,omitempty"`
 	Leader            *Player               `protobuf:"bytes,20,opt,name=leader,proto3" json:"leader,omitempty" bson:"leader,omitempty"`
 	LastMissionResult *MissionResult        `protobuf:"bytes,30,opt,name=last_mission_result,json=lastMissionResult,proto3" json:"last_mission_result,omitempty" bson:"last_mission_result,omitempty"`
+	LastTeamVote      *TeamVoteResult       `protobuf:"bytes,31,opt,name=last_team_vote,json=lastTeamVote,proto3" json:"last_team_vote,omitempty" bson:"last_team_vote,omitempty"`
 	MissionsPassed    int32                 `protobuf:"varint,40,opt,name=missions_passed,json=missionsPassed,proto3" json:"missions_passed,omitempty" bson:"missions_passed,omitempty"`
 	MissionsFailed    int32                 `protobuf:"varint,41,opt,name=missions_failed,json=missionsFailed,proto3" json:"missions_failed,omitempty" bson:"missions_failed,omitempty"`
+	ChatId            int64                 `protobuf:"varint,50,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty" bson:"chat_id,omitempty"`
+	Locale            string                `protobuf:"bytes,51,opt,name=locale,proto3" json:"locale,omitempty" bson:"locale,omitempty"`
 }
 
 func (m *GameSession) Reset()         { *m = GameSession{} }
@@ -207,6 +251,13 @@ func (m *GameSession) GetEndgameReason() string {
 	return ""
 }
 
+func (m *GameSession) GetEndgameOutcome() *EndgameOutcome {
+	if m != nil {
+		return m.EndgameOutcome
+	}
+	return nil
+}
+
 func (m *GameSession) GetLeader() *Player {
 	if m != nil {
 		return m.Leader
@@ -221,6 +272,13 @@ func (m *GameSession) GetLastMissionResult() *MissionResult {
 	return nil
 }
 
+func (m *GameSession) GetLastTeamVote() *TeamVoteResult {
+	if m != nil {
+		return m.LastTeamVote
+	}
+	return nil
+}
+
 func (m *GameSession) GetMissionsPassed() int32 {
 	if m != nil {
 		return m.MissionsPassed
@@ -235,10 +293,110 @@ func (m *GameSession) GetMissionsFailed() int32 {
 	return 0
 }
 
+func (m *GameSession) GetChatId() int64 {
+	if m != nil {
+		return m.ChatId
+	}
+	return 0
+}
+
+func (m *GameSession) GetLocale() string {
+	if m != nil {
+		return m.Locale
+	}
+	return ""
+}
+
+//EndgameOutcome explains why game reached any of *TEAM_WON states
+type EndgameOutcome struct {
+	Reason            EndgameOutcome_Reason `protobuf:"varint,1,opt,name=reason,proto3,enum=proto.EndgameOutcome_Reason" json:"reason,omitempty" bson:"reason,omitempty"`
+	MissionsPassed    int32                 `protobuf:"varint,2,opt,name=missions_passed,json=missionsPassed,proto3" json:"missions_passed,omitempty" bson:"missions_passed,omitempty"`
+	MissionsFailed    int32                 `protobuf:"varint,3,opt,name=missions_failed,json=missionsFailed,proto3" json:"missions_failed,omitempty" bson:"missions_failed,omitempty"`
+	RejectedProposals uint32                `protobuf:"varint,4,opt,name=rejected_proposals,json=rejectedProposals,proto3" json:"rejected_proposals,omitempty" bson:"rejected_proposals,omitempty"`
+	Assassinated      *Player               `protobuf:"bytes,5,opt,name=assassinated,proto3" json:"assassinated,omitempty" bson:"assassinated,omitempty"`
+}
+
+func (m *EndgameOutcome) Reset()         { *m = EndgameOutcome{} }
+func (m *EndgameOutcome) String() string { return proto.CompactTextString(m) }
+func (*EndgameOutcome) ProtoMessage()    {}
+func (*EndgameOutcome) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5befea5ed4f8cd8c, []int{2}
+}
+func (m *EndgameOutcome) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *EndgameOutcome) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_EndgameOutcome.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *EndgameOutcome) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EndgameOutcome.Merge(m, src)
+}
+func (m *EndgameOutcome) XXX_Size() int {
+	return m.Size()
+}
+func (m *EndgameOutcome) XXX_DiscardUnknown() {
+	xxx_messageInfo_EndgameOutcome.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EndgameOutcome proto.InternalMessageInfo
+
+func (m *EndgameOutcome) GetReason() EndgameOutcome_Reason {
+	if m != nil {
+		return m.Reason
+	}
+	return EndgameOutcome_REASON_UNSPECIFIED
+}
+
+func (m *EndgameOutcome) GetMissionsPassed() int32 {
+	if m != nil {
+		return m.MissionsPassed
+	}
+	return 0
+}
+
+func (m *EndgameOutcome) GetMissionsFailed() int32 {
+	if m != nil {
+		return m.MissionsFailed
+	}
+	return 0
+}
+
+func (m *EndgameOutcome) GetRejectedProposals() uint32 {
+	if m != nil {
+		return m.RejectedProposals
+	}
+	return 0
+}
+
+func (m *EndgameOutcome) GetAssassinated() *Player {
+	if m != nil {
+		return m.Assassinated
+	}
+	return nil
+}
+
 //GameConfig holds data about teams and session configuration to create session with
 type GameConfig struct {
-	GoodTeam   *VirtuousTeam   `protobuf:"bytes,10,opt,name=good_team,json=goodTeam,proto3" json:"good_team,omitempty" bson:"good_team,omitempty"`
-	EvilTeam   *EvilTeam       `protobuf:"bytes,20,opt,name=evil_team,json=evilTeam,proto3" json:"evil_team,omitempty" bson:"evil_team,omitempty"`
+	GoodTeam *VirtuousTeam `protobuf:"bytes,10,opt,name=good_team,json=goodTeam,proto3" json:"good_team,omitempty" bson:"good_team,omitempty"`
+	EvilTeam *EvilTeam     `protobuf:"bytes,20,opt,name=evil_team,json=evilTeam,proto3" json:"evil_team,omitempty" bson:"evil_team,omitempty"`
+	ChatId   int64         `protobuf:"varint,30,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty" bson:"chat_id,omitempty"`
+	Locale   string        `protobuf:"bytes,40,opt,name=locale,proto3" json:"locale,omitempty" bson:"locale,omitempty"`
+	Rules    *RuleSet      `protobuf:"bytes,50,opt,name=rules,proto3" json:"rules,omitempty" bson:"rules,omitempty"`
+	//Players to deal roles to instead of good_team and evil_team,
+	//server splits them into teams by the rules using session seed, so dealing is reproducible
+	Players []*Player `protobuf:"bytes,60,rep,name=players,proto3" json:"players,omitempty" bson:"players,omitempty"`
+	//Computer-controlled players seated along with players, marked with Player.bot.
+	//Bots act through the same calls as players, client that asked for them makes their moves.
+	BotPlayers uint32          `protobuf:"varint,70,opt,name=bot_players,json=botPlayers,proto3" json:"bot_players,omitempty" bson:"bot_players,omitempty"`
 	Extensions *GameExtensions `protobuf:"bytes,100,opt,name=extensions,proto3" json:"extensions,omitempty" bson:"extensions,omitempty"`
 }
 
@@ -246,7 +404,7 @@ func (m *GameConfig) Reset()         { *m = GameConfig{} }
 func (m *GameConfig) String() string { return proto.CompactTextString(m) }
 func (*GameConfig) ProtoMessage()    {}
 func (*GameConfig) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{2}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{3}
 }
 func (m *GameConfig) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -289,6 +447,41 @@ func (m *GameConfig) GetEvilTeam() *EvilTeam {
 	return nil
 }
 
+func (m *GameConfig) GetChatId() int64 {
+	if m != nil {
+		return m.ChatId
+	}
+	return 0
+}
+
+func (m *GameConfig) GetLocale() string {
+	if m != nil {
+		return m.Locale
+	}
+	return ""
+}
+
+func (m *GameConfig) GetRules() *RuleSet {
+	if m != nil {
+		return m.Rules
+	}
+	return nil
+}
+
+func (m *GameConfig) GetPlayers() []*Player {
+	if m != nil {
+		return m.Players
+	}
+	return nil
+}
+
+func (m *GameConfig) GetBotPlayers() uint32 {
+	if m != nil {
+		return m.BotPlayers
+	}
+	return 0
+}
+
 func (m *GameConfig) GetExtensions() *GameExtensions {
 	if m != nil {
 		return m.Extensions
@@ -296,7 +489,131 @@ func (m *GameConfig) GetExtensions() *GameExtensions {
 	return nil
 }
 
-//GameExtensions holds flags specifying additional player roles and rules to be used during game session
+//RuleSet overrides built-in rules of the game.
+//Session keeps the complete rules it is played by, including built-in ones.
+type RuleSet struct {
+	PlayerCounts         []*PlayerCountRules `protobuf:"bytes,10,rep,name=player_counts,json=playerCounts,proto3" json:"player_counts,omitempty" bson:"player_counts,omitempty"`
+	MaxRejectedProposals uint32              `protobuf:"varint,20,opt,name=max_rejected_proposals,json=maxRejectedProposals,proto3" json:"max_rejected_proposals,omitempty" bson:"max_rejected_proposals,omitempty"`
+}
+
+func (m *RuleSet) Reset()         { *m = RuleSet{} }
+func (m *RuleSet) String() string { return proto.CompactTextString(m) }
+func (*RuleSet) ProtoMessage()    {}
+func (*RuleSet) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5befea5ed4f8cd8c, []int{4}
+}
+func (m *RuleSet) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RuleSet) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RuleSet.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RuleSet) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RuleSet.Merge(m, src)
+}
+func (m *RuleSet) XXX_Size() int {
+	return m.Size()
+}
+func (m *RuleSet) XXX_DiscardUnknown() {
+	xxx_messageInfo_RuleSet.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RuleSet proto.InternalMessageInfo
+
+func (m *RuleSet) GetPlayerCounts() []*PlayerCountRules {
+	if m != nil {
+		return m.PlayerCounts
+	}
+	return nil
+}
+
+func (m *RuleSet) GetMaxRejectedProposals() uint32 {
+	if m != nil {
+		return m.MaxRejectedProposals
+	}
+	return 0
+}
+
+//PlayerCountRules are rules of a game for specific number of players
+type PlayerCountRules struct {
+	Players       uint32   `protobuf:"varint,10,opt,name=players,proto3" json:"players,omitempty" bson:"players,omitempty"`
+	EvilPlayers   uint32   `protobuf:"varint,20,opt,name=evil_players,json=evilPlayers,proto3" json:"evil_players,omitempty" bson:"evil_players,omitempty"`
+	TeamSizes     []uint32 `protobuf:"varint,30,rep,packed,name=team_sizes,json=teamSizes,proto3" json:"team_sizes,omitempty" bson:"team_sizes,omitempty"`
+	FailsRequired []uint32 `protobuf:"varint,40,rep,packed,name=fails_required,json=failsRequired,proto3" json:"fails_required,omitempty" bson:"fails_required,omitempty"`
+}
+
+func (m *PlayerCountRules) Reset()         { *m = PlayerCountRules{} }
+func (m *PlayerCountRules) String() string { return proto.CompactTextString(m) }
+func (*PlayerCountRules) ProtoMessage()    {}
+func (*PlayerCountRules) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5befea5ed4f8cd8c, []int{5}
+}
+func (m *PlayerCountRules) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PlayerCountRules) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PlayerCountRules.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PlayerCountRules) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlayerCountRules.Merge(m, src)
+}
+func (m *PlayerCountRules) XXX_Size() int {
+	return m.Size()
+}
+func (m *PlayerCountRules) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlayerCountRules.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlayerCountRules proto.InternalMessageInfo
+
+func (m *PlayerCountRules) GetPlayers() uint32 {
+	if m != nil {
+		return m.Players
+	}
+	return 0
+}
+
+func (m *PlayerCountRules) GetEvilPlayers() uint32 {
+	if m != nil {
+		return m.EvilPlayers
+	}
+	return 0
+}
+
+func (m *PlayerCountRules) GetTeamSizes() []uint32 {
+	if m != nil {
+		return m.TeamSizes
+	}
+	return nil
+}
+
+func (m *PlayerCountRules) GetFailsRequired() []uint32 {
+	if m != nil {
+		return m.FailsRequired
+	}
+	return nil
+}
+
+//GameExtensions holds flags specifying additional player roles and rules to be used during game session.
+//Players can't be dealt roles if rules for their number have less evil players than enabled evil roles.
 type GameExtensions struct {
 	//Merlin and assassin are always in game
 	PercivalAndMorgana bool `protobuf:"varint,1,opt,name=percival_and_morgana,json=percivalAndMorgana,proto3" json:"percival_and_morgana,omitempty" bson:"percival_and_morgana,omitempty"`
@@ -308,7 +625,7 @@ func (m *GameExtensions) Reset()         { *m = GameExtensions{} }
 func (m *GameExtensions) String() string { return proto.CompactTextString(m) }
 func (*GameExtensions) ProtoMessage()    {}
 func (*GameExtensions) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{3}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{6}
 }
 func (m *GameExtensions) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -358,16 +675,62 @@ func (m *GameExtensions) GetMordred() bool {
 	return false
 }
 
+//Chat identifies Telegram group chat or channel
+type Chat struct {
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty" bson:"id,omitempty"`
+}
+
+func (m *Chat) Reset()         { *m = Chat{} }
+func (m *Chat) String() string { return proto.CompactTextString(m) }
+func (*Chat) ProtoMessage()    {}
+func (*Chat) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5befea5ed4f8cd8c, []int{7}
+}
+func (m *Chat) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Chat) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Chat.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Chat) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Chat.Merge(m, src)
+}
+func (m *Chat) XXX_Size() int {
+	return m.Size()
+}
+func (m *Chat) XXX_DiscardUnknown() {
+	xxx_messageInfo_Chat.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Chat proto.InternalMessageInfo
+
+func (m *Chat) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
 type Player struct {
 	Id       uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty" bson:"id,omitempty"`
 	UserName string `protobuf:"bytes,10,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty" bson:"user_name,omitempty"`
+	Bot      bool   `protobuf:"varint,20,opt,name=bot,proto3" json:"bot,omitempty" bson:"bot,omitempty"`
 }
 
 func (m *Player) Reset()         { *m = Player{} }
 func (m *Player) String() string { return proto.CompactTextString(m) }
 func (*Player) ProtoMessage()    {}
 func (*Player) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{4}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{8}
 }
 func (m *Player) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -410,19 +773,27 @@ func (m *Player) GetUserName() string {
 	return ""
 }
 
+func (m *Player) GetBot() bool {
+	if m != nil {
+		return m.Bot
+	}
+	return false
+}
+
 type EvilTeam struct {
 	Members  []*Player `protobuf:"bytes,10,rep,name=members,proto3" json:"members,omitempty" bson:"members,omitempty"`
 	Assassin *Player   `protobuf:"bytes,20,opt,name=assassin,proto3" json:"assassin,omitempty" bson:"assassin,omitempty"`
-	//Rest are ignored for now
+	//Rest are dealt when game extensions have the role
 	Oberon  *Player `protobuf:"bytes,30,opt,name=oberon,proto3" json:"oberon,omitempty" bson:"oberon,omitempty"`
 	Morgana *Player `protobuf:"bytes,40,opt,name=morgana,proto3" json:"morgana,omitempty" bson:"morgana,omitempty"`
+	Mordred *Player `protobuf:"bytes,50,opt,name=mordred,proto3" json:"mordred,omitempty" bson:"mordred,omitempty"`
 }
 
 func (m *EvilTeam) Reset()         { *m = EvilTeam{} }
 func (m *EvilTeam) String() string { return proto.CompactTextString(m) }
 func (*EvilTeam) ProtoMessage()    {}
 func (*EvilTeam) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{5}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{9}
 }
 func (m *EvilTeam) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -479,6 +850,13 @@ func (m *EvilTeam) GetMorgana() *Player {
 	return nil
 }
 
+func (m *EvilTeam) GetMordred() *Player {
+	if m != nil {
+		return m.Mordred
+	}
+	return nil
+}
+
 type VirtuousTeam struct {
 	Members  []*Player `protobuf:"bytes,10,rep,name=members,proto3" json:"members,omitempty" bson:"members,omitempty"`
 	Merlin   *Player   `protobuf:"bytes,20,opt,name=merlin,proto3" json:"merlin,omitempty" bson:"merlin,omitempty"`
@@ -489,7 +867,7 @@ func (m *VirtuousTeam) Reset()         { *m = VirtuousTeam{} }
 func (m *VirtuousTeam) String() string { return proto.CompactTextString(m) }
 func (*VirtuousTeam) ProtoMessage()    {}
 func (*VirtuousTeam) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{6}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{10}
 }
 func (m *VirtuousTeam) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -542,13 +920,17 @@ func (m *VirtuousTeam) GetPercival() *Player {
 type PendingMission struct {
 	MissionNumber       uint32 `protobuf:"varint,10,opt,name=mission_number,json=missionNumber,proto3" json:"mission_number,omitempty" bson:"mission_number,omitempty"`
 	TeamPickingAttempts uint32 `protobuf:"varint,20,opt,name=team_picking_attempts,json=teamPickingAttempts,proto3" json:"team_picking_attempts,omitempty" bson:"team_picking_attempts,omitempty"`
+	//Next are set by the rules of the session
+	TeamSize               uint32 `protobuf:"varint,30,opt,name=team_size,json=teamSize,proto3" json:"team_size,omitempty" bson:"team_size,omitempty"`
+	FailsRequired          uint32 `protobuf:"varint,40,opt,name=fails_required,json=failsRequired,proto3" json:"fails_required,omitempty" bson:"fails_required,omitempty"`
+	MaxTeamPickingAttempts uint32 `protobuf:"varint,50,opt,name=max_team_picking_attempts,json=maxTeamPickingAttempts,proto3" json:"max_team_picking_attempts,omitempty" bson:"max_team_picking_attempts,omitempty"`
 }
 
 func (m *PendingMission) Reset()         { *m = PendingMission{} }
 func (m *PendingMission) String() string { return proto.CompactTextString(m) }
 func (*PendingMission) ProtoMessage()    {}
 func (*PendingMission) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{7}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{11}
 }
 func (m *PendingMission) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -591,6 +973,27 @@ func (m *PendingMission) GetTeamPickingAttempts() uint32 {
 	return 0
 }
 
+func (m *PendingMission) GetTeamSize() uint32 {
+	if m != nil {
+		return m.TeamSize
+	}
+	return 0
+}
+
+func (m *PendingMission) GetFailsRequired() uint32 {
+	if m != nil {
+		return m.FailsRequired
+	}
+	return 0
+}
+
+func (m *PendingMission) GetMaxTeamPickingAttempts() uint32 {
+	if m != nil {
+		return m.MaxTeamPickingAttempts
+	}
+	return 0
+}
+
 type MissionTeam struct {
 	Members []*Player `protobuf:"bytes,10,rep,name=members,proto3" json:"members,omitempty" bson:"members,omitempty"`
 }
@@ -599,7 +1002,7 @@ func (m *MissionTeam) Reset()         { *m = MissionTeam{} }
 func (m *MissionTeam) String() string { return proto.CompactTextString(m) }
 func (*MissionTeam) ProtoMessage()    {}
 func (*MissionTeam) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{8}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{12}
 }
 func (m *MissionTeam) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -646,7 +1049,7 @@ func (m *MissionResult) Reset()         { *m = MissionResult{} }
 func (m *MissionResult) String() string { return proto.CompactTextString(m) }
 func (*MissionResult) ProtoMessage()    {}
 func (*MissionResult) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{9}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{13}
 }
 func (m *MissionResult) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -705,7 +1108,7 @@ func (m *AssignTeamContext) Reset()         { *m = AssignTeamContext{} }
 func (m *AssignTeamContext) String() string { return proto.CompactTextString(m) }
 func (*AssignTeamContext) ProtoMessage()    {}
 func (*AssignTeamContext) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{10}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{14}
 }
 func (m *AssignTeamContext) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -748,17 +1151,86 @@ func (m *AssignTeamContext) GetTeam() *MissionTeam {
 	return nil
 }
 
-type VoteContext struct {
-	Session *GameSession           `protobuf:"bytes,10,opt,name=session,proto3" json:"session,omitempty" bson:"session,omitempty"`
-	Voter   *Player                `protobuf:"bytes,20,opt,name=voter,proto3" json:"voter,omitempty" bson:"voter,omitempty"`
-	Vote    VoteContext_VoteOption `protobuf:"varint,30,opt,name=vote,proto3,enum=proto.VoteContext_VoteOption" json:"vote,omitempty" bson:"vote,omitempty"`
+//TeamVoteResult is public outcome of voting for a mission team proposal
+type TeamVoteResult struct {
+	Approved    bool  `protobuf:"varint,10,opt,name=approved,proto3" json:"approved,omitempty" bson:"approved,omitempty"`
+	Approvals   int32 `protobuf:"varint,20,opt,name=approvals,proto3" json:"approvals,omitempty" bson:"approvals,omitempty"`
+	Rejections  int32 `protobuf:"varint,30,opt,name=rejections,proto3" json:"rejections,omitempty" bson:"rejections,omitempty"`
+	Abstentions int32 `protobuf:"varint,40,opt,name=abstentions,proto3" json:"abstentions,omitempty" bson:"abstentions,omitempty"`
 }
 
-func (m *VoteContext) Reset()         { *m = VoteContext{} }
-func (m *VoteContext) String() string { return proto.CompactTextString(m) }
+func (m *TeamVoteResult) Reset()         { *m = TeamVoteResult{} }
+func (m *TeamVoteResult) String() string { return proto.CompactTextString(m) }
+func (*TeamVoteResult) ProtoMessage()    {}
+func (*TeamVoteResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5befea5ed4f8cd8c, []int{15}
+}
+func (m *TeamVoteResult) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TeamVoteResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TeamVoteResult.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TeamVoteResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TeamVoteResult.Merge(m, src)
+}
+func (m *TeamVoteResult) XXX_Size() int {
+	return m.Size()
+}
+func (m *TeamVoteResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_TeamVoteResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TeamVoteResult proto.InternalMessageInfo
+
+func (m *TeamVoteResult) GetApproved() bool {
+	if m != nil {
+		return m.Approved
+	}
+	return false
+}
+
+func (m *TeamVoteResult) GetApprovals() int32 {
+	if m != nil {
+		return m.Approvals
+	}
+	return 0
+}
+
+func (m *TeamVoteResult) GetRejections() int32 {
+	if m != nil {
+		return m.Rejections
+	}
+	return 0
+}
+
+func (m *TeamVoteResult) GetAbstentions() int32 {
+	if m != nil {
+		return m.Abstentions
+	}
+	return 0
+}
+
+type VoteContext struct {
+	Session *GameSession           `protobuf:"bytes,10,opt,name=session,proto3" json:"session,omitempty" bson:"session,omitempty"`
+	Voter   *Player                `protobuf:"bytes,20,opt,name=voter,proto3" json:"voter,omitempty" bson:"voter,omitempty"`
+	Vote    VoteContext_VoteOption `protobuf:"varint,30,opt,name=vote,proto3,enum=proto.VoteContext_VoteOption" json:"vote,omitempty" bson:"vote,omitempty"`
+}
+
+func (m *VoteContext) Reset()         { *m = VoteContext{} }
+func (m *VoteContext) String() string { return proto.CompactTextString(m) }
 func (*VoteContext) ProtoMessage()    {}
 func (*VoteContext) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{11}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{16}
 }
 func (m *VoteContext) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -817,7 +1289,7 @@ func (m *AssassinationContext) Reset()         { *m = AssassinationContext{} }
 func (m *AssassinationContext) String() string { return proto.CompactTextString(m) }
 func (*AssassinationContext) ProtoMessage()    {}
 func (*AssassinationContext) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{12}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{17}
 }
 func (m *AssassinationContext) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -869,7 +1341,7 @@ func (m *AssassinationOutcome) Reset()         { *m = AssassinationOutcome{} }
 func (m *AssassinationOutcome) String() string { return proto.CompactTextString(m) }
 func (*AssassinationOutcome) ProtoMessage()    {}
 func (*AssassinationOutcome) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5befea5ed4f8cd8c, []int{13}
+	return fileDescriptor_5befea5ed4f8cd8c, []int{18}
 }
 func (m *AssassinationOutcome) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -912,13 +1384,155 @@ func (m *AssassinationOutcome) GetMerlinWasKilled() bool {
 	return false
 }
 
+//ListSessionsRequest holds filters for ListSessions, unset filters are ignored
+type ListSessionsRequest struct {
+	States       []GameSession_GameState `protobuf:"varint,1,rep,packed,name=states,proto3,enum=proto.GameSession_GameState" json:"states,omitempty" bson:"states,omitempty"`
+	PlayerId     uint64                  `protobuf:"varint,2,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty" bson:"player_id,omitempty"`
+	ChatId       int64                   `protobuf:"varint,4,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty" bson:"chat_id,omitempty"`
+	CreatedAfter *types.Timestamp        `protobuf:"bytes,3,opt,name=created_after,json=createdAfter,proto3" json:"created_after,omitempty" bson:"created_after,omitempty"`
+	Offset       uint32                  `protobuf:"varint,10,opt,name=offset,proto3" json:"offset,omitempty" bson:"offset,omitempty"`
+	Limit        uint32                  `protobuf:"varint,11,opt,name=limit,proto3" json:"limit,omitempty" bson:"limit,omitempty"`
+}
+
+func (m *ListSessionsRequest) Reset()         { *m = ListSessionsRequest{} }
+func (m *ListSessionsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListSessionsRequest) ProtoMessage()    {}
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5befea5ed4f8cd8c, []int{19}
+}
+func (m *ListSessionsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ListSessionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ListSessionsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ListSessionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListSessionsRequest.Merge(m, src)
+}
+func (m *ListSessionsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ListSessionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListSessionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListSessionsRequest proto.InternalMessageInfo
+
+func (m *ListSessionsRequest) GetStates() []GameSession_GameState {
+	if m != nil {
+		return m.States
+	}
+	return nil
+}
+
+func (m *ListSessionsRequest) GetPlayerId() uint64 {
+	if m != nil {
+		return m.PlayerId
+	}
+	return 0
+}
+
+func (m *ListSessionsRequest) GetChatId() int64 {
+	if m != nil {
+		return m.ChatId
+	}
+	return 0
+}
+
+func (m *ListSessionsRequest) GetCreatedAfter() *types.Timestamp {
+	if m != nil {
+		return m.CreatedAfter
+	}
+	return nil
+}
+
+func (m *ListSessionsRequest) GetOffset() uint32 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *ListSessionsRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type ListSessionsResponse struct {
+	Sessions []*GameSession `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty" bson:"sessions,omitempty"`
+	HasMore  bool           `protobuf:"varint,2,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty" bson:"has_more,omitempty"`
+}
+
+func (m *ListSessionsResponse) Reset()         { *m = ListSessionsResponse{} }
+func (m *ListSessionsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListSessionsResponse) ProtoMessage()    {}
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5befea5ed4f8cd8c, []int{20}
+}
+func (m *ListSessionsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ListSessionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ListSessionsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ListSessionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListSessionsResponse.Merge(m, src)
+}
+func (m *ListSessionsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ListSessionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListSessionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListSessionsResponse proto.InternalMessageInfo
+
+func (m *ListSessionsResponse) GetSessions() []*GameSession {
+	if m != nil {
+		return m.Sessions
+	}
+	return nil
+}
+
+func (m *ListSessionsResponse) GetHasMore() bool {
+	if m != nil {
+		return m.HasMore
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterEnum("proto.GameSession_GameState", GameSession_GameState_name, GameSession_GameState_value)
+	proto.RegisterEnum("proto.EndgameOutcome_Reason", EndgameOutcome_Reason_name, EndgameOutcome_Reason_value)
 	proto.RegisterEnum("proto.VoteContext_VoteOption", VoteContext_VoteOption_name, VoteContext_VoteOption_value)
 	proto.RegisterType((*UUID)(nil), "proto.UUID")
 	proto.RegisterType((*GameSession)(nil), "proto.GameSession")
+	proto.RegisterType((*EndgameOutcome)(nil), "proto.EndgameOutcome")
 	proto.RegisterType((*GameConfig)(nil), "proto.GameConfig")
+	proto.RegisterType((*RuleSet)(nil), "proto.RuleSet")
+	proto.RegisterType((*PlayerCountRules)(nil), "proto.PlayerCountRules")
 	proto.RegisterType((*GameExtensions)(nil), "proto.GameExtensions")
+	proto.RegisterType((*Chat)(nil), "proto.Chat")
 	proto.RegisterType((*Player)(nil), "proto.Player")
 	proto.RegisterType((*EvilTeam)(nil), "proto.EvilTeam")
 	proto.RegisterType((*VirtuousTeam)(nil), "proto.VirtuousTeam")
@@ -926,91 +1540,140 @@ func init() {
 	proto.RegisterType((*MissionTeam)(nil), "proto.MissionTeam")
 	proto.RegisterType((*MissionResult)(nil), "proto.MissionResult")
 	proto.RegisterType((*AssignTeamContext)(nil), "proto.AssignTeamContext")
+	proto.RegisterType((*TeamVoteResult)(nil), "proto.TeamVoteResult")
 	proto.RegisterType((*VoteContext)(nil), "proto.VoteContext")
 	proto.RegisterType((*AssassinationContext)(nil), "proto.AssassinationContext")
 	proto.RegisterType((*AssassinationOutcome)(nil), "proto.AssassinationOutcome")
+	proto.RegisterType((*ListSessionsRequest)(nil), "proto.ListSessionsRequest")
+	proto.RegisterType((*ListSessionsResponse)(nil), "proto.ListSessionsResponse")
 }
 
 func init() { proto.RegisterFile("avalonGame.proto", fileDescriptor_5befea5ed4f8cd8c) }
 
 var fileDescriptor_5befea5ed4f8cd8c = []byte{
-	// 1212 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0xcb, 0x6e, 0xdb, 0x46,
-	0x17, 0x16, 0x13, 0x5b, 0xb6, 0x8f, 0x22, 0x59, 0x1a, 0xcb, 0xfe, 0x19, 0x27, 0x11, 0x0c, 0xfe,
-	0x75, 0xe3, 0x14, 0x81, 0x93, 0xaa, 0x4d, 0xd1, 0x16, 0x5d, 0x54, 0x91, 0x19, 0x41, 0x48, 0x2d,
-	0x09, 0x94, 0xac, 0x00, 0xdd, 0x10, 0x63, 0xf3, 0x44, 0x25, 0xcc, 0x8b, 0xc0, 0x19, 0xa9, 0xc9,
-	0x23, 0x74, 0x57, 0xa0, 0x40, 0x36, 0x5d, 0x76, 0xdf, 0x37, 0xe8, 0xb6, 0xe8, 0x32, 0xcb, 0x2e,
-	0x8b, 0xe4, 0x45, 0x8a, 0xb9, 0x50, 0x97, 0x98, 0x4e, 0xeb, 0xae, 0xc8, 0x39, 0xdf, 0x77, 0x6e,
-	0xc3, 0xf9, 0xce, 0x10, 0xca, 0x74, 0x4a, 0x83, 0x38, 0x6a, 0xd1, 0x10, 0x0f, 0xc7, 0x49, 0xcc,
-	0x63, 0xb2, 0x2a, 0x1f, 0xbb, 0xb7, 0x46, 0x71, 0x3c, 0x0a, 0xf0, 0x81, 0x5c, 0x9d, 0x4e, 0x9e,
-	0x3f, 0xc0, 0x70, 0xcc, 0x5f, 0x2a, 0x8e, 0x75, 0x1b, 0x56, 0x4e, 0x4e, 0xda, 0x47, 0xa4, 0x0a,
-	0xab, 0x53, 0x1a, 0x4c, 0xd0, 0x34, 0xf6, 0x8c, 0x83, 0x0d, 0x47, 0x2d, 0xac, 0x57, 0x2b, 0x50,
-	0x10, 0x01, 0xfb, 0xc8, 0x98, 0x1f, 0x47, 0xe4, 0x03, 0x58, 0x1b, 0xd1, 0x10, 0x5d, 0xdf, 0x93,
-	0xbc, 0x42, 0xbd, 0xa0, 0xc2, 0x1c, 0x8a, 0x18, 0x4e, 0x5e, 0x60, 0x6d, 0x8f, 0xd4, 0x61, 0x95,
-	0x71, 0xca, 0xd1, 0x84, 0x3d, 0xe3, 0xa0, 0x54, 0xbf, 0xad, 0x39, 0x0b, 0x81, 0xd4, 0xbb, 0xe0,
-	0x38, 0x8a, 0x4a, 0xf6, 0xa1, 0x84, 0x91, 0x27, 0x83, 0x27, 0x48, 0x59, 0x1c, 0x99, 0x9b, 0xb2,
-	0x90, 0xa2, 0xb6, 0x3a, 0xd2, 0x48, 0xf6, 0x21, 0x1f, 0x20, 0xf5, 0x30, 0x31, 0xab, 0x32, 0x7f,
-	0x51, 0xc7, 0xee, 0x05, 0xf4, 0x25, 0x26, 0x8e, 0x06, 0xc9, 0x11, 0x6c, 0x05, 0x94, 0x71, 0x37,
-	0xf4, 0x65, 0x3a, 0x37, 0x41, 0x36, 0x09, 0xb8, 0x59, 0x93, 0x3e, 0x55, 0xed, 0x73, 0xac, 0x40,
-	0x47, 0x62, 0x4e, 0x45, 0x38, 0x2c, 0x99, 0xc8, 0x5d, 0xd8, 0xd4, 0x01, 0x98, 0x3b, 0xa6, 0x8c,
-	0xa1, 0x67, 0x1e, 0xec, 0x19, 0x07, 0xab, 0x4e, 0x29, 0x35, 0xf7, 0xa4, 0x75, 0x89, 0xf8, 0x9c,
-	0xfa, 0x01, 0x7a, 0xe6, 0xbd, 0x65, 0xe2, 0x13, 0x69, 0xb5, 0x7e, 0x37, 0x60, 0x63, 0xd6, 0x3a,
-	0x29, 0xc3, 0x8d, 0x56, 0xe3, 0xd8, 0x76, 0x9b, 0x8e, 0xdd, 0x18, 0xd8, 0x47, 0xe5, 0x1c, 0x31,
-	0xa1, 0x7a, 0xdc, 0xee, 0xf7, 0xdb, 0xdd, 0x8e, 0x3b, 0xb0, 0x1b, 0xc7, 0x6e, 0xaf, 0xdd, 0x7c,
-	0xda, 0xee, 0xb4, 0xca, 0x55, 0xf2, 0x3f, 0xd8, 0x5a, 0x42, 0x86, 0xdd, 0x81, 0x00, 0x6e, 0x92,
-	0x5d, 0xd8, 0x49, 0x81, 0xfe, 0x49, 0xb3, 0x69, 0xf7, 0xfb, 0x29, 0xb6, 0x4b, 0x2a, 0x50, 0x4c,
-	0x31, 0xbb, 0x73, 0x64, 0x1f, 0x95, 0x6b, 0xe4, 0x26, 0x6c, 0xf7, 0xba, 0xfd, 0x81, 0xab, 0xed,
-	0x7d, 0xb7, 0xd1, 0x1c, 0x88, 0x67, 0xd9, 0x23, 0x3b, 0x50, 0x19, 0xb6, 0x9d, 0xc1, 0x49, 0xf7,
-	0xa4, 0xaf, 0x72, 0x3c, 0xeb, 0x76, 0xca, 0xaf, 0x0c, 0x42, 0xa0, 0x68, 0x0f, 0xdb, 0xdf, 0xcc,
-	0x6d, 0x3f, 0x1b, 0xd6, 0x2f, 0x06, 0x80, 0x68, 0xa4, 0x19, 0x47, 0xcf, 0xfd, 0x11, 0x79, 0x08,
-	0x1b, 0xa3, 0x38, 0xf6, 0x5c, 0x8e, 0x34, 0x94, 0x5f, 0xbd, 0x50, 0xdf, 0xd2, 0xbb, 0x3c, 0xf4,
-	0x13, 0x3e, 0x89, 0x27, 0x6c, 0x80, 0x34, 0x74, 0xd6, 0x05, 0x4b, 0xbc, 0x91, 0xfb, 0xb0, 0x81,
-	0x53, 0x3f, 0x50, 0x1e, 0xea, 0x5b, 0x6e, 0x6a, 0x0f, 0x7b, 0xea, 0x07, 0x8a, 0x8d, 0xfa, 0x8d,
-	0x3c, 0x02, 0xc0, 0x17, 0x1c, 0x23, 0xb9, 0x97, 0xa6, 0x27, 0xe9, 0xdb, 0x0b, 0xc7, 0xca, 0x9e,
-	0x81, 0xce, 0x02, 0xd1, 0xe2, 0x50, 0x5a, 0x46, 0xc9, 0x43, 0xa8, 0x8e, 0x31, 0x39, 0xf3, 0xa7,
-	0x34, 0x70, 0x69, 0xe4, 0xb9, 0x61, 0x9c, 0x8c, 0x68, 0x44, 0xe5, 0x69, 0x5e, 0x77, 0x48, 0x8a,
-	0x35, 0x22, 0xef, 0x58, 0x21, 0x64, 0x07, 0xf2, 0xf1, 0x29, 0x26, 0x71, 0x64, 0x5e, 0x93, 0x1c,
-	0xbd, 0x22, 0x26, 0xac, 0x85, 0x71, 0xe2, 0x25, 0xe8, 0x99, 0xd7, 0x25, 0x90, 0x2e, 0xad, 0x47,
-	0x90, 0x57, 0xc7, 0x91, 0x94, 0xe0, 0x9a, 0x56, 0xca, 0x8a, 0x73, 0xcd, 0xf7, 0xc8, 0x2d, 0xd8,
-	0x98, 0x30, 0x4c, 0xdc, 0x88, 0x86, 0x4a, 0x1c, 0x1b, 0xce, 0xba, 0x30, 0x74, 0x68, 0x88, 0xd6,
-	0xaf, 0x06, 0xac, 0xa7, 0xad, 0x93, 0xbb, 0xb0, 0x16, 0x62, 0x78, 0x8a, 0x09, 0x33, 0x61, 0xef,
-	0xfa, 0xc5, 0x83, 0x9e, 0xa2, 0xe4, 0x1e, 0xac, 0x53, 0xc6, 0x28, 0x63, 0x7e, 0x94, 0x2d, 0x89,
-	0x19, 0x2c, 0xb4, 0xa3, 0x3b, 0xa9, 0x65, 0x6a, 0x47, 0x37, 0x76, 0x57, 0x36, 0x26, 0x77, 0xe5,
-	0x20, 0x8b, 0x97, 0xa2, 0xd6, 0x0f, 0x06, 0xdc, 0x58, 0xfc, 0xba, 0xff, 0xbe, 0xe8, 0x7d, 0xc8,
-	0x87, 0x98, 0x04, 0x97, 0x95, 0xac, 0x41, 0xd1, 0x5b, 0xfa, 0x41, 0xb2, 0x4b, 0x9e, 0xc1, 0xd6,
-	0x39, 0x94, 0x7a, 0x18, 0x79, 0x7e, 0x34, 0xd2, 0x12, 0x16, 0x03, 0x25, 0x55, 0x7f, 0x34, 0x11,
-	0x69, 0xe5, 0x86, 0x17, 0x9d, 0xa2, 0xb6, 0x76, 0xa4, 0x91, 0xd4, 0x61, 0x5b, 0x1c, 0x41, 0x77,
-	0xec, 0x9f, 0x9d, 0xfb, 0xd1, 0xc8, 0xa5, 0x9c, 0x8b, 0xf1, 0xc8, 0x64, 0x65, 0x45, 0x67, 0x4b,
-	0x80, 0x3d, 0x85, 0x35, 0x34, 0x64, 0x7d, 0x06, 0x05, 0x9d, 0xe5, 0x4a, 0x6d, 0x5b, 0x13, 0x28,
-	0x2e, 0x0f, 0x98, 0x1d, 0xc8, 0xeb, 0x71, 0x01, 0xea, 0x6c, 0xa9, 0x95, 0xa8, 0x7d, 0x1c, 0x33,
-	0x9f, 0xfb, 0x53, 0x74, 0xa7, 0x31, 0x47, 0x55, 0xcd, 0xaa, 0x53, 0x4c, 0xad, 0x43, 0x61, 0x14,
-	0xb4, 0x08, 0x47, 0x74, 0x81, 0x56, 0x53, 0xb4, 0xd4, 0x2a, 0x69, 0x96, 0x0f, 0x95, 0x06, 0x63,
-	0xfe, 0x48, 0x56, 0xdb, 0x8c, 0x23, 0x8e, 0x2f, 0x38, 0xb9, 0x0f, 0x6b, 0x4c, 0xcd, 0x62, 0xad,
-	0x57, 0x72, 0x71, 0x4a, 0x3b, 0x29, 0x85, 0x7c, 0x08, 0x2b, 0x0b, 0x42, 0x25, 0xcb, 0x03, 0x54,
-	0x6a, 0x55, 0xe2, 0xd6, 0x6f, 0x06, 0x14, 0x44, 0xd2, 0xff, 0x96, 0xe5, 0xff, 0xb0, 0x2a, 0xda,
-	0xb8, 0x64, 0xb6, 0x2b, 0x8c, 0x7c, 0x0c, 0x2b, 0xe2, 0x45, 0xb6, 0x5a, 0xaa, 0xdf, 0x49, 0xa7,
-	0xcc, 0x3c, 0xa9, 0x7c, 0xef, 0x8e, 0xb9, 0x08, 0x2d, 0xa9, 0xd6, 0x01, 0xc0, 0xdc, 0x46, 0x6e,
-	0xc0, 0x7a, 0xc7, 0x6e, 0x35, 0x06, 0xed, 0xa1, 0x5d, 0xce, 0x89, 0x55, 0xaf, 0xdb, 0x6f, 0xcb,
-	0x95, 0x61, 0x9d, 0x43, 0xb5, 0xa1, 0xe5, 0x42, 0x05, 0x39, 0xa3, 0x0f, 0xe3, 0x9f, 0xfb, 0xd8,
-	0x87, 0x3c, 0xa7, 0xc9, 0x08, 0xb9, 0x1c, 0x19, 0x17, 0x8f, 0xb7, 0x02, 0xad, 0xf1, 0x3b, 0xc9,
-	0xba, 0x13, 0x7e, 0x16, 0x87, 0x78, 0xc5, 0x64, 0x1f, 0x41, 0x45, 0xc9, 0xc5, 0xfd, 0x9e, 0x32,
-	0xf7, 0xdc, 0x0f, 0xc4, 0x71, 0x52, 0xa3, 0x6a, 0x53, 0x01, 0xcf, 0x28, 0x7b, 0x2a, 0xcd, 0xf5,
-	0x9f, 0xf2, 0xe9, 0x75, 0x9e, 0x4c, 0xfd, 0x33, 0x24, 0x9f, 0x43, 0xb1, 0x99, 0x20, 0xe5, 0xb3,
-	0xfb, 0xbd, 0xb2, 0x90, 0x49, 0x8d, 0xf6, 0xdd, 0x8c, 0xe4, 0x56, 0x8e, 0x7c, 0x0d, 0xe5, 0x01,
-	0x26, 0xa1, 0xa8, 0x7c, 0xe6, 0x9c, 0xc1, 0xdc, 0xdd, 0x39, 0x54, 0x3f, 0x1f, 0x87, 0xe9, 0xcf,
-	0xc7, 0xa1, 0x2d, 0x7e, 0x3e, 0xac, 0x1c, 0x79, 0x00, 0xd0, 0x42, 0x9e, 0xfa, 0x2e, 0xfe, 0x47,
-	0x5c, 0x92, 0xf2, 0x53, 0x28, 0xb4, 0x90, 0xcf, 0x26, 0x64, 0x56, 0xb6, 0x77, 0x6f, 0x10, 0x2b,
-	0x47, 0xbe, 0x82, 0xcd, 0x16, 0xf2, 0xa5, 0x31, 0x95, 0xe5, 0x99, 0x75, 0x5b, 0x59, 0x39, 0xf2,
-	0x05, 0x14, 0x7b, 0x13, 0xf6, 0xdd, 0xfc, 0xca, 0xce, 0xf2, 0xbd, 0x6c, 0x87, 0x2a, 0x2d, 0xe4,
-	0xef, 0x0c, 0xa5, 0x2c, 0xf7, 0xf4, 0x1e, 0x5b, 0xa6, 0x5a, 0x39, 0xd2, 0x4a, 0x75, 0xbb, 0x38,
-	0x6c, 0x4c, 0xcd, 0xbe, 0xa0, 0xe8, 0xf7, 0x6c, 0xf5, 0x97, 0x50, 0x6a, 0x21, 0x5f, 0x8c, 0xf2,
-	0xbe, 0x36, 0x16, 0x78, 0x56, 0x8e, 0x3c, 0x06, 0x22, 0xb4, 0xf3, 0x24, 0x4e, 0xb2, 0xfc, 0x17,
-	0x64, 0xf7, 0x9e, 0xfc, 0x36, 0x6c, 0x2f, 0xc7, 0xe8, 0x4f, 0xce, 0xce, 0x90, 0xb1, 0x2b, 0x86,
-	0x19, 0x82, 0x39, 0xd7, 0x0b, 0x36, 0x82, 0x00, 0x47, 0xe8, 0x1d, 0xab, 0xab, 0xe2, 0xd6, 0x7c,
-	0x5b, 0x2e, 0xa8, 0x77, 0x37, 0x13, 0xd4, 0x6a, 0xb3, 0x72, 0x8f, 0xef, 0xfc, 0xf1, 0xa6, 0x66,
-	0xbc, 0x7e, 0x53, 0x33, 0xfe, 0x7a, 0x53, 0x33, 0x7e, 0x7c, 0x5b, 0xcb, 0xbd, 0x7e, 0x5b, 0xcb,
-	0xfd, 0xf9, 0xb6, 0x96, 0xfb, 0xf6, 0x3a, 0x1d, 0xfb, 0xa7, 0x79, 0xe9, 0xfc, 0xc9, 0xdf, 0x01,
-	0x00, 0x00, 0xff, 0xff, 0x80, 0x63, 0xf8, 0xb8, 0x60, 0x0b, 0x00, 0x00,
+	// 1946 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x57, 0xcd, 0x73, 0x1b, 0x49,
+	0x15, 0xd7, 0x58, 0x96, 0x2c, 0x3f, 0x59, 0xb2, 0xdc, 0x76, 0x9c, 0x89, 0x92, 0x28, 0x66, 0xd8,
+	0x10, 0x2f, 0xb5, 0x38, 0xbb, 0xda, 0x2c, 0xc5, 0x42, 0x0a, 0x98, 0xc8, 0x63, 0x95, 0xd8, 0x58,
+	0x52, 0xf5, 0xc8, 0xde, 0x2a, 0x2e, 0x53, 0x6d, 0xa9, 0xad, 0x0c, 0x99, 0x0f, 0x31, 0xdd, 0x32,
+	0x59, 0xaa, 0xf8, 0x03, 0xa8, 0xe2, 0xc0, 0x05, 0xa8, 0x02, 0xfe, 0x9c, 0x2d, 0x8a, 0xe2, 0xb4,
+	0x37, 0x38, 0x52, 0xc9, 0x8d, 0x13, 0x7f, 0x02, 0xd5, 0x1f, 0x23, 0x6b, 0xec, 0x71, 0x76, 0xc3,
+	0x69, 0xa6, 0xdf, 0xef, 0xd7, 0x1f, 0xef, 0xf5, 0xfb, 0x6a, 0x68, 0x90, 0x0b, 0x12, 0xc4, 0x51,
+	0x97, 0x84, 0xf4, 0x60, 0x96, 0xc4, 0x3c, 0x46, 0x25, 0xf9, 0x69, 0xde, 0x9d, 0xc6, 0xf1, 0x34,
+	0xa0, 0x8f, 0xe5, 0xe8, 0x6c, 0x7e, 0xfe, 0x98, 0x86, 0x33, 0xfe, 0x85, 0xe2, 0x34, 0x1f, 0x5c,
+	0x05, 0xb9, 0x1f, 0x52, 0xc6, 0x49, 0x38, 0x53, 0x04, 0xeb, 0x1e, 0xac, 0x9e, 0x9c, 0xf4, 0x0e,
+	0xd1, 0x0e, 0x94, 0x2e, 0x48, 0x30, 0xa7, 0xa6, 0xb1, 0x67, 0xec, 0xaf, 0x63, 0x35, 0xb0, 0xbe,
+	0x2c, 0x41, 0x55, 0xec, 0xe8, 0x52, 0xc6, 0xfc, 0x38, 0x42, 0xef, 0xc1, 0xda, 0x94, 0x84, 0xd4,
+	0xf3, 0x27, 0x92, 0x57, 0x6d, 0x57, 0xd5, 0x32, 0x07, 0x62, 0x0d, 0x5c, 0x16, 0x58, 0x6f, 0x82,
+	0xda, 0x50, 0x62, 0x9c, 0x70, 0x6a, 0xc2, 0x9e, 0xb1, 0x5f, 0x6f, 0xdf, 0xd3, 0x9c, 0xa5, 0x85,
+	0xd4, 0xbf, 0xe0, 0x60, 0x45, 0x45, 0x0f, 0xa1, 0x4e, 0xa3, 0x89, 0x5c, 0x3c, 0xa1, 0x84, 0xc5,
+	0x91, 0xb9, 0x29, 0x0f, 0x52, 0xd3, 0x52, 0x2c, 0x85, 0xe8, 0xc7, 0xb0, 0x99, 0xd2, 0xe2, 0x39,
+	0x1f, 0xc7, 0x21, 0x35, 0x1b, 0xf2, 0x20, 0xb7, 0xf4, 0x26, 0x8e, 0x42, 0x07, 0x0a, 0xc4, 0x75,
+	0x9a, 0x19, 0xa3, 0x87, 0x50, 0x0e, 0x28, 0x99, 0xd0, 0xc4, 0xdc, 0x91, 0xd3, 0x6a, 0x7a, 0xda,
+	0x30, 0x20, 0x5f, 0xd0, 0x04, 0x6b, 0x10, 0x1d, 0xc2, 0x76, 0x40, 0x18, 0xf7, 0x42, 0x5f, 0x1e,
+	0xd7, 0x4b, 0x28, 0x9b, 0x07, 0xdc, 0x6c, 0xc9, 0x39, 0x3b, 0x7a, 0xce, 0xb1, 0x02, 0xb1, 0xc4,
+	0xf0, 0x96, 0x98, 0x90, 0x11, 0xa1, 0x1f, 0x41, 0x5d, 0xae, 0xc2, 0x29, 0x09, 0xbd, 0x8b, 0x98,
+	0x53, 0xf3, 0x41, 0xe6, 0xac, 0x23, 0x4a, 0xc2, 0xd3, 0x98, 0x53, 0xbd, 0xc2, 0x86, 0x20, 0xa7,
+	0x32, 0xf4, 0x08, 0x36, 0xf5, 0xee, 0xcc, 0x9b, 0x11, 0xc6, 0xe8, 0xc4, 0xdc, 0xdf, 0x33, 0xf6,
+	0x4b, 0xb8, 0x9e, 0x8a, 0x87, 0x52, 0x9a, 0x21, 0x9e, 0x13, 0x3f, 0xa0, 0x13, 0xf3, 0xfd, 0x2c,
+	0xf1, 0x48, 0x4a, 0xd1, 0x6d, 0x58, 0x1b, 0xbf, 0x20, 0x5c, 0x5c, 0x5e, 0x7b, 0xcf, 0xd8, 0x2f,
+	0xe2, 0xb2, 0x18, 0xf6, 0x26, 0x68, 0x17, 0xca, 0x41, 0x3c, 0x26, 0x01, 0x35, 0x3f, 0x96, 0x36,
+	0xd7, 0x23, 0xeb, 0x6f, 0x06, 0xac, 0x2f, 0x2e, 0x0a, 0x35, 0x60, 0xa3, 0x6b, 0x1f, 0x3b, 0x5e,
+	0x07, 0x3b, 0xf6, 0xc8, 0x39, 0x6c, 0x14, 0x90, 0x09, 0x3b, 0xc7, 0x3d, 0xd7, 0xed, 0x0d, 0xfa,
+	0xde, 0xc8, 0xb1, 0x8f, 0xbd, 0x61, 0xaf, 0xf3, 0x59, 0xaf, 0xdf, 0x6d, 0xec, 0xa0, 0xdb, 0xb0,
+	0x9d, 0x41, 0x4e, 0x07, 0x23, 0x01, 0xdc, 0x41, 0x4d, 0xd8, 0x4d, 0x01, 0xf7, 0xa4, 0xd3, 0x71,
+	0x5c, 0x37, 0xc5, 0x9a, 0x68, 0x0b, 0x6a, 0x29, 0xe6, 0xf4, 0x0f, 0x9d, 0xc3, 0x46, 0x0b, 0xdd,
+	0x81, 0x5b, 0xc3, 0x81, 0x3b, 0xf2, 0xb4, 0xdc, 0xf5, 0xec, 0xce, 0x48, 0x7c, 0x1b, 0xe2, 0xd0,
+	0x5b, 0xa7, 0x3d, 0x3c, 0x3a, 0x19, 0x9c, 0xb8, 0x6a, 0x8f, 0xcf, 0x07, 0xfd, 0xc6, 0x1f, 0x0d,
+	0x84, 0xa0, 0xe6, 0x9c, 0xf6, 0x9e, 0x5f, 0xca, 0xfe, 0x62, 0x58, 0x7f, 0x2a, 0x42, 0x3d, 0xeb,
+	0x18, 0xe8, 0x09, 0x94, 0xb5, 0x9f, 0x19, 0x19, 0x27, 0xcd, 0xd2, 0x0e, 0x94, 0xdb, 0x61, 0xcd,
+	0xcd, 0xbb, 0x94, 0x95, 0x6f, 0x7a, 0x29, 0xc5, 0xdc, 0x4b, 0xf9, 0x1e, 0xa0, 0x84, 0xfe, 0x82,
+	0x8e, 0x39, 0x9d, 0x78, 0xb3, 0x24, 0x9e, 0xc5, 0x8c, 0x04, 0xcc, 0x5c, 0xdd, 0x33, 0xf6, 0x6b,
+	0x78, 0x2b, 0x45, 0x86, 0x29, 0x80, 0x3e, 0x82, 0x0d, 0xc2, 0x18, 0x61, 0xcc, 0x8f, 0x08, 0xa7,
+	0x13, 0xb3, 0x94, 0xe7, 0xc5, 0x19, 0x8a, 0xf5, 0x67, 0x03, 0xca, 0x3a, 0x7a, 0x76, 0x01, 0x61,
+	0xc7, 0x76, 0x07, 0x7d, 0xef, 0xa4, 0xef, 0x0e, 0x9d, 0x4e, 0xef, 0xa8, 0x27, 0x2f, 0x72, 0x1b,
+	0x36, 0x17, 0x16, 0x1e, 0xda, 0xae, 0xeb, 0x1c, 0x36, 0x8c, 0x8c, 0xf0, 0xc8, 0xee, 0x3d, 0x77,
+	0x0e, 0x1b, 0x2b, 0xe8, 0x2e, 0xdc, 0x56, 0x57, 0x8d, 0x07, 0xc3, 0x81, 0x6b, 0x3f, 0x77, 0x3d,
+	0xec, 0xfc, 0xcc, 0xe9, 0x08, 0x7f, 0x28, 0xca, 0x5b, 0x77, 0xf0, 0xf3, 0x5e, 0xdf, 0xb3, 0x5d,
+	0xd7, 0x76, 0xdd, 0x5e, 0x5f, 0x3a, 0xca, 0xaa, 0x70, 0x94, 0x4b, 0x89, 0xb8, 0x5f, 0xbd, 0x5e,
+	0xc9, 0xfa, 0x72, 0x05, 0x40, 0xb8, 0x58, 0x27, 0x8e, 0xce, 0xfd, 0x29, 0xfa, 0x10, 0xd6, 0xa7,
+	0x71, 0x3c, 0x91, 0x11, 0x23, 0xb3, 0x47, 0xb5, 0xbd, 0xad, 0x75, 0x3b, 0xf5, 0x13, 0x3e, 0x8f,
+	0xe7, 0x4c, 0x04, 0x08, 0xae, 0x08, 0x96, 0xf8, 0x43, 0x1f, 0xc0, 0x3a, 0xbd, 0xf0, 0x03, 0x35,
+	0x43, 0xc5, 0xf4, 0x66, 0x7a, 0x95, 0x17, 0x7e, 0xa0, 0xd8, 0x54, 0xff, 0x2d, 0x87, 0x40, 0xeb,
+	0x86, 0x10, 0xd8, 0x5f, 0x0e, 0x01, 0xf4, 0x1e, 0x94, 0x92, 0x79, 0x40, 0x99, 0x8c, 0x98, 0x6a,
+	0xbb, 0xae, 0x97, 0xc6, 0xf3, 0x80, 0xba, 0x94, 0x63, 0x05, 0xa2, 0x47, 0xb0, 0x36, 0x93, 0xa6,
+	0x67, 0xe6, 0xd3, 0xbd, 0xe2, 0xf5, 0x0b, 0x49, 0x51, 0xf4, 0x00, 0xaa, 0x67, 0x31, 0xf7, 0x52,
+	0xf2, 0x91, 0xbc, 0x66, 0x38, 0x8b, 0xf9, 0x50, 0x13, 0x3e, 0x01, 0xa0, 0xaf, 0x38, 0x8d, 0xa4,
+	0x8b, 0x98, 0x93, 0x4c, 0xba, 0x10, 0x76, 0x72, 0x16, 0x20, 0x5e, 0x22, 0x5a, 0xbf, 0x81, 0x35,
+	0x7d, 0x24, 0xf4, 0x14, 0x6a, 0x6a, 0x79, 0x6f, 0x1c, 0xcf, 0x23, 0xce, 0x4c, 0x90, 0x27, 0xba,
+	0x9d, 0x39, 0x51, 0x47, 0x40, 0x62, 0x06, 0xc3, 0x1b, 0xb3, 0x4b, 0x09, 0x43, 0x4f, 0x60, 0x37,
+	0x24, 0xaf, 0xbc, 0x1c, 0x97, 0xdc, 0x91, 0x67, 0xdd, 0x09, 0xc9, 0x2b, 0x7c, 0xd5, 0x2b, 0xad,
+	0x3f, 0x18, 0xd0, 0xb8, 0xba, 0x30, 0x32, 0x2f, 0x8d, 0x02, 0x72, 0x6e, 0x3a, 0x44, 0xdf, 0x82,
+	0x0d, 0x79, 0x67, 0x29, 0xac, 0x96, 0xae, 0x0a, 0x59, 0x6a, 0x87, 0xfb, 0x00, 0x32, 0x6b, 0x32,
+	0xff, 0xd7, 0x94, 0x99, 0xad, 0xbd, 0xe2, 0x7e, 0x0d, 0xaf, 0x0b, 0x89, 0x2b, 0x04, 0xa2, 0x5a,
+	0x88, 0xa8, 0x62, 0x5e, 0x42, 0x7f, 0x39, 0xf7, 0x13, 0x99, 0x1b, 0x05, 0xa5, 0x26, 0xa5, 0x58,
+	0x0b, 0x2d, 0x0e, 0xf5, 0xac, 0xd1, 0xd0, 0x87, 0xb0, 0x33, 0xa3, 0xc9, 0xd8, 0xbf, 0x20, 0x81,
+	0x47, 0xa2, 0x89, 0x17, 0xc6, 0xc9, 0x94, 0x44, 0x44, 0x26, 0x81, 0x0a, 0x46, 0x29, 0x66, 0x47,
+	0x93, 0x63, 0x85, 0x08, 0xcf, 0x88, 0xcf, 0x68, 0x12, 0x47, 0x32, 0xd2, 0x2b, 0x58, 0x8f, 0x84,
+	0x7a, 0x61, 0x9c, 0x4c, 0x12, 0x1d, 0xd9, 0x15, 0x9c, 0x0e, 0xad, 0x5d, 0x58, 0xed, 0xbc, 0x20,
+	0x1c, 0xd5, 0x61, 0x45, 0xd7, 0xc9, 0x22, 0x5e, 0xf1, 0x27, 0x56, 0x17, 0xca, 0x4a, 0xbd, 0x25,
+	0x64, 0x55, 0x20, 0xe8, 0x2e, 0xac, 0xcf, 0x19, 0x4d, 0xbc, 0x88, 0x84, 0xaa, 0x68, 0xae, 0xe3,
+	0x8a, 0x10, 0xf4, 0x49, 0x28, 0xf2, 0x6e, 0xf1, 0x2c, 0xe6, 0xd2, 0x48, 0x15, 0x2c, 0x7e, 0xad,
+	0x7f, 0x1a, 0x50, 0x49, 0x9d, 0x5b, 0xf8, 0x5e, 0x48, 0xc3, 0x33, 0x9a, 0xa4, 0x37, 0x7d, 0xd5,
+	0xf7, 0x34, 0x8a, 0xde, 0x87, 0x4a, 0x9a, 0x17, 0xf2, 0x8b, 0xdf, 0x02, 0x16, 0x55, 0x52, 0xeb,
+	0xdc, 0xca, 0xad, 0x92, 0xda, 0x04, 0x8f, 0xa4, 0x09, 0xa4, 0xfd, 0xf6, 0xf3, 0x78, 0x29, 0xaa,
+	0x89, 0xd2, 0x56, 0xed, 0x9b, 0x88, 0xd2, 0x74, 0xbf, 0x35, 0x60, 0x63, 0x39, 0xd0, 0xbf, 0xb9,
+	0x76, 0x0f, 0xa1, 0x1c, 0xd2, 0x24, 0xb8, 0x49, 0x37, 0x0d, 0x0a, 0x23, 0xa4, 0x77, 0x9c, 0xaf,
+	0xdb, 0x02, 0xb6, 0xfe, 0x63, 0x40, 0x7d, 0x48, 0xa3, 0x89, 0x1f, 0x4d, 0x75, 0x59, 0x17, 0x6e,
+	0x97, 0x76, 0x04, 0xd1, 0x5c, 0xec, 0xab, 0x3d, 0xbb, 0xa6, 0xa5, 0x7d, 0x29, 0x44, 0x6d, 0xb8,
+	0x25, 0x9d, 0x77, 0xe6, 0x8f, 0x5f, 0xfa, 0xd1, 0xd4, 0x23, 0x9c, 0x8b, 0x9e, 0x2c, 0x75, 0xf4,
+	0x6d, 0x01, 0x0e, 0x15, 0x66, 0x6b, 0x48, 0xb8, 0xc0, 0xc2, 0xe1, 0xe5, 0xc9, 0x6a, 0xb8, 0x92,
+	0xfa, 0x7b, 0xae, 0xbb, 0x1b, 0xd7, 0xdc, 0x1d, 0x7d, 0x0a, 0x77, 0x44, 0xf0, 0xe6, 0xef, 0xdd,
+	0x96, 0x33, 0x44, 0x74, 0x8f, 0xae, 0x6f, 0x6f, 0x7d, 0x1f, 0xaa, 0x5a, 0xc9, 0x77, 0x32, 0xbb,
+	0x35, 0x87, 0x5a, 0xb6, 0xe7, 0xd9, 0x85, 0xb2, 0xae, 0x77, 0xa0, 0xc2, 0x45, 0x8d, 0x84, 0x0a,
+	0xb3, 0x98, 0xf9, 0xdc, 0xbf, 0xa0, 0xb2, 0x15, 0x52, 0xc6, 0x28, 0xe1, 0x5a, 0x2a, 0x15, 0x4d,
+	0x8f, 0x0c, 0xec, 0x88, 0x4e, 0xc9, 0x12, 0xad, 0xa5, 0x68, 0xa9, 0x54, 0xd2, 0x2c, 0x1f, 0xb6,
+	0x6c, 0xc6, 0xfc, 0xa9, 0x3c, 0x6d, 0x27, 0x8e, 0x38, 0x7d, 0xc5, 0xd1, 0x07, 0xb0, 0xc6, 0x54,
+	0x7b, 0xa9, 0x4b, 0x07, 0xba, 0xde, 0x78, 0xe2, 0x94, 0x82, 0xbe, 0x03, 0xab, 0x4b, 0x35, 0x03,
+	0x65, 0x7b, 0x3a, 0x59, 0x36, 0x24, 0x6e, 0xfd, 0xce, 0x80, 0x7a, 0xb6, 0x51, 0x43, 0x4d, 0xa8,
+	0x90, 0xd9, 0x2c, 0x89, 0x2f, 0x16, 0x5a, 0x2e, 0xc6, 0xe8, 0x1e, 0xac, 0xab, 0xff, 0x34, 0x67,
+	0x96, 0xf0, 0xa5, 0x00, 0xb5, 0x00, 0x54, 0x6a, 0x95, 0xe9, 0x5d, 0xa9, 0xb6, 0x24, 0x41, 0x7b,
+	0x50, 0x25, 0x67, 0x8c, 0xd3, 0x48, 0x11, 0x54, 0xc3, 0xb7, 0x2c, 0xb2, 0xfe, 0x61, 0x40, 0x55,
+	0x1c, 0xe5, 0xff, 0x53, 0xfa, 0xdb, 0x50, 0x12, 0x56, 0xbd, 0xa1, 0xfb, 0x55, 0x18, 0xfa, 0x08,
+	0x56, 0xc5, 0x8f, 0x3c, 0x5e, 0xbd, 0x7d, 0x3f, 0xad, 0xbf, 0x97, 0x9b, 0xca, 0xff, 0xc1, 0x4c,
+	0x1c, 0x08, 0x4b, 0xaa, 0xf5, 0x09, 0xc0, 0xa5, 0x0c, 0x6d, 0x40, 0xa5, 0xef, 0x74, 0xed, 0x51,
+	0xef, 0xd4, 0x69, 0x14, 0xc4, 0x68, 0x38, 0x70, 0x7b, 0x72, 0x64, 0xa0, 0x2a, 0xac, 0xd9, 0xcf,
+	0xdc, 0x91, 0xdd, 0xeb, 0x37, 0x56, 0xac, 0x97, 0xb0, 0x63, 0x2f, 0x5a, 0x15, 0x3f, 0x8e, 0x72,
+	0x94, 0x32, 0xbe, 0x5e, 0xa9, 0x87, 0x50, 0xe6, 0x24, 0x99, 0x52, 0x6e, 0xae, 0xe4, 0x69, 0xa5,
+	0x41, 0x6b, 0x76, 0x65, 0xb3, 0xb4, 0x13, 0x7c, 0xb7, 0xcd, 0xbe, 0x0b, 0x5b, 0x2a, 0x95, 0x78,
+	0xbf, 0x22, 0xcc, 0x7b, 0xe9, 0x07, 0x81, 0xee, 0x01, 0x2b, 0x78, 0x53, 0x01, 0x9f, 0x13, 0xf6,
+	0x99, 0x14, 0x5b, 0xff, 0x35, 0x60, 0xfb, 0xb9, 0xcf, 0xb8, 0x5e, 0x44, 0x06, 0x2a, 0x65, 0x5c,
+	0xf4, 0x9e, 0xf2, 0xd1, 0xc3, 0x4c, 0x63, 0xaf, 0xf8, 0xb5, 0x0f, 0x24, 0xcd, 0x15, 0x19, 0x42,
+	0x17, 0x76, 0x5f, 0xed, 0xb8, 0x8a, 0x2b, 0x4a, 0xd0, 0xcb, 0xf4, 0xf6, 0xab, 0x99, 0xc6, 0xe6,
+	0x27, 0x50, 0x1b, 0x27, 0x54, 0x34, 0x82, 0x1e, 0x39, 0x17, 0x37, 0x5f, 0x94, 0x3a, 0x36, 0x0f,
+	0xd4, 0xc3, 0xf0, 0x20, 0x7d, 0x18, 0x1e, 0x8c, 0xd2, 0x87, 0x21, 0xde, 0xd0, 0x13, 0x6c, 0xc1,
+	0x97, 0xf5, 0xef, 0xfc, 0x9c, 0x51, 0xae, 0x73, 0x9d, 0x1e, 0x89, 0x07, 0x63, 0xe0, 0x87, 0x3e,
+	0x37, 0xab, 0x52, 0xac, 0x06, 0x16, 0x81, 0x9d, 0xac, 0xc6, 0x6c, 0x16, 0x47, 0x8c, 0xa2, 0x03,
+	0xa8, 0x68, 0x0b, 0x2a, 0xa5, 0xf3, 0xad, 0xbc, 0xe0, 0xa0, 0x3b, 0x50, 0x79, 0x41, 0x98, 0x28,
+	0xcf, 0x54, 0x5b, 0x77, 0xed, 0x05, 0x61, 0xc7, 0x71, 0x42, 0xdb, 0x7f, 0x5d, 0x4b, 0xdf, 0xa4,
+	0xc9, 0x85, 0x3f, 0xa6, 0xe8, 0x07, 0x50, 0xeb, 0xc8, 0x03, 0xeb, 0x55, 0xd0, 0xd6, 0xd2, 0xca,
+	0xaa, 0xaf, 0x6c, 0xe6, 0x6c, 0x66, 0x15, 0xd0, 0x4f, 0xa1, 0x31, 0xa2, 0x49, 0x28, 0xfb, 0xe4,
+	0x74, 0x72, 0x0e, 0xb3, 0xb9, 0x7b, 0xcd, 0x58, 0x8e, 0x78, 0x62, 0x5b, 0x05, 0xf4, 0x18, 0xa0,
+	0x4b, 0x53, 0x6d, 0xd1, 0xf2, 0x63, 0xf8, 0x86, 0x2d, 0x9f, 0x40, 0xb5, 0x4b, 0xf9, 0xa2, 0x78,
+	0xe7, 0xed, 0x76, 0xb5, 0x7d, 0xb5, 0x0a, 0xe8, 0x29, 0x6c, 0x76, 0x29, 0xcf, 0x14, 0xc6, 0xbc,
+	0x99, 0x79, 0xad, 0xb2, 0x55, 0x40, 0x9f, 0x42, 0x6d, 0x38, 0x67, 0x2f, 0x2e, 0x5f, 0x72, 0x79,
+	0x73, 0x6f, 0xb2, 0xd0, 0x56, 0x97, 0xf2, 0x2b, 0x55, 0x30, 0x6f, 0x7a, 0xda, 0xa3, 0x66, 0xa9,
+	0x56, 0x01, 0x75, 0xd3, 0x4c, 0xbd, 0x5c, 0x5e, 0x4c, 0xcd, 0xbe, 0x96, 0xc3, 0xdf, 0x62, 0xea,
+	0x1f, 0x42, 0xbd, 0x4b, 0xf9, 0xf2, 0x2a, 0x6f, 0x53, 0x63, 0x89, 0x67, 0x15, 0xd0, 0x33, 0x40,
+	0x22, 0x3d, 0x1d, 0xc5, 0x49, 0xde, 0xfc, 0xa5, 0xcc, 0xf6, 0x96, 0xfd, 0x1d, 0xb8, 0x95, 0x5d,
+	0xc3, 0x9d, 0x8f, 0xc7, 0x94, 0xb1, 0x77, 0x5c, 0xe6, 0x14, 0xcc, 0xcb, 0x2c, 0x44, 0xed, 0x20,
+	0xa0, 0x53, 0x3a, 0x39, 0x56, 0xcd, 0xc9, 0xdd, 0x4b, 0xb3, 0x5c, 0xcb, 0x89, 0xcd, 0x5c, 0x50,
+	0xe7, 0x30, 0xab, 0x80, 0x7a, 0xb0, 0xb1, 0x1c, 0x78, 0xa8, 0xa9, 0xe9, 0x39, 0xf9, 0xa7, 0x79,
+	0x37, 0x17, 0x53, 0x91, 0x2a, 0xbd, 0xed, 0x76, 0x97, 0x72, 0x7b, 0x2c, 0xca, 0xad, 0x86, 0x8f,
+	0xe2, 0x44, 0xb6, 0xb4, 0xa9, 0x87, 0x8b, 0x41, 0xbe, 0xcb, 0x3c, 0xbb, 0xff, 0xf7, 0xd7, 0x2d,
+	0xe3, 0xab, 0xd7, 0x2d, 0xe3, 0xdf, 0xaf, 0x5b, 0xc6, 0xef, 0xdf, 0xb4, 0x0a, 0x5f, 0xbd, 0x69,
+	0x15, 0xfe, 0xf5, 0xa6, 0x55, 0xf8, 0x79, 0x91, 0xcc, 0xfc, 0xb3, 0xb2, 0x9c, 0xf3, 0xf1, 0xff,
+	0x06, 0x00, 0xe8, 0xf3, 0x4e, 0x37, 0xcf, 0x12, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1056,6 +1719,12 @@ type GameServiceClient interface {
 	//Returns an AssassinationOutcome, that reports if killed player was merlin.
 	//GameState in response is determining which team won the game, will ether be VIRTUOUS_TEAM_WON or EVIL_TEAM_WON
 	AssassinateAllegedMerlin(ctx context.Context, in *AssassinationContext, opts ...grpc.CallOption) (*AssassinationOutcome, error)
+	//ListSessions returns sessions matching all of the provided filters, oldest first.
+	//Use it to find games of specific player without knowing their UUIDs.
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	//GetActiveSessionForChat returns a session, that is not finished yet, created for specified chat.
+	//There could be only one such session per chat.
+	GetActiveSessionForChat(ctx context.Context, in *Chat, opts ...grpc.CallOption) (*GameSession, error)
 }
 
 type gameServiceClient struct {
@@ -1174,6 +1843,24 @@ func (c *gameServiceClient) AssassinateAllegedMerlin(ctx context.Context, in *As
 	return out, nil
 }
 
+func (c *gameServiceClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, "/proto.GameService/ListSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameServiceClient) GetActiveSessionForChat(ctx context.Context, in *Chat, opts ...grpc.CallOption) (*GameSession, error) {
+	out := new(GameSession)
+	err := c.cc.Invoke(ctx, "/proto.GameService/GetActiveSessionForChat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // GameServiceServer is the server API for GameService service.
 type GameServiceServer interface {
 	//CreateSession with specified players and options.
@@ -1207,6 +1894,12 @@ type GameServiceServer interface {
 	//Returns an AssassinationOutcome, that reports if killed player was merlin.
 	//GameState in response is determining which team won the game, will ether be VIRTUOUS_TEAM_WON or EVIL_TEAM_WON
 	AssassinateAllegedMerlin(context.Context, *AssassinationContext) (*AssassinationOutcome, error)
+	//ListSessions returns sessions matching all of the provided filters, oldest first.
+	//Use it to find games of specific player without knowing their UUIDs.
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	//GetActiveSessionForChat returns a session, that is not finished yet, created for specified chat.
+	//There could be only one such session per chat.
+	GetActiveSessionForChat(context.Context, *Chat) (*GameSession, error)
 }
 
 // UnimplementedGameServiceServer can be embedded to have forward compatible implementations.
@@ -1249,6 +1942,12 @@ func (*UnimplementedGameServiceServer) VoteForMissionSuccess(ctx context.Context
 func (*UnimplementedGameServiceServer) AssassinateAllegedMerlin(ctx context.Context, req *AssassinationContext) (*AssassinationOutcome, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AssassinateAllegedMerlin not implemented")
 }
+func (*UnimplementedGameServiceServer) ListSessions(ctx context.Context, req *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (*UnimplementedGameServiceServer) GetActiveSessionForChat(ctx context.Context, req *Chat) (*GameSession, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetActiveSessionForChat not implemented")
+}
 
 func RegisterGameServiceServer(s *grpc.Server, srv GameServiceServer) {
 	s.RegisterService(&_GameService_serviceDesc, srv)
@@ -1470,6 +2169,42 @@ func _GameService_AssassinateAllegedMerlin_Handler(srv interface{}, ctx context.
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GameService_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GameServiceServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GameService/ListSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GameServiceServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GameService_GetActiveSessionForChat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Chat)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GameServiceServer).GetActiveSessionForChat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GameService/GetActiveSessionForChat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GameServiceServer).GetActiveSessionForChat(ctx, req.(*Chat))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _GameService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.GameService",
 	HandlerType: (*GameServiceServer)(nil),
@@ -1522,6 +2257,14 @@ var _GameService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "AssassinateAllegedMerlin",
 			Handler:    _GameService_AssassinateAllegedMerlin_Handler,
 		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _GameService_ListSessions_Handler,
+		},
+		{
+			MethodName: "GetActiveSessionForChat",
+			Handler:    _GameService_GetActiveSessionForChat_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "avalonGame.proto",
@@ -1577,6 +2320,22 @@ func (m *GameSession) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Locale) > 0 {
+		i -= len(m.Locale)
+		copy(dAtA[i:], m.Locale)
+		i = encodeVarintAvalonGame(dAtA, i, uint64(len(m.Locale)))
+		i--
+		dAtA[i] = 0x3
+		i--
+		dAtA[i] = 0x9a
+	}
+	if m.ChatId != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.ChatId))
+		i--
+		dAtA[i] = 0x3
+		i--
+		dAtA[i] = 0x90
+	}
 	if m.MissionsFailed != 0 {
 		i = encodeVarintAvalonGame(dAtA, i, uint64(m.MissionsFailed))
 		i--
@@ -1591,6 +2350,20 @@ func (m *GameSession) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0xc0
 	}
+	if m.LastTeamVote != nil {
+		{
+			size, err := m.LastTeamVote.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xfa
+	}
 	if m.LastMissionResult != nil {
 		{
 			size, err := m.LastMissionResult.MarshalToSizedBuffer(dAtA[:i])
@@ -1619,6 +2392,20 @@ func (m *GameSession) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0xa2
 	}
+	if m.EndgameOutcome != nil {
+		{
+			size, err := m.EndgameOutcome.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x82
+	}
 	if len(m.EndgameReason) > 0 {
 		i -= len(m.EndgameReason)
 		copy(dAtA[i:], m.EndgameReason)
@@ -1646,7 +2433,7 @@ func (m *GameSession) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *GameConfig) Marshal() (dAtA []byte, err error) {
+func (m *EndgameOutcome) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -1656,19 +2443,19 @@ func (m *GameConfig) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *GameConfig) MarshalTo(dAtA []byte) (int, error) {
+func (m *EndgameOutcome) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *GameConfig) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *EndgameOutcome) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.Extensions != nil {
+	if m.Assassinated != nil {
 		{
-			size, err := m.Extensions.MarshalToSizedBuffer(dAtA[:i])
+			size, err := m.Assassinated.MarshalToSizedBuffer(dAtA[:i])
 			if err != nil {
 				return 0, err
 			}
@@ -1676,40 +2463,32 @@ func (m *GameConfig) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
 		}
 		i--
-		dAtA[i] = 0x6
+		dAtA[i] = 0x2a
+	}
+	if m.RejectedProposals != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.RejectedProposals))
 		i--
-		dAtA[i] = 0xa2
+		dAtA[i] = 0x20
 	}
-	if m.EvilTeam != nil {
-		{
-			size, err := m.EvilTeam.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
-		}
+	if m.MissionsFailed != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.MissionsFailed))
 		i--
-		dAtA[i] = 0x1
+		dAtA[i] = 0x18
+	}
+	if m.MissionsPassed != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.MissionsPassed))
 		i--
-		dAtA[i] = 0xa2
+		dAtA[i] = 0x10
 	}
-	if m.GoodTeam != nil {
-		{
-			size, err := m.GoodTeam.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
-		}
+	if m.Reason != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.Reason))
 		i--
-		dAtA[i] = 0x52
+		dAtA[i] = 0x8
 	}
 	return len(dAtA) - i, nil
 }
 
-func (m *GameExtensions) Marshal() (dAtA []byte, err error) {
+func (m *GameConfig) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -1719,24 +2498,259 @@ func (m *GameExtensions) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *GameExtensions) MarshalTo(dAtA []byte) (int, error) {
+func (m *GameConfig) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *GameExtensions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *GameConfig) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.Mordred {
-		i--
-		if m.Mordred {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i--
+	if m.Extensions != nil {
+		{
+			size, err := m.Extensions.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x6
+		i--
+		dAtA[i] = 0xa2
+	}
+	if m.BotPlayers != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.BotPlayers))
+		i--
+		dAtA[i] = 0x4
+		i--
+		dAtA[i] = 0xb0
+	}
+	if len(m.Players) > 0 {
+		for iNdEx := len(m.Players) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Players[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAvalonGame(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3
+			i--
+			dAtA[i] = 0xe2
+		}
+	}
+	if m.Rules != nil {
+		{
+			size, err := m.Rules.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3
+		i--
+		dAtA[i] = 0x92
+	}
+	if len(m.Locale) > 0 {
+		i -= len(m.Locale)
+		copy(dAtA[i:], m.Locale)
+		i = encodeVarintAvalonGame(dAtA, i, uint64(len(m.Locale)))
+		i--
+		dAtA[i] = 0x2
+		i--
+		dAtA[i] = 0xc2
+	}
+	if m.ChatId != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.ChatId))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xf0
+	}
+	if m.EvilTeam != nil {
+		{
+			size, err := m.EvilTeam.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xa2
+	}
+	if m.GoodTeam != nil {
+		{
+			size, err := m.GoodTeam.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x52
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RuleSet) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RuleSet) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RuleSet) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.MaxRejectedProposals != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.MaxRejectedProposals))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xa0
+	}
+	if len(m.PlayerCounts) > 0 {
+		for iNdEx := len(m.PlayerCounts) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.PlayerCounts[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAvalonGame(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x52
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PlayerCountRules) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PlayerCountRules) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PlayerCountRules) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.FailsRequired) > 0 {
+		dAtA12 := make([]byte, len(m.FailsRequired)*10)
+		var j11 int
+		for _, num := range m.FailsRequired {
+			for num >= 1<<7 {
+				dAtA12[j11] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j11++
+			}
+			dAtA12[j11] = uint8(num)
+			j11++
+		}
+		i -= j11
+		copy(dAtA[i:], dAtA12[:j11])
+		i = encodeVarintAvalonGame(dAtA, i, uint64(j11))
+		i--
+		dAtA[i] = 0x2
+		i--
+		dAtA[i] = 0xc2
+	}
+	if len(m.TeamSizes) > 0 {
+		dAtA14 := make([]byte, len(m.TeamSizes)*10)
+		var j13 int
+		for _, num := range m.TeamSizes {
+			for num >= 1<<7 {
+				dAtA14[j13] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j13++
+			}
+			dAtA14[j13] = uint8(num)
+			j13++
+		}
+		i -= j13
+		copy(dAtA[i:], dAtA14[:j13])
+		i = encodeVarintAvalonGame(dAtA, i, uint64(j13))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xf2
+	}
+	if m.EvilPlayers != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.EvilPlayers))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xa0
+	}
+	if m.Players != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.Players))
+		i--
+		dAtA[i] = 0x50
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GameExtensions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GameExtensions) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GameExtensions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Mordred {
+		i--
+		if m.Mordred {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
 		dAtA[i] = 0x18
 	}
 	if m.Oberon {
@@ -1762,6 +2776,34 @@ func (m *GameExtensions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *Chat) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Chat) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Chat) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Id != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.Id))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *Player) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -1782,6 +2824,18 @@ func (m *Player) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Bot {
+		i--
+		if m.Bot {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xa0
+	}
 	if len(m.UserName) > 0 {
 		i -= len(m.UserName)
 		copy(dAtA[i:], m.UserName)
@@ -1817,6 +2871,20 @@ func (m *EvilTeam) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Mordred != nil {
+		{
+			size, err := m.Mordred.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3
+		i--
+		dAtA[i] = 0x92
+	}
 	if m.Morgana != nil {
 		{
 			size, err := m.Morgana.MarshalToSizedBuffer(dAtA[:i])
@@ -1961,6 +3029,27 @@ func (m *PendingMission) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.MaxTeamPickingAttempts != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.MaxTeamPickingAttempts))
+		i--
+		dAtA[i] = 0x3
+		i--
+		dAtA[i] = 0x90
+	}
+	if m.FailsRequired != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.FailsRequired))
+		i--
+		dAtA[i] = 0x2
+		i--
+		dAtA[i] = 0xc0
+	}
+	if m.TeamSize != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.TeamSize))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xf0
+	}
 	if m.TeamPickingAttempts != 0 {
 		i = encodeVarintAvalonGame(dAtA, i, uint64(m.TeamPickingAttempts))
 		i--
@@ -2109,7 +3198,7 @@ func (m *AssignTeamContext) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *VoteContext) Marshal() (dAtA []byte, err error) {
+func (m *TeamVoteResult) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -2119,30 +3208,84 @@ func (m *VoteContext) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *VoteContext) MarshalTo(dAtA []byte) (int, error) {
+func (m *TeamVoteResult) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *VoteContext) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *TeamVoteResult) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.Vote != 0 {
-		i = encodeVarintAvalonGame(dAtA, i, uint64(m.Vote))
+	if m.Abstentions != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.Abstentions))
+		i--
+		dAtA[i] = 0x2
+		i--
+		dAtA[i] = 0xc0
+	}
+	if m.Rejections != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.Rejections))
 		i--
 		dAtA[i] = 0x1
 		i--
 		dAtA[i] = 0xf0
 	}
-	if m.Voter != nil {
-		{
-			size, err := m.Voter.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
+	if m.Approvals != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.Approvals))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xa0
+	}
+	if m.Approved {
+		i--
+		if m.Approved {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x50
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *VoteContext) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *VoteContext) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *VoteContext) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Vote != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.Vote))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xf0
+	}
+	if m.Voter != nil {
+		{
+			size, err := m.Voter.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
 			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
 		}
 		i--
@@ -2257,6 +3400,126 @@ func (m *AssassinationOutcome) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *ListSessionsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ListSessionsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ListSessionsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Limit != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x58
+	}
+	if m.Offset != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.Offset))
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.ChatId != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.ChatId))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.CreatedAfter != nil {
+		{
+			size, err := m.CreatedAfter.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAvalonGame(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.PlayerId != 0 {
+		i = encodeVarintAvalonGame(dAtA, i, uint64(m.PlayerId))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.States) > 0 {
+		dAtA30 := make([]byte, len(m.States)*10)
+		var j29 int
+		for _, num := range m.States {
+			for num >= 1<<7 {
+				dAtA30[j29] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j29++
+			}
+			dAtA30[j29] = uint8(num)
+			j29++
+		}
+		i -= j29
+		copy(dAtA[i:], dAtA30[:j29])
+		i = encodeVarintAvalonGame(dAtA, i, uint64(j29))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ListSessionsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ListSessionsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ListSessionsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.HasMore {
+		i--
+		if m.HasMore {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Sessions) > 0 {
+		for iNdEx := len(m.Sessions) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Sessions[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAvalonGame(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintAvalonGame(dAtA []byte, offset int, v uint64) int {
 	offset -= sovAvalonGame(v)
 	base := offset
@@ -2298,6 +3561,10 @@ func (m *GameSession) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovAvalonGame(uint64(l))
 	}
+	if m.EndgameOutcome != nil {
+		l = m.EndgameOutcome.Size()
+		n += 2 + l + sovAvalonGame(uint64(l))
+	}
 	if m.Leader != nil {
 		l = m.Leader.Size()
 		n += 2 + l + sovAvalonGame(uint64(l))
@@ -2306,12 +3573,48 @@ func (m *GameSession) Size() (n int) {
 		l = m.LastMissionResult.Size()
 		n += 2 + l + sovAvalonGame(uint64(l))
 	}
+	if m.LastTeamVote != nil {
+		l = m.LastTeamVote.Size()
+		n += 2 + l + sovAvalonGame(uint64(l))
+	}
 	if m.MissionsPassed != 0 {
 		n += 2 + sovAvalonGame(uint64(m.MissionsPassed))
 	}
 	if m.MissionsFailed != 0 {
 		n += 2 + sovAvalonGame(uint64(m.MissionsFailed))
 	}
+	if m.ChatId != 0 {
+		n += 2 + sovAvalonGame(uint64(m.ChatId))
+	}
+	l = len(m.Locale)
+	if l > 0 {
+		n += 2 + l + sovAvalonGame(uint64(l))
+	}
+	return n
+}
+
+func (m *EndgameOutcome) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Reason != 0 {
+		n += 1 + sovAvalonGame(uint64(m.Reason))
+	}
+	if m.MissionsPassed != 0 {
+		n += 1 + sovAvalonGame(uint64(m.MissionsPassed))
+	}
+	if m.MissionsFailed != 0 {
+		n += 1 + sovAvalonGame(uint64(m.MissionsFailed))
+	}
+	if m.RejectedProposals != 0 {
+		n += 1 + sovAvalonGame(uint64(m.RejectedProposals))
+	}
+	if m.Assassinated != nil {
+		l = m.Assassinated.Size()
+		n += 1 + l + sovAvalonGame(uint64(l))
+	}
 	return n
 }
 
@@ -2329,6 +3632,26 @@ func (m *GameConfig) Size() (n int) {
 		l = m.EvilTeam.Size()
 		n += 2 + l + sovAvalonGame(uint64(l))
 	}
+	if m.ChatId != 0 {
+		n += 2 + sovAvalonGame(uint64(m.ChatId))
+	}
+	l = len(m.Locale)
+	if l > 0 {
+		n += 2 + l + sovAvalonGame(uint64(l))
+	}
+	if m.Rules != nil {
+		l = m.Rules.Size()
+		n += 2 + l + sovAvalonGame(uint64(l))
+	}
+	if len(m.Players) > 0 {
+		for _, e := range m.Players {
+			l = e.Size()
+			n += 2 + l + sovAvalonGame(uint64(l))
+		}
+	}
+	if m.BotPlayers != 0 {
+		n += 2 + sovAvalonGame(uint64(m.BotPlayers))
+	}
 	if m.Extensions != nil {
 		l = m.Extensions.Size()
 		n += 2 + l + sovAvalonGame(uint64(l))
@@ -2336,6 +3659,53 @@ func (m *GameConfig) Size() (n int) {
 	return n
 }
 
+func (m *RuleSet) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.PlayerCounts) > 0 {
+		for _, e := range m.PlayerCounts {
+			l = e.Size()
+			n += 1 + l + sovAvalonGame(uint64(l))
+		}
+	}
+	if m.MaxRejectedProposals != 0 {
+		n += 2 + sovAvalonGame(uint64(m.MaxRejectedProposals))
+	}
+	return n
+}
+
+func (m *PlayerCountRules) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Players != 0 {
+		n += 1 + sovAvalonGame(uint64(m.Players))
+	}
+	if m.EvilPlayers != 0 {
+		n += 2 + sovAvalonGame(uint64(m.EvilPlayers))
+	}
+	if len(m.TeamSizes) > 0 {
+		l = 0
+		for _, e := range m.TeamSizes {
+			l += sovAvalonGame(uint64(e))
+		}
+		n += 2 + sovAvalonGame(uint64(l)) + l
+	}
+	if len(m.FailsRequired) > 0 {
+		l = 0
+		for _, e := range m.FailsRequired {
+			l += sovAvalonGame(uint64(e))
+		}
+		n += 2 + sovAvalonGame(uint64(l)) + l
+	}
+	return n
+}
+
 func (m *GameExtensions) Size() (n int) {
 	if m == nil {
 		return 0
@@ -2354,6 +3724,18 @@ func (m *GameExtensions) Size() (n int) {
 	return n
 }
 
+func (m *Chat) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Id != 0 {
+		n += 1 + sovAvalonGame(uint64(m.Id))
+	}
+	return n
+}
+
 func (m *Player) Size() (n int) {
 	if m == nil {
 		return 0
@@ -2367,6 +3749,9 @@ func (m *Player) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovAvalonGame(uint64(l))
 	}
+	if m.Bot {
+		n += 3
+	}
 	return n
 }
 
@@ -2394,6 +3779,10 @@ func (m *EvilTeam) Size() (n int) {
 		l = m.Morgana.Size()
 		n += 2 + l + sovAvalonGame(uint64(l))
 	}
+	if m.Mordred != nil {
+		l = m.Mordred.Size()
+		n += 2 + l + sovAvalonGame(uint64(l))
+	}
 	return n
 }
 
@@ -2432,6 +3821,15 @@ func (m *PendingMission) Size() (n int) {
 	if m.TeamPickingAttempts != 0 {
 		n += 2 + sovAvalonGame(uint64(m.TeamPickingAttempts))
 	}
+	if m.TeamSize != 0 {
+		n += 2 + sovAvalonGame(uint64(m.TeamSize))
+	}
+	if m.FailsRequired != 0 {
+		n += 2 + sovAvalonGame(uint64(m.FailsRequired))
+	}
+	if m.MaxTeamPickingAttempts != 0 {
+		n += 2 + sovAvalonGame(uint64(m.MaxTeamPickingAttempts))
+	}
 	return n
 }
 
@@ -2485,6 +3883,27 @@ func (m *AssignTeamContext) Size() (n int) {
 	return n
 }
 
+func (m *TeamVoteResult) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Approved {
+		n += 2
+	}
+	if m.Approvals != 0 {
+		n += 2 + sovAvalonGame(uint64(m.Approvals))
+	}
+	if m.Rejections != 0 {
+		n += 2 + sovAvalonGame(uint64(m.Rejections))
+	}
+	if m.Abstentions != 0 {
+		n += 2 + sovAvalonGame(uint64(m.Abstentions))
+	}
+	return n
+}
+
 func (m *VoteContext) Size() (n int) {
 	if m == nil {
 		return 0
@@ -2538,6 +3957,56 @@ func (m *AssassinationOutcome) Size() (n int) {
 	return n
 }
 
+func (m *ListSessionsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.States) > 0 {
+		l = 0
+		for _, e := range m.States {
+			l += sovAvalonGame(uint64(e))
+		}
+		n += 1 + sovAvalonGame(uint64(l)) + l
+	}
+	if m.PlayerId != 0 {
+		n += 1 + sovAvalonGame(uint64(m.PlayerId))
+	}
+	if m.CreatedAfter != nil {
+		l = m.CreatedAfter.Size()
+		n += 1 + l + sovAvalonGame(uint64(l))
+	}
+	if m.ChatId != 0 {
+		n += 1 + sovAvalonGame(uint64(m.ChatId))
+	}
+	if m.Offset != 0 {
+		n += 1 + sovAvalonGame(uint64(m.Offset))
+	}
+	if m.Limit != 0 {
+		n += 1 + sovAvalonGame(uint64(m.Limit))
+	}
+	return n
+}
+
+func (m *ListSessionsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Sessions) > 0 {
+		for _, e := range m.Sessions {
+			l = e.Size()
+			n += 1 + l + sovAvalonGame(uint64(l))
+		}
+	}
+	if m.HasMore {
+		n += 2
+	}
+	return n
+}
+
 func sovAvalonGame(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -2745,9 +4214,9 @@ func (m *GameSession) Unmarshal(dAtA []byte) error {
 			}
 			m.EndgameReason = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 20:
+		case 16:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Leader", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field EndgameOutcome", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2774,8 +4243,44 @@ func (m *GameSession) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Leader == nil {
-				m.Leader = &Player{}
+			if m.EndgameOutcome == nil {
+				m.EndgameOutcome = &EndgameOutcome{}
+			}
+			if err := m.EndgameOutcome.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 20:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Leader", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Leader == nil {
+				m.Leader = &Player{}
 			}
 			if err := m.Leader.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
@@ -2783,7 +4288,1415 @@ func (m *GameSession) Unmarshal(dAtA []byte) error {
 			iNdEx = postIndex
 		case 30:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LastMissionResult", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field LastMissionResult", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.LastMissionResult == nil {
+				m.LastMissionResult = &MissionResult{}
+			}
+			if err := m.LastMissionResult.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 31:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LastTeamVote", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.LastTeamVote == nil {
+				m.LastTeamVote = &TeamVoteResult{}
+			}
+			if err := m.LastTeamVote.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 40:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MissionsPassed", wireType)
+			}
+			m.MissionsPassed = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MissionsPassed |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 41:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MissionsFailed", wireType)
+			}
+			m.MissionsFailed = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MissionsFailed |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 50:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChatId", wireType)
+			}
+			m.ChatId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChatId |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 51:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Locale", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Locale = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAvalonGame(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *EndgameOutcome) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAvalonGame
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: EndgameOutcome: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: EndgameOutcome: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Reason", wireType)
+			}
+			m.Reason = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Reason |= EndgameOutcome_Reason(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MissionsPassed", wireType)
+			}
+			m.MissionsPassed = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MissionsPassed |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MissionsFailed", wireType)
+			}
+			m.MissionsFailed = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MissionsFailed |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RejectedProposals", wireType)
+			}
+			m.RejectedProposals = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RejectedProposals |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Assassinated", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Assassinated == nil {
+				m.Assassinated = &Player{}
+			}
+			if err := m.Assassinated.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAvalonGame(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GameConfig) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAvalonGame
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GameConfig: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GameConfig: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GoodTeam", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.GoodTeam == nil {
+				m.GoodTeam = &VirtuousTeam{}
+			}
+			if err := m.GoodTeam.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 20:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EvilTeam", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.EvilTeam == nil {
+				m.EvilTeam = &EvilTeam{}
+			}
+			if err := m.EvilTeam.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 30:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChatId", wireType)
+			}
+			m.ChatId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChatId |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 40:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Locale", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Locale = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 50:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Rules", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Rules == nil {
+				m.Rules = &RuleSet{}
+			}
+			if err := m.Rules.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 60:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Players", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Players = append(m.Players, &Player{})
+			if err := m.Players[len(m.Players)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 70:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BotPlayers", wireType)
+			}
+			m.BotPlayers = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BotPlayers |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 100:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Extensions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Extensions == nil {
+				m.Extensions = &GameExtensions{}
+			}
+			if err := m.Extensions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAvalonGame(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RuleSet) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAvalonGame
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RuleSet: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RuleSet: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PlayerCounts", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PlayerCounts = append(m.PlayerCounts, &PlayerCountRules{})
+			if err := m.PlayerCounts[len(m.PlayerCounts)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 20:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxRejectedProposals", wireType)
+			}
+			m.MaxRejectedProposals = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxRejectedProposals |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAvalonGame(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PlayerCountRules) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAvalonGame
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PlayerCountRules: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PlayerCountRules: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Players", wireType)
+			}
+			m.Players = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Players |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 20:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EvilPlayers", wireType)
+			}
+			m.EvilPlayers = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EvilPlayers |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 30:
+			if wireType == 0 {
+				var v uint32
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAvalonGame
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint32(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.TeamSizes = append(m.TeamSizes, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAvalonGame
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthAvalonGame
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthAvalonGame
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.TeamSizes) == 0 {
+					m.TeamSizes = make([]uint32, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint32
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAvalonGame
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.TeamSizes = append(m.TeamSizes, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field TeamSizes", wireType)
+			}
+		case 40:
+			if wireType == 0 {
+				var v uint32
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAvalonGame
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint32(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.FailsRequired = append(m.FailsRequired, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAvalonGame
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthAvalonGame
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthAvalonGame
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.FailsRequired) == 0 {
+					m.FailsRequired = make([]uint32, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint32
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAvalonGame
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.FailsRequired = append(m.FailsRequired, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field FailsRequired", wireType)
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAvalonGame(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GameExtensions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAvalonGame
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GameExtensions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GameExtensions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PercivalAndMorgana", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.PercivalAndMorgana = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Oberon", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Oberon = bool(v != 0)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mordred", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Mordred = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAvalonGame(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Chat) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAvalonGame
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Chat: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Chat: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Id |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAvalonGame(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Player) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAvalonGame
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Player: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Player: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Id |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 20:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bot", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Bot = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAvalonGame(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *EvilTeam) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAvalonGame
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: EvilTeam: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: EvilTeam: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Members", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Members = append(m.Members, &Player{})
+			if err := m.Members[len(m.Members)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 20:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Assassin", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Assassin == nil {
+				m.Assassin = &Player{}
+			}
+			if err := m.Assassin.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 30:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Oberon", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2810,18 +5723,18 @@ func (m *GameSession) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.LastMissionResult == nil {
-				m.LastMissionResult = &MissionResult{}
+			if m.Oberon == nil {
+				m.Oberon = &Player{}
 			}
-			if err := m.LastMissionResult.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Oberon.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 40:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MissionsPassed", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Morgana", wireType)
 			}
-			m.MissionsPassed = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -2831,16 +5744,33 @@ func (m *GameSession) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.MissionsPassed |= int32(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 41:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MissionsFailed", wireType)
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
 			}
-			m.MissionsFailed = 0
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Morgana == nil {
+				m.Morgana = &Player{}
+			}
+			if err := m.Morgana.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 50:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mordred", wireType)
+			}
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -2850,11 +5780,28 @@ func (m *GameSession) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.MissionsFailed |= int32(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Mordred == nil {
+				m.Mordred = &Player{}
+			}
+			if err := m.Mordred.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAvalonGame(dAtA[iNdEx:])
@@ -2879,7 +5826,7 @@ func (m *GameSession) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GameConfig) Unmarshal(dAtA []byte) error {
+func (m *VirtuousTeam) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2902,15 +5849,15 @@ func (m *GameConfig) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GameConfig: wiretype end group for non-group")
+			return fmt.Errorf("proto: VirtuousTeam: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GameConfig: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: VirtuousTeam: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 10:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field GoodTeam", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Members", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2937,16 +5884,14 @@ func (m *GameConfig) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.GoodTeam == nil {
-				m.GoodTeam = &VirtuousTeam{}
-			}
-			if err := m.GoodTeam.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Members = append(m.Members, &Player{})
+			if err := m.Members[len(m.Members)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 20:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EvilTeam", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Merlin", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2973,16 +5918,16 @@ func (m *GameConfig) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.EvilTeam == nil {
-				m.EvilTeam = &EvilTeam{}
+			if m.Merlin == nil {
+				m.Merlin = &Player{}
 			}
-			if err := m.EvilTeam.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Merlin.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 100:
+		case 30:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Extensions", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Percival", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3009,10 +5954,10 @@ func (m *GameConfig) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Extensions == nil {
-				m.Extensions = &GameExtensions{}
+			if m.Percival == nil {
+				m.Percival = &Player{}
 			}
-			if err := m.Extensions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Percival.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -3040,7 +5985,7 @@ func (m *GameConfig) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GameExtensions) Unmarshal(dAtA []byte) error {
+func (m *PendingMission) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3063,17 +6008,17 @@ func (m *GameExtensions) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GameExtensions: wiretype end group for non-group")
+			return fmt.Errorf("proto: PendingMission: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GameExtensions: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PendingMission: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
+		case 10:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PercivalAndMorgana", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field MissionNumber", wireType)
 			}
-			var v int
+			m.MissionNumber = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3083,17 +6028,16 @@ func (m *GameExtensions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				m.MissionNumber |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.PercivalAndMorgana = bool(v != 0)
-		case 2:
+		case 20:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Oberon", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field TeamPickingAttempts", wireType)
 			}
-			var v int
+			m.TeamPickingAttempts = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3103,17 +6047,16 @@ func (m *GameExtensions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				m.TeamPickingAttempts |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.Oberon = bool(v != 0)
-		case 3:
+		case 30:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Mordred", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field TeamSize", wireType)
 			}
-			var v int
+			m.TeamSize = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3123,12 +6066,49 @@ func (m *GameExtensions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				m.TeamSize |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 40:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FailsRequired", wireType)
+			}
+			m.FailsRequired = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FailsRequired |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 50:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTeamPickingAttempts", wireType)
+			}
+			m.MaxTeamPickingAttempts = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTeamPickingAttempts |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.Mordred = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAvalonGame(dAtA[iNdEx:])
@@ -3153,7 +6133,7 @@ func (m *GameExtensions) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Player) Unmarshal(dAtA []byte) error {
+func (m *MissionTeam) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3176,36 +6156,17 @@ func (m *Player) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Player: wiretype end group for non-group")
+			return fmt.Errorf("proto: MissionTeam: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Player: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MissionTeam: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
-			}
-			m.Id = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAvalonGame
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Id |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
 		case 10:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field UserName", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Members", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3215,23 +6176,25 @@ func (m *Player) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthAvalonGame
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthAvalonGame
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.UserName = string(dAtA[iNdEx:postIndex])
+			m.Members = append(m.Members, &Player{})
+			if err := m.Members[len(m.Members)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -3257,7 +6220,7 @@ func (m *Player) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *EvilTeam) Unmarshal(dAtA []byte) error {
+func (m *MissionResult) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3280,17 +6243,17 @@ func (m *EvilTeam) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: EvilTeam: wiretype end group for non-group")
+			return fmt.Errorf("proto: MissionResult: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: EvilTeam: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MissionResult: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 10:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Members", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Failed", wireType)
 			}
-			var msglen int
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3300,31 +6263,17 @@ func (m *EvilTeam) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Members = append(m.Members, &Player{})
-			if err := m.Members[len(m.Members)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
+			m.Failed = bool(v != 0)
 		case 20:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Assassin", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PositiveVotes", wireType)
 			}
-			var msglen int
+			m.PositiveVotes = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3334,69 +6283,16 @@ func (m *EvilTeam) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.PositiveVotes |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Assassin == nil {
-				m.Assassin = &Player{}
-			}
-			if err := m.Assassin.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		case 30:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Oberon", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAvalonGame
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Oberon == nil {
-				m.Oberon = &Player{}
-			}
-			if err := m.Oberon.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 40:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Morgana", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NegativeVotes", wireType)
 			}
-			var msglen int
+			m.NegativeVotes = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3406,28 +6302,11 @@ func (m *EvilTeam) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.NegativeVotes |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Morgana == nil {
-				m.Morgana = &Player{}
-			}
-			if err := m.Morgana.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAvalonGame(dAtA[iNdEx:])
@@ -3452,7 +6331,7 @@ func (m *EvilTeam) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *VirtuousTeam) Unmarshal(dAtA []byte) error {
+func (m *AssignTeamContext) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3475,49 +6354,15 @@ func (m *VirtuousTeam) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: VirtuousTeam: wiretype end group for non-group")
+			return fmt.Errorf("proto: AssignTeamContext: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: VirtuousTeam: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: AssignTeamContext: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 10:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Members", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAvalonGame
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Members = append(m.Members, &Player{})
-			if err := m.Members[len(m.Members)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 20:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Merlin", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Session", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3544,16 +6389,16 @@ func (m *VirtuousTeam) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Merlin == nil {
-				m.Merlin = &Player{}
+			if m.Session == nil {
+				m.Session = &GameSession{}
 			}
-			if err := m.Merlin.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Session.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 30:
+		case 20:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Percival", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Team", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3580,10 +6425,10 @@ func (m *VirtuousTeam) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Percival == nil {
-				m.Percival = &Player{}
+			if m.Team == nil {
+				m.Team = &MissionTeam{}
 			}
-			if err := m.Percival.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Team.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -3611,7 +6456,7 @@ func (m *VirtuousTeam) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PendingMission) Unmarshal(dAtA []byte) error {
+func (m *TeamVoteResult) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3634,17 +6479,17 @@ func (m *PendingMission) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PendingMission: wiretype end group for non-group")
+			return fmt.Errorf("proto: TeamVoteResult: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PendingMission: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TeamVoteResult: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 10:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MissionNumber", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Approved", wireType)
 			}
-			m.MissionNumber = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3654,16 +6499,17 @@ func (m *PendingMission) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.MissionNumber |= uint32(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.Approved = bool(v != 0)
 		case 20:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TeamPickingAttempts", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Approvals", wireType)
 			}
-			m.TeamPickingAttempts = 0
+			m.Approvals = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3673,69 +6519,16 @@ func (m *PendingMission) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TeamPickingAttempts |= uint32(b&0x7F) << shift
+				m.Approvals |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		default:
-			iNdEx = preIndex
-			skippy, err := skipAvalonGame(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *MissionTeam) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowAvalonGame
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: MissionTeam: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MissionTeam: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 10:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Members", wireType)
-			}
-			var msglen int
+		case 30:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Rejections", wireType)
+			}
+			m.Rejections = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3745,26 +6538,30 @@ func (m *MissionTeam) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Rejections |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+		case 40:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Abstentions", wireType)
 			}
-			m.Members = append(m.Members, &Player{})
-			if err := m.Members[len(m.Members)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Abstentions = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Abstentions |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAvalonGame(dAtA[iNdEx:])
@@ -3789,7 +6586,7 @@ func (m *MissionTeam) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MissionResult) Unmarshal(dAtA []byte) error {
+func (m *VoteContext) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3812,17 +6609,17 @@ func (m *MissionResult) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MissionResult: wiretype end group for non-group")
+			return fmt.Errorf("proto: VoteContext: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MissionResult: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: VoteContext: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 10:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Failed", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Session", wireType)
 			}
-			var v int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3832,17 +6629,33 @@ func (m *MissionResult) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.Failed = bool(v != 0)
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Session == nil {
+				m.Session = &GameSession{}
+			}
+			if err := m.Session.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 20:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PositiveVotes", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Voter", wireType)
 			}
-			m.PositiveVotes = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3852,16 +6665,33 @@ func (m *MissionResult) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.PositiveVotes |= int32(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAvalonGame
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Voter == nil {
+				m.Voter = &Player{}
+			}
+			if err := m.Voter.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 30:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field NegativeVotes", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Vote", wireType)
 			}
-			m.NegativeVotes = 0
+			m.Vote = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -3871,7 +6701,7 @@ func (m *MissionResult) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.NegativeVotes |= int32(b&0x7F) << shift
+				m.Vote |= VoteContext_VoteOption(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -3900,7 +6730,7 @@ func (m *MissionResult) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *AssignTeamContext) Unmarshal(dAtA []byte) error {
+func (m *AssassinationContext) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3923,13 +6753,13 @@ func (m *AssignTeamContext) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: AssignTeamContext: wiretype end group for non-group")
+			return fmt.Errorf("proto: AssassinationContext: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AssignTeamContext: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: AssassinationContext: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 10:
+		case 1:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Session", wireType)
 			}
@@ -3965,9 +6795,9 @@ func (m *AssignTeamContext) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		case 20:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Team", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3994,10 +6824,10 @@ func (m *AssignTeamContext) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Team == nil {
-				m.Team = &MissionTeam{}
+			if m.Target == nil {
+				m.Target = &Player{}
 			}
-			if err := m.Team.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Target.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -4025,7 +6855,7 @@ func (m *AssignTeamContext) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *VoteContext) Unmarshal(dAtA []byte) error {
+func (m *AssassinationOutcome) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4048,13 +6878,13 @@ func (m *VoteContext) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: VoteContext: wiretype end group for non-group")
+			return fmt.Errorf("proto: AssassinationOutcome: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: VoteContext: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: AssassinationOutcome: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 10:
+		case 1:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Session", wireType)
 			}
@@ -4090,47 +6920,11 @@ func (m *VoteContext) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		case 20:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Voter", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAvalonGame
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Voter == nil {
-				m.Voter = &Player{}
-			}
-			if err := m.Voter.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 30:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Vote", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field MerlinWasKilled", wireType)
 			}
-			m.Vote = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -4140,11 +6934,12 @@ func (m *VoteContext) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Vote |= VoteContext_VoteOption(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.MerlinWasKilled = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAvalonGame(dAtA[iNdEx:])
@@ -4169,7 +6964,7 @@ func (m *VoteContext) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *AssassinationContext) Unmarshal(dAtA []byte) error {
+func (m *ListSessionsRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4192,15 +6987,103 @@ func (m *AssassinationContext) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: AssassinationContext: wiretype end group for non-group")
+			return fmt.Errorf("proto: ListSessionsRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AssassinationContext: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ListSessionsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType == 0 {
+				var v GameSession_GameState
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAvalonGame
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= GameSession_GameState(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.States = append(m.States, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAvalonGame
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthAvalonGame
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthAvalonGame
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				if elementCount != 0 && len(m.States) == 0 {
+					m.States = make([]GameSession_GameState, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v GameSession_GameState
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAvalonGame
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= GameSession_GameState(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.States = append(m.States, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field States", wireType)
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PlayerId", wireType)
+			}
+			m.PlayerId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PlayerId |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Session", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedAfter", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4227,18 +7110,18 @@ func (m *AssassinationContext) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Session == nil {
-				m.Session = &GameSession{}
+			if m.CreatedAfter == nil {
+				m.CreatedAfter = &types.Timestamp{}
 			}
-			if err := m.Session.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.CreatedAfter.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChatId", wireType)
 			}
-			var msglen int
+			m.ChatId = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAvalonGame
@@ -4248,28 +7131,49 @@ func (m *AssassinationContext) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.ChatId |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthAvalonGame
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthAvalonGame
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			m.Offset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Offset |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if m.Target == nil {
-				m.Target = &Player{}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
 			}
-			if err := m.Target.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAvalonGame
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAvalonGame(dAtA[iNdEx:])
@@ -4294,7 +7198,7 @@ func (m *AssassinationContext) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *AssassinationOutcome) Unmarshal(dAtA []byte) error {
+func (m *ListSessionsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -4317,15 +7221,15 @@ func (m *AssassinationOutcome) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: AssassinationOutcome: wiretype end group for non-group")
+			return fmt.Errorf("proto: ListSessionsResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AssassinationOutcome: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ListSessionsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Session", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Sessions", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -4352,16 +7256,14 @@ func (m *AssassinationOutcome) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Session == nil {
-				m.Session = &GameSession{}
-			}
-			if err := m.Session.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Sessions = append(m.Sessions, &GameSession{})
+			if err := m.Sessions[len(m.Sessions)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MerlinWasKilled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field HasMore", wireType)
 			}
 			var v int
 			for shift := uint(0); ; shift += 7 {
@@ -4378,7 +7280,7 @@ func (m *AssassinationOutcome) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-			m.MerlinWasKilled = bool(v != 0)
+			m.HasMore = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAvalonGame(dAtA[iNdEx:])
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// tokenAuth rejects calls without one of the configured tokens in "authorization: Bearer <token>" metadata
+type tokenAuth struct {
+	tokens [][]byte
+}
+
+func newTokenAuth(tokens []string) *tokenAuth {
+	auth := &tokenAuth{tokens: make([][]byte, 0, len(tokens))}
+	for _, token := range tokens {
+		auth.tokens = append(auth.tokens, []byte(strings.TrimSpace(token)))
+	}
+	return auth
+}
+
+func (a *tokenAuth) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("authorization") {
+		presented := []byte(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+		for _, token := range a.tokens {
+			if subtle.ConstantTimeCompare(presented, token) == 1 {
+				return nil
+			}
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid auth token")
+}
+
+func (a *tokenAuth) unaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *tokenAuth) streamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.authorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
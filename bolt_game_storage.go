@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+	"time"
+)
+
+var (
+	boltSessionsBucket     = []byte("sessions")
+	boltVotesBucket        = []byte("votes") //Holds bucket per game with mission and team votes buckets inside
+	boltMissionVotesBucket = []byte("mission")
+	boltTeamVotesBucket    = []byte("team")
+	boltMigrationsBucket   = []byte("migrations")
+)
+
+const (
+	boltVoteNegative byte = 0
+	boltVotePositive byte = 1
+)
+
+// boltStorage keeps sessions and votes in a single embedded database file.
+// It implements both GameSessionStorage and VoteStorage and is meant for single node deployments.
+type boltStorage struct {
+	sessionChangeBroadcaster
+
+	db     *bolt.DB
+	logger *zap.Logger
+}
+
+func NewBoltStorage(path string, logger *zap.Logger) (*boltStorage, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	stor := &boltStorage{sessionChangeBroadcaster: sessionChangeBroadcaster{logger: logger}, db: db, logger: logger}
+	if err = stor.Migrate(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to migrate bolt storage: %w", err)
+	}
+
+	return stor, nil
+}
+
+// boltMigrations upgrade database file, append new migrations to the end with the next version
+func (i *boltStorage) boltMigrations() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "create sessions and votes buckets",
+			Apply: func(_ context.Context) error {
+				return i.db.Update(func(tx *bolt.Tx) error {
+					for _, bucket := range [][]byte{boltSessionsBucket, boltVotesBucket} {
+						if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			},
+		},
+	}
+}
+
+func (i *boltStorage) Migrate(ctx context.Context) error {
+	return RunMigrations(ctx, i.logger, i, i.boltMigrations())
+}
+
+func (i *boltStorage) AppliedMigrations(_ context.Context) (map[int]AppliedMigration, error) {
+	applied := make(map[int]AppliedMigration)
+	err := i.db.View(func(tx *bolt.Tx) error {
+		migrations := tx.Bucket(boltMigrationsBucket)
+		if migrations == nil {
+			return nil
+		}
+		return migrations.ForEach(func(_, data []byte) error {
+			var record AppliedMigration
+			if err := json.Unmarshal(data, &record); err != nil {
+				return err
+			}
+			applied[record.Version] = record
+			return nil
+		})
+	})
+	return applied, err
+}
+
+func (i *boltStorage) RecordMigration(_ context.Context, applied AppliedMigration) error {
+	data, err := json.Marshal(applied)
+	if err != nil {
+		return err
+	}
+	return i.db.Update(func(tx *bolt.Tx) error {
+		migrations, err := tx.CreateBucketIfNotExists(boltMigrationsBucket)
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, uint64(applied.Version))
+		return migrations.Put(key, data)
+	})
+}
+
+func (i *boltStorage) Close() error {
+	return i.db.Close()
+}
+
+// Ping fails once database is closed
+func (i *boltStorage) Ping(_ context.Context) error {
+	return i.db.View(func(*bolt.Tx) error { return nil })
+}
+
+func putSession(tx *bolt.Tx, gameId uuid.UUID, session *GameInstance) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(boltSessionsBucket).Put(gameId[:], data)
+}
+
+func (i *boltStorage) StoreSession(_ context.Context, session *GameInstance) error {
+	gameId, err := uuid.Parse(session.GameId.Value)
+	if err != nil {
+		return err
+	}
+
+	err = i.db.Update(func(tx *bolt.Tx) error {
+		return putSession(tx, gameId, session)
+	})
+	if err != nil {
+		i.logger.Error("failed to store session in bolt", gameIdField(gameId), stateField(session.State), zap.Error(err))
+		return err
+	}
+
+	i.notify(SessionChange{GameId: gameId, Origin: processId})
+	return nil
+}
+
+func (i *boltStorage) StoreSessionAndResetVotes(_ context.Context, session *GameInstance) error {
+	gameId, err := uuid.Parse(session.GameId.Value)
+	if err != nil {
+		return err
+	}
+
+	err = i.db.Update(func(tx *bolt.Tx) error {
+		if err := resetVotes(tx, gameId); err != nil {
+			return err
+		}
+		return putSession(tx, gameId, session)
+	})
+	if err != nil {
+		i.logger.Error("failed to store session and reset votes in bolt", gameIdField(gameId), stateField(session.State), zap.Error(err))
+		return err
+	}
+
+	i.notify(SessionChange{GameId: gameId, Origin: processId})
+	return nil
+}
+
+func (i *boltStorage) GetSession(_ context.Context, id uuid.UUID) (*GameInstance, error) {
+	ret := new(GameInstance)
+	err := i.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltSessionsBucket).Get(id[:])
+		if data == nil {
+			return ErrSessionNotFound
+		}
+		return json.Unmarshal(data, ret)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (i *boltStorage) CloseSession(_ context.Context, id uuid.UUID) error {
+	err := i.db.Update(func(tx *bolt.Tx) error {
+		if err := resetVotes(tx, id); err != nil {
+			return err
+		}
+		return tx.Bucket(boltSessionsBucket).Delete(id[:])
+	})
+	if err != nil {
+		i.logger.Error("failed to delete session data from bolt", gameIdField(id), zap.Error(err))
+		return err
+	}
+
+	i.notify(SessionChange{GameId: id, Closed: true, Origin: processId})
+	return nil
+}
+
+func (i *boltStorage) CheckExistence(_ context.Context, id uuid.UUID) (bool, error) {
+	found := false
+	err := i.db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket(boltSessionsBucket).Get(id[:]) != nil
+		return nil
+	})
+	return found, err
+}
+
+func (i *boltStorage) NumberOfGames(_ context.Context) (uint, error) {
+	var n uint
+	err := i.db.View(func(tx *bolt.Tx) error {
+		n = uint(tx.Bucket(boltSessionsBucket).Stats().KeyN)
+		return nil
+	})
+	return n, err
+}
+
+func (i *boltStorage) ListSessions(_ context.Context, filter SessionFilter) ([]*GameInstance, error) {
+	matched := make([]*GameInstance, 0)
+	err := i.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSessionsBucket).ForEach(func(_, data []byte) error {
+			game := new(GameInstance)
+			if err := json.Unmarshal(data, game); err != nil {
+				return err
+			}
+			if filter.Match(game) {
+				matched = append(matched, game)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		i.logger.Error("failed to list sessions in bolt", zap.Error(err))
+		return nil, err
+	}
+
+	sortSessionsByCreation(matched)
+
+	return filter.Paginate(matched), nil
+}
+
+func (i *boltStorage) FindSessionsByPlayer(ctx context.Context, playerId uint64) ([]*GameInstance, error) {
+	return i.ListSessions(ctx, SessionFilter{PlayerId: playerId})
+}
+
+func resetVotes(tx *bolt.Tx, gameId uuid.UUID) error {
+	err := tx.Bucket(boltVotesBucket).DeleteBucket(gameId[:])
+	if err == bolt.ErrBucketNotFound {
+		return nil
+	}
+	return err
+}
+
+func playerKey(player *api.Player) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, player.Id)
+	return key
+}
+
+// recordVote stores player's vote in a single transaction, repeated votes are ignored
+func (i *boltStorage) recordVote(gameId uuid.UUID, kind []byte, player *api.Player, vote byte) error {
+	repeated := false
+	err := i.db.Update(func(tx *bolt.Tx) error {
+		game, err := tx.Bucket(boltVotesBucket).CreateBucketIfNotExists(gameId[:])
+		if err != nil {
+			return err
+		}
+		votes, err := game.CreateBucketIfNotExists(kind)
+		if err != nil {
+			return err
+		}
+
+		if votes.Get(playerKey(player)) != nil {
+			i.logger.Info("repeated vote attempt", gameIdField(gameId), playerIdField(player))
+			repeated = true
+			return nil
+		}
+		return votes.Put(playerKey(player), []byte{vote})
+	})
+	if err != nil {
+		i.logger.Error("failed to store vote in bolt", gameIdField(gameId), playerIdField(player), zap.Error(err))
+		return err
+	}
+	if repeated {
+		return ErrRepeatedVote
+	}
+	return nil
+}
+
+// countVotes returns number of positive votes and total number of votes
+func (i *boltStorage) countVotes(gameId uuid.UUID, kind []byte) (int, int, error) {
+	positive, total := 0, 0
+	err := i.db.View(func(tx *bolt.Tx) error {
+		game := tx.Bucket(boltVotesBucket).Bucket(gameId[:])
+		if game == nil || game.Bucket(kind) == nil {
+			return nil
+		}
+		return game.Bucket(kind).ForEach(func(_, vote []byte) error {
+			total++
+			if vote[0] == boltVotePositive {
+				positive++
+			}
+			return nil
+		})
+	})
+	return positive, total, err
+}
+
+func (i *boltStorage) AddPositiveMissionVote(_ context.Context, gameId uuid.UUID, player *api.Player) error {
+	return i.recordVote(gameId, boltMissionVotesBucket, player, boltVotePositive)
+}
+
+func (i *boltStorage) AddNegativeMissionVote(_ context.Context, gameId uuid.UUID, player *api.Player) error {
+	return i.recordVote(gameId, boltMissionVotesBucket, player, boltVoteNegative)
+}
+
+func (i *boltStorage) GetMissionVotesCountForGame(_ context.Context, id uuid.UUID) (int8, error) {
+	positive, _, err := i.countVotes(id, boltMissionVotesBucket)
+	return int8(positive), err
+}
+
+func (i *boltStorage) NumberOfPlayersVotedForMission(_ context.Context, id uuid.UUID) (int, error) {
+	_, total, err := i.countVotes(id, boltMissionVotesBucket)
+	return total, err
+}
+
+// AddTeamVote stores vote option number, positive and negative ones are the same as mission votes
+func (i *boltStorage) AddTeamVote(_ context.Context, gameId uuid.UUID, player *api.Player, vote api.VoteContext_VoteOption) error {
+	return i.recordVote(gameId, boltTeamVotesBucket, player, byte(vote))
+}
+
+func (i *boltStorage) GetTeamVotes(_ context.Context, id uuid.UUID) (*TeamVotes, error) {
+	votes := newTeamVotes()
+	err := i.db.View(func(tx *bolt.Tx) error {
+		game := tx.Bucket(boltVotesBucket).Bucket(id[:])
+		if game == nil || game.Bucket(boltTeamVotesBucket) == nil {
+			return nil
+		}
+		return game.Bucket(boltTeamVotesBucket).ForEach(func(player, vote []byte) error {
+			playerId := binary.BigEndian.Uint64(player)
+			if !votes.add(playerId, api.VoteContext_VoteOption(vote[0])) {
+				return fmt.Errorf("malformed team vote %d of player %d", vote[0], playerId)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		i.logger.Error("failed to read votes from bolt", gameIdField(id), zap.Error(err))
+		return nil, err
+	}
+	return votes, nil
+}
+
+func (i *boltStorage) ResetVotes(_ context.Context, gameId uuid.UUID) error {
+	err := i.db.Update(func(tx *bolt.Tx) error {
+		return resetVotes(tx, gameId)
+	})
+	if err != nil {
+		i.logger.Error("failed to reset votes in bolt", gameIdField(gameId), zap.Error(err))
+	}
+	return err
+}
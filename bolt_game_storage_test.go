@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestBoltStorage(t *testing.T) *boltStorage {
+	t.Helper()
+	stor, err := NewBoltStorage(filepath.Join(t.TempDir(), "avalon.db"), zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal("failed to open bolt storage: ", err)
+	}
+	t.Cleanup(func() { _ = stor.Close() })
+	return stor
+}
+
+func TestBoltStorageVotes(t *testing.T) {
+	ctx := context.Background()
+	stor := newTestBoltStorage(t)
+	game := newTestGame(0, time.Now(), 1, 2, 3)
+	gameId := testGameId(game.GameId)
+
+	for _, vote := range []func() error{
+		func() error { return stor.AddTeamVote(ctx, gameId, &api.Player{Id: 1}, api.VoteContext_POSITIVE) },
+		func() error { return stor.AddTeamVote(ctx, gameId, &api.Player{Id: 2}, api.VoteContext_NEGATIVE) },
+		func() error { return stor.AddTeamVote(ctx, gameId, &api.Player{Id: 3}, api.VoteContext_ABSTAIN) },
+		func() error { return stor.AddPositiveMissionVote(ctx, gameId, &api.Player{Id: 1}) },
+	} {
+		if err := vote(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := stor.AddTeamVote(ctx, gameId, &api.Player{Id: 3}, api.VoteContext_POSITIVE); err != ErrRepeatedVote {
+		t.Fatalf("repeated vote = %v; want %v", err, ErrRepeatedVote)
+	}
+
+	teamVotes, err := stor.GetTeamVotes(ctx, gameId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &TeamVotes{Approvals: map[uint64]bool{1: true}, Rejections: map[uint64]bool{2: true}, Abstentions: map[uint64]bool{3: true}}
+	if !reflect.DeepEqual(teamVotes, want) {
+		t.Errorf("GetTeamVotes = %+v; want %+v", teamVotes, want)
+	}
+	if c, _ := stor.GetMissionVotesCountForGame(ctx, gameId); c != 1 {
+		t.Errorf("GetMissionVotesCountForGame = %d; want 1", c)
+	}
+
+	if err := stor.StoreSessionAndResetVotes(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+	if exist, _ := stor.CheckExistence(ctx, gameId); !exist {
+		t.Error("session was not stored by StoreSessionAndResetVotes")
+	}
+	if teamVotes, _ = stor.GetTeamVotes(ctx, gameId); len(teamVotes.Approvals)+len(teamVotes.Rejections)+len(teamVotes.Abstentions) != 0 {
+		t.Errorf("team votes after reset = %+v; want none", teamVotes)
+	}
+	if n, _ := stor.NumberOfPlayersVotedForMission(ctx, gameId); n != 0 {
+		t.Errorf("NumberOfPlayersVotedForMission after reset = %d; want 0", n)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/justmax437/avalonBacker/api"
+)
+
+type botRole int
+
+const (
+	roleServant botRole = iota //Loyal servant of Arthur without special abilities
+	roleMerlin
+	roleMinion //Minion of Mordred without special abilities
+	roleAssassin
+)
+
+var botRoleNames = map[botRole]string{
+	roleServant:  "servant",
+	roleMerlin:   "merlin",
+	roleMinion:   "minion",
+	roleAssassin: "assassin",
+}
+
+func (r botRole) String() string {
+	return botRoleNames[r]
+}
+
+func (r botRole) evil() bool {
+	return r == roleMinion || r == roleAssassin
+}
+
+// playerRole finds out role of player from teams of a game
+func playerRole(p *api.Player, good *api.VirtuousTeam, evil *api.EvilTeam) botRole {
+	switch {
+	case p.GetId() == evil.GetAssassin().GetId():
+		return roleAssassin
+	case findPlayer(evil.GetMembers(), p.GetId()) != nil:
+		return roleMinion
+	case p.GetId() == good.GetMerlin().GetId():
+		return roleMerlin
+	default:
+		return roleServant
+	}
+}
+
+// teamProposal is public record of a team that was voted for
+type teamProposal struct {
+	team   []*api.Player
+	votes  map[uint64]bool //Vote of every player, true for approval
+	failed bool            //Team went on a mission and failed it
+}
+
+// botView is what a computer-controlled player legitimately knows about the game:
+// public history and whatever their role reveals
+type botView struct {
+	self      *api.Player
+	role      botRole
+	players   []*api.Player
+	knownEvil []*api.Player //Known to Merlin and evil players only, Oberon knows only himself
+	//Known to Percival only: Merlin and Morgana, who appears as Merlin to him
+	knownMerlin []*api.Player
+	proposals   []*teamProposal
+	rng         *rand.Rand
+}
+
+// newBotView reveals teams to player according to their role
+func newBotView(self *api.Player, players []*api.Player, good *api.VirtuousTeam, evil *api.EvilTeam, rng *rand.Rand) *botView {
+	v := &botView{self: self, role: playerRole(self, good, evil), players: players, rng: rng}
+	switch {
+	case v.role == roleMerlin:
+		v.knownEvil = evilKnownToMerlin(evil)
+	case isRole(self, evil.GetOberon()):
+		v.knownEvil = []*api.Player{self}
+	case v.role.evil():
+		v.knownEvil = evilKnownToEvil(evil)
+	case isRole(self, good.GetPercival()):
+		v.knownMerlin = merlinKnownToPercival(good, evil)
+	}
+	return v
+}
+
+func (v *botView) isKnownEvil(p *api.Player) bool {
+	return findPlayer(v.knownEvil, p.GetId()) != nil
+}
+
+// suspicious tells if player went on a failed mission, that is known to everyone
+func (v *botView) suspicious(p *api.Player) bool {
+	for _, proposal := range v.proposals {
+		if proposal.failed && findPlayer(proposal.team, p.GetId()) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// pickTeam builds team of size players with self in it, preferring players not matching avoid
+func (v *botView) pickTeam(size int, avoid func(p *api.Player) bool) []*api.Player {
+	team := []*api.Player{v.self}
+	var preferred, others []*api.Player
+	for _, n := range v.rng.Perm(len(v.players)) {
+		p := v.players[n]
+		switch {
+		case p.GetId() == v.self.GetId():
+		case avoid != nil && avoid(p):
+			others = append(others, p)
+		default:
+			preferred = append(preferred, p)
+		}
+	}
+	candidates := append(preferred, others...)
+	return append(team, candidates[:size-1]...)
+}
+
+func (v *botView) teamHas(team []*api.Player, match func(p *api.Player) bool) bool {
+	for _, p := range team {
+		if match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// merlinCandidates lists players assassin could suspect to be Merlin
+func (v *botView) merlinCandidates() []*api.Player {
+	var candidates []*api.Player
+	for _, p := range v.players {
+		if !v.isKnownEvil(p) && p.GetId() != v.self.GetId() {
+			candidates = append(candidates, p)
+		}
+	}
+	return candidates
+}
+
+// BotStrategy makes decisions of a computer-controlled player.
+// Strategies must only use botView, so bots don't cheat.
+type BotStrategy interface {
+	ProposeTeam(v *botView, size int) []*api.Player
+	VoteForTeam(v *botView, team []*api.Player) bool
+	//VoteForMission is asked of evil players only, virtuous players can't fail missions
+	VoteForMission(v *botView) bool
+	//GuessMerlin is asked of assassin only
+	GuessMerlin(v *botView) *api.Player
+}
+
+var botStrategies = map[string]BotStrategy{
+	"random":         randomStrategy{},
+	"always-approve": alwaysApproveStrategy{},
+	"evil-saboteur":  evilSaboteurStrategy{},
+	"merlin-hinting": merlinHintingStrategy{},
+}
+
+// botStrategyNames lists registered strategies in stable order
+func botStrategyNames() []string {
+	names := make([]string, 0, len(botStrategies))
+	for name := range botStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// randomStrategy flips a coin for every decision
+type randomStrategy struct{}
+
+func (randomStrategy) ProposeTeam(v *botView, size int) []*api.Player {
+	return v.pickTeam(size, nil)
+}
+
+func (randomStrategy) VoteForTeam(v *botView, _ []*api.Player) bool {
+	return v.rng.Intn(2) == 0
+}
+
+func (randomStrategy) VoteForMission(v *botView) bool {
+	return v.rng.Intn(2) == 0
+}
+
+func (randomStrategy) GuessMerlin(v *botView) *api.Player {
+	candidates := v.merlinCandidates()
+	return candidates[v.rng.Intn(len(candidates))]
+}
+
+// alwaysApproveStrategy approves every team, evil players always fail missions
+type alwaysApproveStrategy struct{}
+
+func (alwaysApproveStrategy) ProposeTeam(v *botView, size int) []*api.Player {
+	return v.pickTeam(size, nil)
+}
+
+func (alwaysApproveStrategy) VoteForTeam(*botView, []*api.Player) bool {
+	return true
+}
+
+func (alwaysApproveStrategy) VoteForMission(*botView) bool {
+	return false
+}
+
+func (alwaysApproveStrategy) GuessMerlin(v *botView) *api.Player {
+	return randomStrategy{}.GuessMerlin(v)
+}
+
+// evilSaboteurStrategy makes evil players push teams with evil members and fail every mission.
+// Virtuous players avoid those who were on failed missions.
+type evilSaboteurStrategy struct{}
+
+func (evilSaboteurStrategy) ProposeTeam(v *botView, size int) []*api.Player {
+	if v.role.evil() {
+		//Only one saboteur is needed, other evil players would just draw suspicion
+		return v.pickTeam(size, v.isKnownEvil)
+	}
+	return v.pickTeam(size, v.suspicious)
+}
+
+func (evilSaboteurStrategy) VoteForTeam(v *botView, team []*api.Player) bool {
+	if v.role.evil() {
+		return v.teamHas(team, v.isKnownEvil)
+	}
+	return !v.teamHas(team, v.suspicious)
+}
+
+func (evilSaboteurStrategy) VoteForMission(*botView) bool {
+	return false
+}
+
+func (evilSaboteurStrategy) GuessMerlin(v *botView) *api.Player {
+	return randomStrategy{}.GuessMerlin(v)
+}
+
+// merlinHintingStrategy makes Merlin steer teams away from evil players,
+// while assassin looks for a player whose votes show that knowledge
+type merlinHintingStrategy struct{}
+
+func (merlinHintingStrategy) ProposeTeam(v *botView, size int) []*api.Player {
+	if v.role == roleMerlin {
+		return v.pickTeam(size, v.isKnownEvil)
+	}
+	return evilSaboteurStrategy{}.ProposeTeam(v, size)
+}
+
+func (merlinHintingStrategy) VoteForTeam(v *botView, team []*api.Player) bool {
+	if v.role == roleMerlin {
+		return !v.teamHas(team, v.isKnownEvil)
+	}
+	return evilSaboteurStrategy{}.VoteForTeam(v, team)
+}
+
+func (merlinHintingStrategy) VoteForMission(*botView) bool {
+	return false
+}
+
+func (merlinHintingStrategy) GuessMerlin(v *botView) *api.Player {
+	//Merlin approves teams without evil players and rejects teams with them
+	best, bestScore := []*api.Player(nil), -1
+	for _, p := range v.merlinCandidates() {
+		score := 0
+		for _, proposal := range v.proposals {
+			if vote, voted := proposal.votes[p.GetId()]; voted && vote != v.teamHas(proposal.team, v.isKnownEvil) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = nil, score
+		}
+		if score == bestScore {
+			best = append(best, p)
+		}
+	}
+	return best[v.rng.Intn(len(best))]
+}
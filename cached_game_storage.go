@@ -0,0 +1,344 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"github.com/gogo/protobuf/proto"
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheStats are counters of cachedSessionStorage since its creation
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type cacheEntry struct {
+	id        uuid.UUID
+	game      *GameInstance
+	expiresAt time.Time
+}
+
+// cachedSessionStorage is a write-through LRU cache in front of durable GameSessionStorage.
+// Sessions are copied in and out of cache, so callers are free to modify returned sessions.
+// If backend reports session changes, entries changed by other replicas are invalidated too.
+type cachedSessionStorage struct {
+	backend GameSessionStorage
+	size    int
+	ttl     time.Duration
+	logger  *zap.Logger
+
+	lock    sync.Mutex
+	entries map[uuid.UUID]*list.Element
+	lru     *list.List //Front is the most recently used
+	//epoch changes with every write and invalidation, session read from backend
+	//is only cached if nothing changed while it was read, otherwise it could be stale already
+	epoch uint64
+
+	hits, misses, evictions uint64
+	bypass                  int32 //Set once changes of other replicas can't be followed anymore, cache is not used then
+
+	stopWatching context.CancelFunc
+	watchDone    chan struct{} //Closed once changes from backend are drained
+}
+
+// NewCachedSessionStorage fails if backend reports session changes, but changes can't be watched.
+// Backends shared by several replicas must report changes, otherwise cache would serve sessions changed by other replicas.
+func NewCachedSessionStorage(backend GameSessionStorage, size int, ttl time.Duration, logger *zap.Logger) (*cachedSessionStorage, error) {
+	c := &cachedSessionStorage{
+		backend:      backend,
+		size:         size,
+		ttl:          ttl,
+		logger:       logger,
+		entries:      make(map[uuid.UUID]*list.Element, size),
+		lru:          list.New(),
+		stopWatching: func() {},
+		watchDone:    make(chan struct{}),
+	}
+
+	//Other decorators may implement SessionChangeNotifier, while their backend doesn't
+	if _, ok := unwrapStorage(backend).(SessionChangeNotifier); !ok {
+		close(c.watchDone)
+		return c, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := backend.(SessionChangeNotifier).WatchSessions(ctx)
+	if err != nil {
+		cancel()
+		return nil, errors.New("failed to watch session changes of other replicas: " + err.Error())
+	}
+	c.stopWatching = cancel
+	go func() {
+		defer close(c.watchDone)
+		for change := range changes {
+			//Own writes already went through the cache, but sessions could be closed behind its back, e.g. by sweeper
+			if change.Origin != processId || change.Closed {
+				c.invalidate(change.GameId)
+			}
+		}
+		if ctx.Err() == nil {
+			c.stopCaching()
+		}
+	}()
+	return c, nil
+}
+
+// stopCaching turns cache off after changes stream of backend was lost, so stale sessions are never served
+func (c *cachedSessionStorage) stopCaching() {
+	c.logger.Error("session changes of other replicas are lost, session cache is turned off")
+	atomic.StoreInt32(&c.bypass, 1)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = make(map[uuid.UUID]*list.Element)
+	c.lru.Init()
+}
+
+// Close stops listening to backend changes and waits until pending ones are applied, backend itself is left open
+func (c *cachedSessionStorage) Close() {
+	c.stopWatching()
+	<-c.watchDone
+}
+
+func (c *cachedSessionStorage) Stats() CacheStats {
+	c.lock.Lock()
+	size := c.lru.Len()
+	c.lock.Unlock()
+
+	return CacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Size:      size,
+	}
+}
+
+// Unwrap returns storage behind the cache
+func (c *cachedSessionStorage) Unwrap() GameSessionStorage {
+	return c.backend
+}
+
+func (c *cachedSessionStorage) get(id uuid.UUID) (*GameInstance, bool) {
+	if atomic.LoadInt32(&c.bypass) != 0 {
+		return nil, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, found := c.entries[id]
+	if !found {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.lru.Remove(elem)
+		delete(c.entries, id)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	return entry.game, true
+}
+
+// put caches session just written to backend
+func (c *cachedSessionStorage) put(game *GameInstance) {
+	id, err := uuid.Parse(game.GameId.GetValue())
+	if err != nil {
+		return
+	}
+	entry := &cacheEntry{id: id, game: cloneGameInstance(game), expiresAt: time.Now().Add(c.ttl)}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.epoch++
+	c.insert(entry)
+}
+
+// currentEpoch must be taken before reading session from backend, see putRead
+func (c *cachedSessionStorage) currentEpoch() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.epoch
+}
+
+// putRead caches session read from backend, unless cache was written or invalidated since readEpoch
+func (c *cachedSessionStorage) putRead(game *GameInstance, readEpoch uint64) {
+	id, err := uuid.Parse(game.GameId.GetValue())
+	if err != nil {
+		return
+	}
+	entry := &cacheEntry{id: id, game: cloneGameInstance(game), expiresAt: time.Now().Add(c.ttl)}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.epoch != readEpoch {
+		return
+	}
+	c.insert(entry)
+}
+
+// insert must be called with lock held
+func (c *cachedSessionStorage) insert(entry *cacheEntry) {
+	if atomic.LoadInt32(&c.bypass) != 0 {
+		return
+	}
+
+	id := entry.id
+	if elem, found := c.entries[id]; found {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[id] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).id)
+		atomic.AddUint64(&c.evictions, 1)
+	}
+}
+
+func (c *cachedSessionStorage) invalidate(id uuid.UUID) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.epoch++
+
+	if elem, found := c.entries[id]; found {
+		c.lru.Remove(elem)
+		delete(c.entries, id)
+	}
+}
+
+func (c *cachedSessionStorage) StoreSession(ctx context.Context, session *GameInstance) error {
+	if err := c.backend.StoreSession(ctx, session); err != nil {
+		//Stored state is unknown now
+		if id, parseErr := uuid.Parse(session.GameId.GetValue()); parseErr == nil {
+			c.invalidate(id)
+		}
+		return err
+	}
+
+	c.put(session)
+	return nil
+}
+
+func (c *cachedSessionStorage) StoreSessionAndResetVotes(ctx context.Context, session *GameInstance) error {
+	txStorage, ok := c.backend.(TransactionalStorage)
+	if !ok {
+		return errors.New("cached storage backend is not transactional")
+	}
+
+	if err := txStorage.StoreSessionAndResetVotes(ctx, session); err != nil {
+		if id, parseErr := uuid.Parse(session.GameId.GetValue()); parseErr == nil {
+			c.invalidate(id)
+		}
+		return err
+	}
+
+	c.put(session)
+	return nil
+}
+
+func (c *cachedSessionStorage) CreateChatSession(ctx context.Context, session *GameInstance) error {
+	chatStorage, ok := c.backend.(ChatSessionStorage)
+	if !ok {
+		return errors.New("cached storage backend can't bind sessions to chats")
+	}
+
+	if err := chatStorage.CreateChatSession(ctx, session); err != nil {
+		return err
+	}
+	c.put(session)
+	return nil
+}
+
+func (c *cachedSessionStorage) ActiveChatSession(ctx context.Context, chatId int64) (*GameInstance, error) {
+	chatStorage, ok := c.backend.(ChatSessionStorage)
+	if !ok {
+		return nil, errors.New("cached storage backend can't bind sessions to chats")
+	}
+	return chatStorage.ActiveChatSession(ctx, chatId)
+}
+
+func (c *cachedSessionStorage) GetSession(ctx context.Context, id uuid.UUID) (*GameInstance, error) {
+	if game, found := c.get(id); found {
+		atomic.AddUint64(&c.hits, 1)
+		return cloneGameInstance(game), nil
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	readEpoch := c.currentEpoch()
+	game, err := c.backend.GetSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.putRead(game, readEpoch)
+	return game, nil
+}
+
+// CloseSession invalidates session after closing too, so session read while it was being closed is not cached
+func (c *cachedSessionStorage) CloseSession(ctx context.Context, id uuid.UUID) error {
+	c.invalidate(id)
+	defer c.invalidate(id)
+	return c.backend.CloseSession(ctx, id)
+}
+
+func (c *cachedSessionStorage) CheckExistence(ctx context.Context, id uuid.UUID) (bool, error) {
+	if _, found := c.get(id); found {
+		return true, nil
+	}
+	return c.backend.CheckExistence(ctx, id)
+}
+
+func (c *cachedSessionStorage) NumberOfGames(ctx context.Context) (uint, error) {
+	return c.backend.NumberOfGames(ctx)
+}
+
+func (c *cachedSessionStorage) ListSessions(ctx context.Context, filter SessionFilter) ([]*GameInstance, error) {
+	return c.backend.ListSessions(ctx, filter)
+}
+
+func (c *cachedSessionStorage) FindSessionsByPlayer(ctx context.Context, playerId uint64) ([]*GameInstance, error) {
+	return c.backend.FindSessionsByPlayer(ctx, playerId)
+}
+
+func (c *cachedSessionStorage) WatchSessions(ctx context.Context) (<-chan SessionChange, error) {
+	notifier, ok := c.backend.(SessionChangeNotifier)
+	if !ok {
+		return nil, errors.New("cached storage backend does not report session changes")
+	}
+	return notifier.WatchSessions(ctx)
+}
+
+// cloneGameInstance makes a deep copy of game, so cached sessions are not changed through returned pointers
+func cloneGameInstance(game *GameInstance) *GameInstance {
+	clone := *game
+	clone.GameSession = *proto.Clone(&game.GameSession).(*api.GameSession)
+	clone.GameConfig = *proto.Clone(&game.GameConfig).(*api.GameConfig)
+	clone.MissionTeam = *proto.Clone(&game.MissionTeam).(*api.MissionTeam)
+	clone.Mission = *proto.Clone(&game.Mission).(*api.PendingMission)
+	if game.AllPlayers != nil {
+		clone.AllPlayers = make([]*api.Player, len(game.AllPlayers))
+		for n, p := range game.AllPlayers {
+			clone.AllPlayers[n] = proto.Clone(p).(*api.Player)
+		}
+	}
+	return &clone
+}
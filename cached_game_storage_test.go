@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap/zaptest"
+)
+
+// newTestCache is closed when test ends
+func newTestCache(t *testing.T, backend GameSessionStorage, size int) *cachedSessionStorage {
+	t.Helper()
+	cache, err := NewCachedSessionStorage(backend, size, time.Minute, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cache.Close)
+	return cache
+}
+
+func TestCachedSessionStorageConformance(t *testing.T) {
+	testGameSessionStorage(t, func(t *testing.T) GameSessionStorage {
+		cache := newTestCache(t, newTestBoltStorage(t), 2)
+		return cache
+	})
+}
+
+func TestCachedSessionStorageStats(t *testing.T) {
+	ctx := context.Background()
+	cache := newTestCache(t, newTestBoltStorage(t), 2)
+
+	games := []*GameInstance{
+		newTestGame(0, time.Now(), 1),
+		newTestGame(0, time.Now(), 2),
+		newTestGame(0, time.Now(), 3),
+	}
+	for _, game := range games {
+		if err := cache.StoreSession(ctx, game); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//First game was evicted by the last one, fetching it back evicts least recently used game
+	for n := len(games) - 1; n >= 0; n-- {
+		if _, err := cache.GetSession(ctx, testGameId(games[n].GameId)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := cache.Stats()
+	if stats.Size != 2 || stats.Evictions != 2 {
+		t.Errorf("Size = %d, Evictions = %d; want 2, 2", stats.Size, stats.Evictions)
+	}
+	if stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("Hits = %d, Misses = %d; want 2, 1", stats.Hits, stats.Misses)
+	}
+}
+
+func TestCachedSessionStorageCopies(t *testing.T) {
+	ctx := context.Background()
+	cache := newTestCache(t, NewMemoryStorage(time.Minute), 10)
+
+	game := fullTestGame()
+	id := testGameId(game.GameId)
+	if err := cache.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+
+	game.State = api.GameSession_EVIL_TEAM_WON
+	fetched, err := cache.GetSession(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched.AllPlayers[0].UserName = "changed"
+
+	cached, err := cache.GetSession(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.State != api.GameSession_MISSION_SUCCESS_VOTING || cached.AllPlayers[0].UserName != "merlin" {
+		t.Errorf("cached session was changed through returned pointers: %+v", cached)
+	}
+}
+
+type failingSessionStorage struct {
+	GameSessionStorage
+	err error
+}
+
+func (s *failingSessionStorage) StoreSession(ctx context.Context, session *GameInstance) error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.GameSessionStorage.StoreSession(ctx, session)
+}
+
+func TestCachedSessionStorageFailedWrite(t *testing.T) {
+	ctx := context.Background()
+	backend := &failingSessionStorage{GameSessionStorage: NewMemoryStorage(time.Minute)}
+	cache := newTestCache(t, backend, 10)
+
+	game := newTestGame(0, time.Now(), 1)
+	if err := cache.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+
+	backend.err = errors.New("storage is down")
+	game.State = api.GameSession_MISSION_TEAM_PICKING
+	if err := cache.StoreSession(ctx, game); err == nil {
+		t.Fatal("expected error from failing backend")
+	}
+	if cache.Stats().Size != 0 {
+		t.Error("session is still cached after failed write")
+	}
+}
+
+func TestCachedSessionStorageRemoteChanges(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	cache := newTestCache(t, NewRedisSessionStorage(rdb, time.Minute, zaptest.NewLogger(t)), 10)
+
+	game := newTestGame(0, time.Now(), 1)
+	if err := cache.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+
+	change, _ := json.Marshal(SessionChange{GameId: testGameId(game.GameId), Origin: uuid.New().String()})
+	if err := rdb.Publish(context.Background(), redisSessionChangesChannel, change).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for cache.Stats().Size != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("session changed by another replica was not invalidated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// closableFeedStorage reports changes through a channel test can close
+type closableFeedStorage struct {
+	GameSessionStorage
+	changes chan SessionChange
+}
+
+func (s *closableFeedStorage) WatchSessions(ctx context.Context) (<-chan SessionChange, error) {
+	return s.changes, nil
+}
+
+func TestCachedSessionStorageLostChanges(t *testing.T) {
+	ctx := context.Background()
+	backend := &closableFeedStorage{GameSessionStorage: NewMemoryStorage(time.Minute), changes: make(chan SessionChange)}
+	cache := newTestCache(t, backend, 10)
+
+	game := newTestGame(0, time.Now(), 1)
+	if err := cache.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+	close(backend.changes)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for cache.Stats().Size != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("cache kept sessions after changes of other replicas were lost")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := cache.GetSession(ctx, testGameId(game.GameId)); err != nil {
+		t.Fatal(err)
+	}
+	if stats := cache.Stats(); stats.Size != 0 || stats.Hits != 0 {
+		t.Errorf("cache is still used after changes were lost: %+v", stats)
+	}
+}
+
+// slowReadStorage holds every read session until test releases it, so writes can happen in between
+type slowReadStorage struct {
+	GameSessionStorage
+	reading chan struct{}
+	release chan struct{}
+}
+
+func (s *slowReadStorage) GetSession(ctx context.Context, id uuid.UUID) (*GameInstance, error) {
+	game, err := s.GameSessionStorage.GetSession(ctx, id)
+	if err == nil {
+		game = cloneGameInstance(game)
+	}
+	s.reading <- struct{}{}
+	<-s.release
+	return game, err
+}
+
+func TestCachedSessionStorageWriteDuringRead(t *testing.T) {
+	for name, write := range map[string]func(ctx context.Context, cache *cachedSessionStorage, game *GameInstance) error{
+		"store": func(ctx context.Context, cache *cachedSessionStorage, game *GameInstance) error {
+			game.State = api.GameSession_MISSION_TEAM_PICKING
+			return cache.StoreSession(ctx, game)
+		},
+		"close": func(ctx context.Context, cache *cachedSessionStorage, game *GameInstance) error {
+			return cache.CloseSession(ctx, testGameId(game.GameId))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			backend := &slowReadStorage{GameSessionStorage: NewMemoryStorage(time.Minute), reading: make(chan struct{}), release: make(chan struct{})}
+			cache := newTestCache(t, backend, 10)
+			game := newTestGame(0, time.Now(), 1)
+			if err := backend.StoreSession(ctx, cloneGameInstance(game)); err != nil {
+				t.Fatal(err)
+			}
+
+			read := make(chan error)
+			go func() {
+				_, err := cache.GetSession(ctx, testGameId(game.GameId))
+				read <- err
+			}()
+			<-backend.reading
+			if err := write(ctx, cache, game); err != nil {
+				t.Fatal(err)
+			}
+			close(backend.release)
+			if err := <-read; err != nil {
+				t.Fatal(err)
+			}
+
+			//Session read before the write must not replace what was written
+			go func() {
+				for range backend.reading {
+				}
+			}()
+			defer close(backend.reading)
+			cached, err := cache.GetSession(ctx, testGameId(game.GameId))
+			if name == "close" {
+				if err != ErrSessionNotFound {
+					t.Errorf("closed session was read back as %+v, %v", cached, err)
+				}
+				return
+			}
+			if err != nil || cached.State != api.GameSession_MISSION_TEAM_PICKING {
+				t.Errorf("stale session %+v, %v was cached", cached, err)
+			}
+		})
+	}
+}
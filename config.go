@@ -0,0 +1,525 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	storageMemory = "memory"
+	storageMongo  = "mongo"
+	storageRedis  = "redis"
+	storageBolt   = "bolt"
+)
+
+// ServerConfig holds everything main needs to start the server.
+// Values are applied in order: defaults, YAML file, environment, command line flags.
+type ServerConfig struct {
+	ListenAddr string         `yaml:"listen_addr"`
+	Storage    StorageConfig  `yaml:"storage"`
+	TLS        TLSConfig      `yaml:"tls"`
+	Auth       AuthConfig     `yaml:"auth"`
+	Features   FeatureToggles `yaml:"features"`
+	Metrics    MetricsConfig  `yaml:"metrics"`
+	Gateway    GatewayConfig  `yaml:"gateway"`
+	Log        LogConfig      `yaml:"log"`
+	Tracing    TracingConfig  `yaml:"tracing"`
+	Telegram   TelegramConfig `yaml:"telegram"`
+
+	ShutdownTimeout     time.Duration `yaml:"shutdown_timeout"`      //Time for in-flight calls to finish after SIGTERM, then they are cancelled
+	HealthCheckInterval time.Duration `yaml:"health_check_interval"` //How often storage is pinged to report gRPC health status
+}
+
+type StorageConfig struct {
+	Backend    string        `yaml:"backend"` //One of memory, mongo, redis, bolt
+	SessionTTL time.Duration `yaml:"session_ttl"`
+	Mongo      MongoConfig   `yaml:"mongo"`
+	RedisURL   string        `yaml:"redis_url"`
+	BoltPath   string        `yaml:"bolt_path"`
+	Cache      CacheConfig   `yaml:"cache"`
+}
+
+// CacheConfig sets up write-through sessions cache in front of storage backend.
+// Cache is disabled if size is 0, it is never used with memory storage.
+type CacheConfig struct {
+	Size int           `yaml:"size"` //Max number of cached sessions
+	TTL  time.Duration `yaml:"ttl"`
+}
+
+func (c CacheConfig) Enabled() bool {
+	return c.Size > 0
+}
+
+type MongoConfig struct {
+	URI    string `yaml:"uri"` //Takes precedence over separate connection parameters
+	User   string `yaml:"user"`
+	Pass   string `yaml:"pass"`
+	Host   string `yaml:"host"`
+	DBName string `yaml:"db_name"`
+
+	//SessionTTL is a TTL index expiration for sessions without any activity, 0 disables the index.
+	//If idle sessions are archived, it applies to archived sessions instead.
+	SessionTTL time.Duration `yaml:"session_ttl"`
+	//IdleTimeout makes sweeper terminate sessions without activity for that long, 0 disables sweeper
+	IdleTimeout   time.Duration `yaml:"idle_timeout"`
+	SweepInterval time.Duration `yaml:"sweep_interval"`
+	ArchiveIdle   bool          `yaml:"archive_idle"` //Move idle sessions to archive collection instead of deleting them
+}
+
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// AuthConfig lists tokens that clients must present in "authorization: Bearer <token>" metadata.
+// Authentication is disabled if there are no tokens.
+type AuthConfig struct {
+	Tokens []string `yaml:"tokens"`
+}
+
+// MetricsConfig sets up HTTP listener serving Prometheus metrics on /metrics, empty address disables it
+type MetricsConfig struct {
+	ListenAddr string `yaml:"listen_addr"`
+}
+
+// GatewayConfig sets up HTTP listener for web clients, serving REST/JSON gateway to GameService,
+// gRPC-Web and WebSocket session events. Empty address disables it.
+type GatewayConfig struct {
+	ListenAddr string `yaml:"listen_addr"`
+	GRPCWeb    bool   `yaml:"grpc_web"`
+	//AllowedOrigins lists origins of browser apps allowed to make cross-origin calls, "*" allows any origin
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`  //debug, info, warn or error
+	Format string `yaml:"format"` //json for production, console for humans
+}
+
+// TracingConfig sets up export of OpenTelemetry spans, exporter "none" disables tracing
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter"`      //none, stdout or otlp
+	OTLPEndpoint string  `yaml:"otlp_endpoint"` //host:port of OTLP gRPC collector
+	OTLPInsecure bool    `yaml:"otlp_insecure"`
+	SampleRatio  float64 `yaml:"sample_ratio"` //Fraction of traces started by this server that are recorded
+	ServiceName  string  `yaml:"service_name"`
+}
+
+// TelegramConfig sets up built-in Telegram bot playing games in group chats, empty token disables it
+type TelegramConfig struct {
+	Token       string        `yaml:"token"`
+	APIURL      string        `yaml:"api_url"`      //Bot API server, could be changed to a local one
+	PollTimeout time.Duration `yaml:"poll_timeout"` //How long getUpdates waits for new updates
+	SeatBots    int           `yaml:"seat_bots"`    //How many empty seats of a lobby short of players are given to bots, 0 disables bots
+	BotStrategy string        `yaml:"bot_strategy"` //One of strategies from bot_strategies.go
+}
+
+func (c TelegramConfig) Enabled() bool {
+	return c.Token != ""
+}
+
+type FeatureToggles struct {
+	Reflection bool `yaml:"reflection"` //Register gRPC server reflection service
+}
+
+func defaultServerConfig() ServerConfig {
+	return ServerConfig{
+		ListenAddr:          ":50051",
+		ShutdownTimeout:     20 * time.Second, //Heroku kills dyno 30 seconds after SIGTERM
+		HealthCheckInterval: 10 * time.Second,
+		Storage: StorageConfig{
+			SessionTTL: 30 * time.Minute,
+			Mongo: MongoConfig{
+				SessionTTL:    7 * 24 * time.Hour,
+				IdleTimeout:   24 * time.Hour,
+				SweepInterval: 10 * time.Minute,
+			},
+			Cache: CacheConfig{
+				TTL: time.Minute,
+			},
+		},
+		Features: FeatureToggles{
+			Reflection: true,
+		},
+		Metrics: MetricsConfig{
+			ListenAddr: ":9090",
+		},
+		Gateway: GatewayConfig{
+			ListenAddr: ":8080",
+			GRPCWeb:    true,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: logFormatJSON,
+		},
+		Tracing: TracingConfig{
+			Exporter:     tracingExporterNone,
+			OTLPEndpoint: "localhost:55680",
+			OTLPInsecure: true,
+			SampleRatio:  1,
+			ServiceName:  "avalonBacker",
+		},
+		Telegram: TelegramConfig{
+			APIURL:      defaultTelegramAPIURL,
+			PollTimeout: 30 * time.Second,
+			SeatBots:    2,
+			BotStrategy: "merlin-hinting",
+		},
+	}
+}
+
+// LoadServerConfig builds config from $AVALON_CONFIG or -config YAML file, environment and args.
+// Returned config is already validated.
+func LoadServerConfig(args []string) (*ServerConfig, error) {
+	cfg := defaultServerConfig()
+
+	flags := flag.NewFlagSet("avalonBacker", flag.ContinueOnError)
+	configPath := flags.String("config", os.Getenv("AVALON_CONFIG"), "path to YAML config file")
+	listenAddr := flags.String("listen", "", "address to listen on, e.g. :50051")
+	metricsAddr := flags.String("metrics-listen", "", "address to serve /metrics on, empty disables metrics")
+	gatewayAddr := flags.String("gateway-listen", "", "address to serve REST gateway on, empty disables gateway")
+	backend := flags.String("storage", "", "session storage backend: memory, mongo, redis or bolt")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 0, "time to wait for in-flight calls on shutdown")
+	sessionTTL := flags.Duration("session-ttl", 0, "time to keep inactive sessions in memory and redis storages")
+	redisURL := flags.String("redis-url", "", "redis connection URL")
+	boltPath := flags.String("bolt-path", "", "path to bolt database file")
+	cacheSize := flags.Int("cache-size", 0, "max number of sessions cached in front of storage, 0 disables cache")
+	cacheTTL := flags.Duration("cache-ttl", 0, "time to keep sessions in cache")
+	tlsCert := flags.String("tls-cert", "", "TLS certificate file")
+	tlsKey := flags.String("tls-key", "", "TLS private key file")
+	logLevel := flags.String("log-level", "", "minimal level of logged messages: debug, info, warn or error")
+	logFormat := flags.String("log-format", "", "log output format: json or console")
+	tracingExporter := flags.String("tracing-exporter", "", "tracing spans exporter: none, stdout or otlp")
+	otlpEndpoint := flags.String("otlp-endpoint", "", "OTLP collector address, e.g. localhost:55680")
+	reflection := flags.Bool("reflection", cfg.Features.Reflection, "register gRPC reflection service")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configPath != "" {
+		if err := cfg.loadYAML(*configPath); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	//Only flags that were explicitly set override file and env values
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen":
+			cfg.ListenAddr = *listenAddr
+		case "shutdown-timeout":
+			cfg.ShutdownTimeout = *shutdownTimeout
+		case "metrics-listen":
+			cfg.Metrics.ListenAddr = *metricsAddr
+		case "gateway-listen":
+			cfg.Gateway.ListenAddr = *gatewayAddr
+		case "storage":
+			cfg.Storage.Backend = *backend
+		case "session-ttl":
+			cfg.Storage.SessionTTL = *sessionTTL
+		case "redis-url":
+			cfg.Storage.RedisURL = *redisURL
+		case "bolt-path":
+			cfg.Storage.BoltPath = *boltPath
+		case "cache-size":
+			cfg.Storage.Cache.Size = *cacheSize
+		case "cache-ttl":
+			cfg.Storage.Cache.TTL = *cacheTTL
+		case "tls-cert":
+			cfg.TLS.CertFile = *tlsCert
+		case "tls-key":
+			cfg.TLS.KeyFile = *tlsKey
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "log-format":
+			cfg.Log.Format = *logFormat
+		case "tracing-exporter":
+			cfg.Tracing.Exporter = *tracingExporter
+		case "otlp-endpoint":
+			cfg.Tracing.OTLPEndpoint = *otlpEndpoint
+		case "reflection":
+			cfg.Features.Reflection = *reflection
+		}
+	})
+
+	cfg.inferStorageBackend()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *ServerConfig) loadYAML(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err = yaml.UnmarshalStrict(data, c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *ServerConfig) loadEnv() error {
+	setFromEnv := func(dst *string, name string) {
+		if value, exist := os.LookupEnv(name); exist {
+			*dst = value
+		}
+	}
+
+	//$PORT is set by heroku
+	if port, exist := os.LookupEnv("PORT"); exist {
+		c.ListenAddr = ":" + port
+	}
+	setFromEnv(&c.ListenAddr, "AVALON_LISTEN_ADDR")
+	setFromEnv(&c.Metrics.ListenAddr, "AVALON_METRICS_ADDR")
+	setFromEnv(&c.Gateway.ListenAddr, "AVALON_GATEWAY_ADDR")
+	setFromEnv(&c.Storage.Backend, "AVALON_STORAGE")
+	setFromEnv(&c.Storage.Mongo.URI, "MONGO_URI")
+	setFromEnv(&c.Storage.Mongo.User, "MONGO_USER")
+	setFromEnv(&c.Storage.Mongo.Pass, "MONGO_PASS")
+	setFromEnv(&c.Storage.Mongo.Host, "MONGO_HOST")
+	setFromEnv(&c.Storage.Mongo.DBName, "MONGO_DBNAME")
+	setFromEnv(&c.Storage.RedisURL, "REDIS_URL")
+	setFromEnv(&c.Storage.BoltPath, "BOLT_DB_PATH")
+	setFromEnv(&c.TLS.CertFile, "AVALON_TLS_CERT")
+	setFromEnv(&c.TLS.KeyFile, "AVALON_TLS_KEY")
+	setFromEnv(&c.Log.Level, "AVALON_LOG_LEVEL")
+	setFromEnv(&c.Log.Format, "AVALON_LOG_FORMAT")
+	setFromEnv(&c.Tracing.Exporter, "AVALON_TRACING_EXPORTER")
+	setFromEnv(&c.Tracing.OTLPEndpoint, "OTEL_EXPORTER_OTLP_ENDPOINT")
+	setFromEnv(&c.Telegram.Token, "TELEGRAM_BOT_TOKEN")
+	setFromEnv(&c.Telegram.APIURL, "TELEGRAM_API_URL")
+	setFromEnv(&c.Telegram.BotStrategy, "TELEGRAM_BOT_STRATEGY")
+
+	for name, dst := range map[string]*time.Duration{
+		"AVALON_SHUTDOWN_TIMEOUT":      &c.ShutdownTimeout,
+		"AVALON_HEALTH_CHECK_INTERVAL": &c.HealthCheckInterval,
+		"AVALON_SESSION_TTL":           &c.Storage.SessionTTL,
+		"MONGO_SESSION_TTL":            &c.Storage.Mongo.SessionTTL,
+		"MONGO_IDLE_TIMEOUT":           &c.Storage.Mongo.IdleTimeout,
+		"MONGO_SWEEP_INTERVAL":         &c.Storage.Mongo.SweepInterval,
+		"AVALON_CACHE_TTL":             &c.Storage.Cache.TTL,
+		"TELEGRAM_POLL_TIMEOUT":        &c.Telegram.PollTimeout,
+	} {
+		if value, exist := os.LookupEnv(name); exist {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid $%s: %w", name, err)
+			}
+			*dst = d
+		}
+	}
+	if size, exist := os.LookupEnv("AVALON_CACHE_SIZE"); exist {
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			return fmt.Errorf("invalid $AVALON_CACHE_SIZE: %w", err)
+		}
+		c.Storage.Cache.Size = n
+	}
+	if bots, exist := os.LookupEnv("TELEGRAM_SEAT_BOTS"); exist {
+		n, err := strconv.Atoi(bots)
+		if err != nil {
+			return fmt.Errorf("invalid $TELEGRAM_SEAT_BOTS: %w", err)
+		}
+		c.Telegram.SeatBots = n
+	}
+	if archive, exist := os.LookupEnv("MONGO_ARCHIVE_IDLE"); exist {
+		enabled, err := strconv.ParseBool(archive)
+		if err != nil {
+			return fmt.Errorf("invalid $MONGO_ARCHIVE_IDLE: %w", err)
+		}
+		c.Storage.Mongo.ArchiveIdle = enabled
+	}
+	if origins, exist := os.LookupEnv("AVALON_CORS_ORIGINS"); exist {
+		c.Gateway.AllowedOrigins = strings.Split(origins, ",")
+	}
+	if grpcWeb, exist := os.LookupEnv("AVALON_GRPC_WEB"); exist {
+		enabled, err := strconv.ParseBool(grpcWeb)
+		if err != nil {
+			return fmt.Errorf("invalid $AVALON_GRPC_WEB: %w", err)
+		}
+		c.Gateway.GRPCWeb = enabled
+	}
+	if tokens, exist := os.LookupEnv("AVALON_AUTH_TOKENS"); exist {
+		c.Auth.Tokens = strings.Split(tokens, ",")
+	}
+	if ratio, exist := os.LookupEnv("AVALON_TRACING_SAMPLE_RATIO"); exist {
+		r, err := strconv.ParseFloat(ratio, 64)
+		if err != nil {
+			return fmt.Errorf("invalid $AVALON_TRACING_SAMPLE_RATIO: %w", err)
+		}
+		c.Tracing.SampleRatio = r
+	}
+	if reflection, exist := os.LookupEnv("AVALON_REFLECTION"); exist {
+		enabled, err := strconv.ParseBool(reflection)
+		if err != nil {
+			return fmt.Errorf("invalid $AVALON_REFLECTION: %w", err)
+		}
+		c.Features.Reflection = enabled
+	}
+	return nil
+}
+
+// inferStorageBackend keeps old behaviour for deployments that only set connection envs:
+// redis if its url is set, bolt if database path is set and mongo otherwise
+func (c *ServerConfig) inferStorageBackend() {
+	if c.Storage.Backend != "" {
+		return
+	}
+	switch {
+	case c.Storage.RedisURL != "":
+		c.Storage.Backend = storageRedis
+	case c.Storage.BoltPath != "":
+		c.Storage.Backend = storageBolt
+	default:
+		c.Storage.Backend = storageMongo
+	}
+}
+
+// Validate reports every problem with config at once
+func (c *ServerConfig) Validate() error {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.ListenAddr == "" {
+		addProblem("listen address is not set (use -listen, $AVALON_LISTEN_ADDR or $PORT)")
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		addProblem("shutdown timeout must be positive")
+	}
+	if c.HealthCheckInterval <= 0 {
+		addProblem("health check interval must be positive")
+	}
+
+	if c.Metrics.ListenAddr != "" && c.Metrics.ListenAddr == c.ListenAddr {
+		addProblem("metrics and gRPC can't listen on the same address %s", c.ListenAddr)
+	}
+	if gatewayAddr := c.Gateway.ListenAddr; gatewayAddr != "" && (gatewayAddr == c.ListenAddr || gatewayAddr == c.Metrics.ListenAddr) {
+		addProblem("gateway can't listen on the same address %s as gRPC or metrics", gatewayAddr)
+	}
+
+	switch c.Storage.Backend {
+	case storageMemory, storageRedis:
+		if c.Storage.SessionTTL <= 0 {
+			addProblem("session ttl must be positive for %s storage", c.Storage.Backend)
+		}
+		if c.Storage.Backend == storageRedis && c.Storage.RedisURL == "" {
+			addProblem("redis storage requires redis url (use -redis-url or $REDIS_URL)")
+		}
+	case storageMongo:
+		mongoCfg := c.Storage.Mongo
+		if mongoCfg.URI == "" && (mongoCfg.Host == "" || mongoCfg.DBName == "") {
+			addProblem("mongo storage requires $MONGO_URI or both $MONGO_HOST and $MONGO_DBNAME")
+		}
+		if mongoCfg.SessionTTL < 0 || mongoCfg.IdleTimeout < 0 {
+			addProblem("mongo session ttl and idle timeout can't be negative")
+		}
+		if mongoCfg.SessionTTL > 0 && mongoCfg.SessionTTL < time.Second {
+			addProblem("mongo session ttl must be at least a second")
+		}
+		if mongoCfg.IdleTimeout > 0 && mongoCfg.SweepInterval <= 0 {
+			addProblem("mongo sweep interval must be positive when idle timeout is set")
+		}
+		if mongoCfg.ArchiveIdle && mongoCfg.IdleTimeout <= 0 {
+			addProblem("mongo archiving requires idle timeout, only idle sessions sweeper archives sessions")
+		}
+	case storageBolt:
+		if c.Storage.BoltPath == "" {
+			addProblem("bolt storage requires database path (use -bolt-path or $BOLT_DB_PATH)")
+		}
+	default:
+		addProblem("unknown storage backend %q, expected one of: %s, %s, %s, %s",
+			c.Storage.Backend, storageMemory, storageMongo, storageRedis, storageBolt)
+	}
+
+	if c.Storage.Cache.Size < 0 {
+		addProblem("cache size can't be negative")
+	}
+	if c.Storage.Cache.Enabled() && c.Storage.Cache.TTL <= 0 {
+		addProblem("cache ttl must be positive when cache is enabled")
+	}
+
+	if c.TLS.Enabled() {
+		if c.TLS.CertFile == "" || c.TLS.KeyFile == "" {
+			addProblem("both TLS certificate and key files must be set")
+		}
+		for _, file := range []string{c.TLS.CertFile, c.TLS.KeyFile} {
+			if _, err := os.Stat(file); file != "" && err != nil {
+				addProblem("TLS file is not accessible: %v", err)
+			}
+		}
+	}
+
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		addProblem("unknown log level %q", c.Log.Level)
+	}
+	if c.Log.Format != logFormatJSON && c.Log.Format != logFormatConsole {
+		addProblem("unknown log format %q, expected %s or %s", c.Log.Format, logFormatJSON, logFormatConsole)
+	}
+
+	switch c.Tracing.Exporter {
+	case tracingExporterNone, tracingExporterStdout:
+	case tracingExporterOTLP:
+		if c.Tracing.OTLPEndpoint == "" {
+			addProblem("otlp exporter requires collector endpoint (use -otlp-endpoint or $OTEL_EXPORTER_OTLP_ENDPOINT)")
+		}
+	default:
+		addProblem("unknown tracing exporter %q, expected one of: %s, %s, %s",
+			c.Tracing.Exporter, tracingExporterNone, tracingExporterStdout, tracingExporterOTLP)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		addProblem("tracing sample ratio must be between 0 and 1")
+	}
+
+	if c.Telegram.Enabled() {
+		if c.Telegram.APIURL == "" {
+			addProblem("telegram bot requires Bot API url")
+		}
+		if c.Telegram.PollTimeout < time.Second {
+			addProblem("telegram poll timeout must be at least a second")
+		}
+		if c.Telegram.SeatBots < 0 || c.Telegram.SeatBots >= telegramMinPlayers {
+			addProblem("telegram seat bots must be between 0 and %d", telegramMinPlayers-1)
+		}
+		if _, exist := botStrategies[c.Telegram.BotStrategy]; c.Telegram.SeatBots > 0 && !exist {
+			addProblem("unknown telegram bot strategy %q, expected one of: %s", c.Telegram.BotStrategy, strings.Join(botStrategyNames(), ", "))
+		}
+	}
+
+	for n, origin := range c.Gateway.AllowedOrigins {
+		if strings.TrimSpace(origin) == "" {
+			addProblem("allowed origin #%d is empty", n+1)
+		}
+	}
+
+	for n, token := range c.Auth.Tokens {
+		if strings.TrimSpace(token) == "" {
+			addProblem("auth token #%d is empty", n+1)
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid server config:\n\t" + strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setTestEnv sets environment variable for the duration of the test
+func setTestEnv(t *testing.T, name, value string) {
+	t.Helper()
+	old, existed := os.LookupEnv(name)
+	if err := os.Setenv(name, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if existed {
+			_ = os.Setenv(name, old)
+		} else {
+			_ = os.Unsetenv(name)
+		}
+	})
+}
+
+// clearConfigEnv makes sure config is not affected by environment of the test runner
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"AVALON_CONFIG", "PORT", "AVALON_LISTEN_ADDR", "AVALON_SHUTDOWN_TIMEOUT", "AVALON_HEALTH_CHECK_INTERVAL", "AVALON_METRICS_ADDR", "AVALON_GATEWAY_ADDR", "AVALON_CORS_ORIGINS", "AVALON_GRPC_WEB", "AVALON_STORAGE", "AVALON_SESSION_TTL",
+		"MONGO_URI", "MONGO_USER", "MONGO_PASS", "MONGO_HOST", "MONGO_DBNAME", "REDIS_URL", "BOLT_DB_PATH",
+		"MONGO_SESSION_TTL", "MONGO_IDLE_TIMEOUT", "MONGO_SWEEP_INTERVAL", "MONGO_ARCHIVE_IDLE",
+		"AVALON_CACHE_SIZE", "AVALON_CACHE_TTL",
+		"AVALON_TLS_CERT", "AVALON_TLS_KEY", "AVALON_AUTH_TOKENS", "AVALON_REFLECTION",
+		"AVALON_LOG_LEVEL", "AVALON_LOG_FORMAT",
+		"AVALON_TRACING_EXPORTER", "OTEL_EXPORTER_OTLP_ENDPOINT", "AVALON_TRACING_SAMPLE_RATIO",
+		"TELEGRAM_BOT_TOKEN", "TELEGRAM_API_URL", "TELEGRAM_POLL_TIMEOUT", "TELEGRAM_SEAT_BOTS", "TELEGRAM_BOT_STRATEGY",
+	} {
+		setTestEnv(t, name, "")
+		_ = os.Unsetenv(name)
+	}
+}
+
+func TestLoadServerConfigPrecedence(t *testing.T) {
+	clearConfigEnv(t)
+
+	configPath := filepath.Join(t.TempDir(), "avalon.yaml")
+	err := ioutil.WriteFile(configPath, []byte(`
+listen_addr: ":7000"
+storage:
+  backend: memory
+  session_ttl: 10m
+auth:
+  tokens: [secret]
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setTestEnv(t, "PORT", "8000")
+	setTestEnv(t, "AVALON_SESSION_TTL", "20m")
+
+	cfg, err := LoadServerConfig([]string{"-config", configPath, "-session-ttl", "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.ListenAddr != ":8000" {
+		t.Errorf("ListenAddr = %q; env must override file", cfg.ListenAddr)
+	}
+	if cfg.Storage.Backend != storageMemory {
+		t.Errorf("Backend = %q; want value from file", cfg.Storage.Backend)
+	}
+	if cfg.Storage.SessionTTL != time.Hour {
+		t.Errorf("SessionTTL = %v; flags must override env", cfg.Storage.SessionTTL)
+	}
+	if len(cfg.Auth.Tokens) != 1 || cfg.Auth.Tokens[0] != "secret" {
+		t.Errorf("Auth.Tokens = %v; want value from file", cfg.Auth.Tokens)
+	}
+	if !cfg.Features.Reflection {
+		t.Error("reflection must be enabled by default")
+	}
+}
+
+func TestLoadServerConfigInfersBackend(t *testing.T) {
+	clearConfigEnv(t)
+	setTestEnv(t, "BOLT_DB_PATH", "/tmp/avalon.db")
+
+	cfg, err := LoadServerConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Storage.Backend != storageBolt {
+		t.Errorf("Backend = %q; want %q", cfg.Storage.Backend, storageBolt)
+	}
+}
+
+func TestLoadServerConfigValidation(t *testing.T) {
+	clearConfigEnv(t)
+
+	_, err := LoadServerConfig([]string{"-storage", "redis", "-session-ttl", "0s", "-tls-cert", "missing.pem"})
+	if err == nil {
+		t.Fatal("invalid config was accepted")
+	}
+	for _, problem := range []string{"session ttl", "redis url", "certificate and key", "not accessible"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("error %q does not mention %q", err, problem)
+		}
+	}
+
+	setTestEnv(t, "MONGO_URI", "mongodb://localhost")
+	setTestEnv(t, "MONGO_IDLE_TIMEOUT", "0s")
+	setTestEnv(t, "MONGO_ARCHIVE_IDLE", "true")
+	_, err = LoadServerConfig([]string{"-storage", "mongo"})
+	if err == nil || !strings.Contains(err.Error(), "archiving requires idle timeout") {
+		t.Errorf("archiving without sweeper error = %v", err)
+	}
+
+	_, err = LoadServerConfig([]string{"-storage", "postgres"})
+	if err == nil || !strings.Contains(err.Error(), "unknown storage backend") {
+		t.Errorf("unknown backend error = %v", err)
+	}
+
+	setTestEnv(t, "TELEGRAM_BOT_TOKEN", "token")
+	setTestEnv(t, "TELEGRAM_SEAT_BOTS", "5")
+	setTestEnv(t, "TELEGRAM_BOT_STRATEGY", "cheating")
+	_, err = LoadServerConfig(nil)
+	for _, problem := range []string{"seat bots", "bot strategy"} {
+		if err == nil || !strings.Contains(err.Error(), problem) {
+			t.Errorf("error %v does not mention %q", err, problem)
+		}
+	}
+}
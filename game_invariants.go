@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/justmax437/avalonBacker/api"
+)
+
+// gameInvariant names a property of the game state machine that must hold after any sequence of calls
+type gameInvariant string
+
+const (
+	invariantMissionsScore   gameInvariant = "missions passed+failed <= 5"
+	invariantSingleWinner    gameInvariant = "exactly one winner"
+	invariantSeatedLeader    gameInvariant = "leader is one of the players"
+	invariantStickyEnd       gameInvariant = "terminal states are sticky"
+	invariantLeaderRotation  gameInvariant = "leader rotates in AllPlayers order"
+	invariantFreshVotes      gameInvariant = "votes never carry over between rounds"
+	invariantTeamMajority    gameInvariant = "team is approved by strict majority of players"
+	invariantMissionVoters   gameInvariant = "only mission team votes for mission success"
+	invariantSingleChatGame  gameInvariant = "one active game per chat"
+	invariantStorageReadable gameInvariant = "session stays readable"
+)
+
+type invariantViolation struct {
+	invariant gameInvariant
+	details   string
+}
+
+func (v *invariantViolation) Error() string {
+	return string(v.invariant) + ": " + v.details
+}
+
+func violation(invariant gameInvariant, format string, args ...interface{}) *invariantViolation {
+	return &invariantViolation{invariant: invariant, details: fmt.Sprintf(format, args...)}
+}
+
+// checkSessionInvariants verifies what must hold for a session of players in any state.
+// Simulator checks it after every step, game service fuzz test adds checks of transitions between states.
+func checkSessionInvariants(session *api.GameSession, players []*api.Player) error {
+	if session.MissionsPassed < 0 || session.MissionsFailed < 0 || session.MissionsPassed+session.MissionsFailed > missionsCount {
+		return violation(invariantMissionsScore, "score %d:%d", session.MissionsPassed, session.MissionsFailed)
+	}
+	if findPlayer(players, session.Leader.GetId()) == nil {
+		return violation(invariantSeatedLeader, "leader %v is not in game", session.Leader)
+	}
+	if isGameOver(session.State) {
+		return checkSingleWinner(session)
+	}
+	return nil
+}
+
+// checkSingleWinner makes sure endgame outcome agrees with the winner and missions score
+func checkSingleWinner(session *api.GameSession) error {
+	outcome := session.EndgameOutcome
+	virtuousWon := session.State == api.GameSession_VIRTUOUS_TEAM_WON
+	switch outcome.GetReason() {
+	case api.EndgameOutcome_MISSIONS_PASSED, api.EndgameOutcome_ASSASSINATION_FAILED:
+		if !virtuousWon || session.MissionsPassed < 3 {
+			return violation(invariantSingleWinner, "%v won by %v with score %d:%d", session.State, outcome.Reason, session.MissionsPassed, session.MissionsFailed)
+		}
+	case api.EndgameOutcome_MISSIONS_FAILED:
+		if virtuousWon || session.MissionsFailed < 3 {
+			return violation(invariantSingleWinner, "%v won by %v with score %d:%d", session.State, outcome.Reason, session.MissionsPassed, session.MissionsFailed)
+		}
+	case api.EndgameOutcome_MERLIN_ASSASSINATED, api.EndgameOutcome_TEAM_PROPOSALS_REJECTED:
+		if virtuousWon {
+			return violation(invariantSingleWinner, "%v won by %v", session.State, outcome.Reason)
+		}
+	default:
+		return violation(invariantSingleWinner, "%v without endgame outcome", session.State)
+	}
+	return nil
+}
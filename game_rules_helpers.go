@@ -1,23 +1,114 @@
 package main
 
 import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
 	"github.com/justmax437/avalonBacker/api"
 	"math/rand"
-	"time"
+	"sort"
 )
 
-func checkNumberOfPlayersValid(goodPlayers, evilPlayers int) bool {
-	return map[int]map[int]bool{
-		3: {2: true},
-		4: {2: true, 3: true},
-		5: {3: true},
-		6: {3: true, 4: true},
-	}[goodPlayers][evilPlayers]
+// newGameSeed draws seed of game randomness from crypto/rand, so players can't predict seating
+func newGameSeed() (int64, error) {
+	var buf [8]byte
+	if _, err := cryptorand.Read(buf[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(buf[:]) >> 1), nil
 }
 
-func shufflePlayers(players []*api.Player) {
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(players), func(i, j int) {
+func shufflePlayers(players []*api.Player, rng *rand.Rand) {
+	rng.Shuffle(len(players), func(i, j int) {
 		players[i], players[j] = players[j], players[i]
 	})
 }
+
+func isGameOver(state api.GameSession_GameState) bool {
+	return state == api.GameSession_VIRTUOUS_TEAM_WON || state == api.GameSession_EVIL_TEAM_WON
+}
+
+// activeGameStates lists every state of a game that is not finished yet
+func activeGameStates() []api.GameSession_GameState {
+	states := make([]api.GameSession_GameState, 0, len(api.GameSession_GameState_name))
+	for state := range api.GameSession_GameState_name {
+		if !isGameOver(api.GameSession_GameState(state)) {
+			states = append(states, api.GameSession_GameState(state))
+		}
+	}
+	return states
+}
+
+// Telegram user ids fit in 52 bits, so ids of seat bots never collide with them.
+// Ids stay below 1<<63, as storages like mongo keep them in signed 64-bit integers.
+const seatBotIdBase uint64 = 1 << 62
+
+// seatBot makes n-th computer-controlled player to fill an empty seat
+func seatBot(n int, locale string) *api.Player {
+	return &api.Player{Id: seatBotIdBase + uint64(n), UserName: botText(locale, msgSeatBotName, n), Bot: true}
+}
+
+// dealTeams randomly splits players into teams by rules, picking Merlin, assassin and roles of rules composition.
+// Rules must be able to deal roles to that many players, see RuleSet.CanDeal.
+func dealTeams(players []*api.Player, rules *RuleSet, rng *rand.Rand) (*api.VirtuousTeam, *api.EvilTeam) {
+	players = append([]*api.Player(nil), players...)
+	shufflePlayers(players, rng)
+	evilCount := rules.PlayerCounts[len(players)].EvilPlayers
+	evil, good := players[:evilCount], players[evilCount:]
+	goodTeam := &api.VirtuousTeam{Members: good, Merlin: good[0]}
+	evilTeam := &api.EvilTeam{Members: evil, Assassin: evil[0]}
+
+	//Players are already shuffled, so roles go to them in order.
+	//Evil team has a player for every role, virtuous team is a majority of at least 2 players.
+	rest := evil[1:]
+	if rules.Roles.PercivalAndMorgana {
+		goodTeam.Percival = good[1]
+		evilTeam.Morgana, rest = rest[0], rest[1:]
+	}
+	if rules.Roles.Oberon {
+		evilTeam.Oberon, rest = rest[0], rest[1:]
+	}
+	if rules.Roles.Mordred {
+		evilTeam.Mordred = rest[0]
+	}
+	return goodTeam, evilTeam
+}
+
+// playersExcept lists players without the excluded one, nil excludes nobody
+func playersExcept(players []*api.Player, excluded *api.Player) []*api.Player {
+	rest := make([]*api.Player, 0, len(players))
+	for _, p := range players {
+		if excluded == nil || p.GetId() != excluded.Id {
+			rest = append(rest, p)
+		}
+	}
+	return rest
+}
+
+// evilKnownToMerlin lists evil players revealed to Merlin, Mordred is hidden from him
+func evilKnownToMerlin(evil *api.EvilTeam) []*api.Player {
+	return playersExcept(evil.GetMembers(), evil.GetMordred())
+}
+
+// evilKnownToEvil lists evil players revealed to each other, Oberon neither knows them nor is known to them
+func evilKnownToEvil(evil *api.EvilTeam) []*api.Player {
+	return playersExcept(evil.GetMembers(), evil.GetOberon())
+}
+
+// merlinKnownToPercival lists Merlin and Morgana, who appears to Percival as Merlin.
+// They are ordered by id, so the order doesn't tell them apart.
+func merlinKnownToPercival(good *api.VirtuousTeam, evil *api.EvilTeam) []*api.Player {
+	var known []*api.Player
+	for _, p := range []*api.Player{good.GetMerlin(), evil.GetMorgana()} {
+		if p != nil {
+			known = append(known, p)
+		}
+	}
+	sort.Slice(known, func(a, b int) bool { return known[a].Id < known[b].Id })
+	return known
+}
+
+// dealRoles deals teams by built-in rules on client side, where game config is built with teams
+func dealRoles(chatId int64, players []*api.Player, rng *rand.Rand) *api.GameConfig {
+	good, evil := dealTeams(players, &builtinRules, rng)
+	return &api.GameConfig{ChatId: chatId, GoodTeam: good, EvilTeam: evil}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGamesWithSameSeedAreReproducible(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	sessions := NewMemoryStorage(time.Minute)
+	service := NewGameService(sessions, NewVoteStorage(logger), logger)
+	service.newSeed = func() (int64, error) { return 437, nil }
+
+	var games []*GameInstance
+	for i := 0; i < 2; i++ {
+		session, err := service.CreateSession(ctx, testGameConfig(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		game, err := sessions.GetSession(ctx, testGameId(session.GameId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if game.Seed != 437 {
+			t.Errorf("game seed = %d; want 437", game.Seed)
+		}
+		games = append(games, game)
+	}
+	if !reflect.DeepEqual(games[0].AllPlayers, games[1].AllPlayers) || games[0].Leader.Id != games[1].Leader.Id {
+		t.Errorf("games with the same seed have different seating: %v and %v", games[0].AllPlayers, games[1].AllPlayers)
+	}
+}
+
+func TestCreateSessionDealsRoles(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	sessions := NewMemoryStorage(time.Minute)
+	service := NewGameService(sessions, NewVoteStorage(logger), logger)
+	service.newSeed = func() (int64, error) { return 437, nil }
+	players := testGameConfig(0).GoodTeam.Members
+	players = append(players, testGameConfig(0).EvilTeam.Members...)
+
+	var games []*GameInstance
+	for i := 0; i < 2; i++ {
+		session, err := service.CreateSession(ctx, &api.GameConfig{Players: players})
+		if err != nil {
+			t.Fatal(err)
+		}
+		game, err := sessions.GetSession(ctx, testGameId(session.GameId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(game.GameConfig.GoodTeam.Members) != 3 || len(game.GameConfig.EvilTeam.Members) != 2 || game.GameConfig.Players != nil {
+			t.Fatalf("dealt config = %+v; want 3 virtuous and 2 evil players", game.GameConfig)
+		}
+		if game.GoodTeam.Merlin == nil || game.EvilTeam.Assassin == nil {
+			t.Errorf("Merlin %v or assassin %v was not picked", game.GoodTeam.Merlin, game.EvilTeam.Assassin)
+		}
+		games = append(games, game)
+	}
+	if !reflect.DeepEqual(games[0].GameConfig.EvilTeam, games[1].GameConfig.EvilTeam) || !reflect.DeepEqual(games[0].AllPlayers, games[1].AllPlayers) {
+		t.Error("games with the same seed were dealt differently")
+	}
+
+	//Teams are either dealt or provided
+	config := testGameConfig(0)
+	config.Players = players
+	if _, err := service.CreateSession(ctx, config); err == nil {
+		t.Error("session was created with both players and teams")
+	}
+	if _, err := service.CreateSession(ctx, &api.GameConfig{Players: players[:4]}); err == nil {
+		t.Error("session was created for unsupported number of players")
+	}
+
+	session, err := service.CreateSession(ctx, &api.GameConfig{Players: players[:3], BotPlayers: 2, Locale: localeEnglish})
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := sessions.GetSession(ctx, testGameId(session.GameId))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bots := 0
+	for _, p := range game.AllPlayers {
+		if p.Bot {
+			bots++
+			if p.Id <= seatBotIdBase || p.UserName != fmt.Sprint("🤖 Bot ", p.Id-seatBotIdBase) {
+				t.Errorf("bot %v has no bot id or name", p)
+			}
+		}
+	}
+	if bots != 2 || len(game.AllPlayers) != 5 {
+		t.Errorf("%d bots of %d players seated; want 2 of 5", bots, len(game.AllPlayers))
+	}
+	if _, err = service.CreateSession(ctx, &api.GameConfig{Players: []*api.Player{seatBot(1, localeEnglish)}, BotPlayers: 4}); err == nil {
+		t.Error("player with bot id was seated along with bots")
+	}
+
+	roles := &api.GameExtensions{PercivalAndMorgana: true}
+	if session, err = service.CreateSession(ctx, &api.GameConfig{Players: players, Extensions: roles}); err != nil {
+		t.Fatal(err)
+	}
+	if game, err = sessions.GetSession(ctx, testGameId(session.GameId)); err != nil {
+		t.Fatal(err)
+	}
+	good, evil := game.GoodTeam, game.EvilTeam
+	if good.Percival == nil || good.Percival.Id == good.Merlin.Id || evil.Morgana == nil || evil.Morgana.Id == evil.Assassin.Id || evil.Oberon != nil || evil.Mordred != nil {
+		t.Errorf("roles of %+v were not dealt to virtuous %+v and evil %+v", roles, good, evil)
+	}
+	roles.Oberon = true
+	if _, err = service.CreateSession(ctx, &api.GameConfig{Players: players, Extensions: roles}); err == nil {
+		t.Error("3 evil roles were dealt to 2 evil players")
+	}
+
+	//Teams dealt by client are checked against the same roles
+	teamsGood, teamsEvil := specialRolesTeams()
+	roles = &api.GameExtensions{PercivalAndMorgana: true, Oberon: true, Mordred: true}
+	if session, err = service.CreateSession(ctx, &api.GameConfig{GoodTeam: teamsGood, EvilTeam: teamsEvil, Extensions: roles}); err != nil {
+		t.Fatal(err)
+	}
+	if game, err = sessions.GetSession(ctx, testGameId(session.GameId)); err != nil {
+		t.Fatal(err)
+	}
+	if game.EvilTeam.Mordred.GetId() != teamsEvil.Mordred.Id {
+		t.Errorf("Mordred %v was not kept in session", game.EvilTeam.Mordred)
+	}
+	if _, err = service.TerminateSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	roles.Mordred = false
+	if _, err = service.CreateSession(ctx, &api.GameConfig{GoodTeam: teamsGood, EvilTeam: teamsEvil, Extensions: roles}); err == nil {
+		t.Error("teams with Mordred out of game were accepted")
+	}
+}
+
+func TestGameInstanceRand(t *testing.T) {
+	game := &GameInstance{Seed: 437}
+	first, second := game.Rand().Int63(), game.Rand().Int63()
+	if game.Rand() != game.Rand() || first == second {
+		t.Error("every call of Rand starts a new generator")
+	}
+}
+
+func TestNewGameSeed(t *testing.T) {
+	seeds := make(map[int64]bool)
+	for i := 0; i < 100; i++ {
+		seed, err := newGameSeed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seed < 0 {
+			t.Errorf("negative seed %d", seed)
+		}
+		seeds[seed] = true
+	}
+	if len(seeds) < 100 {
+		t.Errorf("got %d distinct seeds out of 100", len(seeds))
+	}
+}
@@ -7,32 +7,107 @@ import (
 	"github.com/gogo/protobuf/types"
 	"github.com/google/uuid"
 	"github.com/justmax437/avalonBacker/api"
-	"log"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+// Used by ListSessions when client did not specify the limit
+const defaultSessionsListLimit = 50
+
+// Carry gRPC codes, so clients and REST gateway could tell them from failures
+var (
+	ErrChatAlreadyHasGame = status.Error(codes.AlreadyExists, "chat already has an active game session")
+	ErrNoActiveGameInChat = status.Error(codes.NotFound, "no active game session in chat")
+	errNoVoter            = status.Error(codes.InvalidArgument, "voter is not specified")
 )
 
 type simpleGameService struct {
 	sessions GameSessionStorage
-	votes    *VoteStorage
+	votes    VoteStorage
+	logger   *zap.Logger //Used when there is no request logger in context
+	//newSeed seeds randomness of new games, tests replace it to get reproducible games
+	newSeed func() (int64, error)
+
+	//Serializes active game check and creation of new sessions in this process,
+	//storages shared between replicas enforce single active game per chat with ChatSessionStorage
+	createLock sync.Mutex
 }
 
-func NewGameService(s GameSessionStorage, votes *VoteStorage) *simpleGameService {
+func NewGameService(s GameSessionStorage, votes VoteStorage, logger *zap.Logger) *simpleGameService {
 	if s == nil {
-		log.Fatal("GameSessionStorage not provided")
+		logger.Fatal("GameSessionStorage not provided")
 	}
 	gs := new(simpleGameService)
 	gs.sessions = s
 	gs.votes = votes
+	gs.logger = logger
+	gs.newSeed = newGameSeed
 	return gs
 }
 
-func (g *simpleGameService) CreateSession(_ context.Context, config *api.GameConfig) (*api.GameSession, error) {
-	if !checkNumberOfPlayersValid(len(config.GoodTeam.Members), len(config.EvilTeam.Members)) {
-		return nil, errors.New("provided teams are not balanced by the game rules")
+func (g *simpleGameService) CreateSession(ctx context.Context, config *api.GameConfig) (*api.GameSession, error) {
+	rules, err := newRuleSet(config.Rules, config.Extensions)
+	if err != nil {
+		return nil, errors.New("invalid game rules: " + err.Error())
+	}
+
+	locale, err := normalizeLocale(config.Locale)
+	if err != nil {
+		return nil, err
 	}
 
 	newGame := new(GameInstance)
 	newGame.GameConfig = *config
+	if newGame.Seed, err = g.newSeed(); err != nil {
+		return nil, errors.New("failed to seed game randomness: " + err.Error())
+	}
+	players := config.Players
+	if config.BotPlayers > 0 {
+		for _, p := range players {
+			if p.GetId() >= seatBotIdBase {
+				return nil, fmt.Errorf("player ids from %d are reserved for bots", seatBotIdBase)
+			}
+		}
+		for n := 1; n <= int(config.BotPlayers); n++ {
+			players = append(players[:len(players):len(players)], seatBot(n, locale))
+		}
+	}
+	if len(players) > 0 {
+		if len(config.GoodTeam.GetMembers())+len(config.EvilTeam.GetMembers()) > 0 {
+			return nil, errors.New("either players or teams must be provided, not both")
+		}
+		if err := rules.CanDeal(len(players)); err != nil {
+			return nil, err
+		}
+		newGame.GameConfig.GoodTeam, newGame.GameConfig.EvilTeam = dealTeams(players, rules, newGame.Rand())
+		//Session keeps dealt players in teams only
+		newGame.GameConfig.Players, newGame.GameConfig.BotPlayers = nil, 0
+	} else if err := rules.CheckTeams(config.GoodTeam, config.EvilTeam); err != nil {
+		return nil, err
+	}
+
+	if config.ChatId != 0 {
+		g.createLock.Lock()
+		defer g.createLock.Unlock()
+
+		active, err := g.findActiveSessionForChat(ctx, config.ChatId)
+		if err != nil {
+			return nil, errors.New("failed to check for active sessions in chat: " + err.Error())
+		}
+		if active != nil {
+			return nil, ErrChatAlreadyHasGame
+		}
+	}
+
 	newGame.GameId = &api.UUID{Value: uuid.New().String()}
+	newGame.GameSession.ChatId = config.ChatId
+	newGame.GameConfig.Locale = locale
+	newGame.GameSession.Locale = locale
+	//Complete rules are kept, so changes of built-in rules don't affect games in progress
+	newGame.GameConfig.Rules = rules.Proto()
 	newGame.State = api.GameSession_GAME_CREATED
 	newGame.MissionTeam = api.MissionTeam{}
 	newGame.Mission = api.PendingMission{
@@ -40,19 +115,29 @@ func (g *simpleGameService) CreateSession(_ context.Context, config *api.GameCon
 		TeamPickingAttempts: 0,
 	}
 	newGame.LastMissionResult = nil
+	newGame.CreatedAt = time.Now().UTC()
 
-	allPLayers := make([]*api.Player, 0, len(config.EvilTeam.Members)+len(config.GoodTeam.Members))
+	good, evil := newGame.GameConfig.GoodTeam, newGame.GameConfig.EvilTeam
+	allPLayers := make([]*api.Player, 0, len(evil.Members)+len(good.Members))
 	allPLayers = append(
-		append(allPLayers, config.EvilTeam.Members...),
-		config.GoodTeam.Members...)
-	shufflePlayers(allPLayers)
+		append(allPLayers, evil.Members...),
+		good.Members...)
+	shufflePlayers(allPLayers, newGame.Rand())
 	newGame.Leader = allPLayers[0]
 	newGame.CurrentLeaderIndex = 0
 	newGame.AllPlayers = allPLayers
 
-	g.votes.ResetVotes(apiIDToUUID(newGame.GetGameId()))
-	err := g.sessions.StoreSession(newGame)
+	err = g.storeNewSession(ctx, newGame)
+	if err == ErrChatAlreadyHasGame {
+		return nil, err
+	}
 	if err == nil {
+		gamesStarted.Inc()
+		loggerFromContext(ctx, g.logger).Info("game created",
+			apiGameIdField(newGame.GameId),
+			zap.Int64("chat_id", newGame.GameSession.ChatId),
+			zap.Int("players", len(allPLayers)),
+		)
 		return &newGame.GameSession, nil
 	} else {
 		return nil, errors.New("failed to store game session: " + err.Error())
@@ -60,49 +145,53 @@ func (g *simpleGameService) CreateSession(_ context.Context, config *api.GameCon
 
 }
 
-func (g *simpleGameService) TerminateSession(_ context.Context, session *api.GameSession) (*types.Empty, error) {
-	gameId, err := uuid.Parse(session.GameId.Value)
+func (g *simpleGameService) TerminateSession(ctx context.Context, session *api.GameSession) (*types.Empty, error) {
+	gameId, err := apiIDToUUID(session.GetGameId())
 	if err != nil {
-		return nil, errors.New("failed to parse session UUID: " + err.Error())
+		return nil, err
 	}
 
-	if exist, err := g.sessions.CheckExistence(gameId); err == nil && exist {
-		return &types.Empty{}, g.sessions.CloseSession(gameId)
-	} else {
+	exist, err := g.sessions.CheckExistence(ctx, gameId)
+	if err != nil {
 		return nil, errors.New("failed to terminate session: " + err.Error())
 	}
+	if !exist {
+		return nil, status.Error(codes.NotFound, "failed to terminate session: "+ErrSessionNotFound.Error())
+	}
+	return &types.Empty{}, g.sessions.CloseSession(ctx, gameId)
 }
 
-func (g *simpleGameService) GetSession(_ context.Context, gameId *api.UUID) (*api.GameSession, error) {
-	gi, err := g.sessions.GetSession(apiIDToUUID(gameId))
+func (g *simpleGameService) GetSession(ctx context.Context, gameId *api.UUID) (*api.GameSession, error) {
+	gi, _, err := g.readSession(ctx, gameId)
 	if err != nil {
-		return nil, errors.New("failed to read session data: " + err.Error())
+		return nil, err
 	}
 	return &gi.GameSession, nil
 }
 
-func (g *simpleGameService) GetEvilTeam(_ context.Context, session *api.GameSession) (*api.EvilTeam, error) {
-	game, err := g.sessions.GetSession(apiIDToUUID(session.GameId))
+func (g *simpleGameService) GetEvilTeam(ctx context.Context, session *api.GameSession) (*api.EvilTeam, error) {
+	game, _, err := g.readSession(ctx, session.GetGameId())
 	if err != nil {
-		return nil, errors.New("failed to read session data: " + err.Error())
+		return nil, err
 	}
 	return game.GetEvilTeam(), nil
 }
 
-func (g *simpleGameService) GetVirtuousTeam(_ context.Context, session *api.GameSession) (*api.VirtuousTeam, error) {
-	game, err := g.sessions.GetSession(apiIDToUUID(session.GameId))
+func (g *simpleGameService) GetVirtuousTeam(ctx context.Context, session *api.GameSession) (*api.VirtuousTeam, error) {
+	game, _, err := g.readSession(ctx, session.GetGameId())
 	if err != nil {
-		return nil, errors.New("failed to read session data: " + err.Error())
+		return nil, err
 	}
 	return game.GetGoodTeam(), nil
 }
 
-func (g *simpleGameService) PushGameState(_ context.Context, session *api.GameSession) (*api.GameSession, error) {
+func (g *simpleGameService) PushGameState(ctx context.Context, session *api.GameSession) (*api.GameSession, error) {
+	logger := loggerFromContext(ctx, g.logger)
 	//Explicitly ignore everything except game id received from clients
 	//Game state date from outside cannot be trusted
-	game, err := g.sessions.GetSession(apiIDToUUID(session.GameId))
+	game, gameId, err := g.readSession(ctx, session.GetGameId())
 	if err != nil {
-		return nil, errors.New("failed to read session data: " + err.Error())
+		return nil, err
 	}
 
 	switch game.GetState() {
@@ -116,7 +205,7 @@ func (g *simpleGameService) PushGameState(_ context.Context, session *api.GameSe
 			TeamPickingAttempts: 0,
 		}
 
-		if err := g.sessions.StoreSession(game); err != nil {
+		if err := g.sessions.StoreSession(ctx, game); err != nil {
 			return nil, errors.New("failed to store session data: " + err.Error())
 		}
 
@@ -127,36 +216,47 @@ func (g *simpleGameService) PushGameState(_ context.Context, session *api.GameSe
 			return nil, errors.New("mission team was not assigned, call AssignMissionTeam first")
 		} else {
 			game.State = api.GameSession_MISSION_TEAM_VOTING
-			if err := g.sessions.StoreSession(game); err != nil {
+			if err := g.sessions.StoreSession(ctx, game); err != nil {
 				return nil, errors.New("failed to store session data: " + err.Error())
 			}
 		}
 		return &game.GameSession, nil
 	case api.GameSession_MISSION_TEAM_VOTING:
-		if game.TotalPlayersCount() > int(g.votes.NumberOfPlayersVotedForTeam(apiIDToUUID(session.GetGameId()))) {
-			log.Println(game.GameId, "not all players voted")
+		teamVotes, err := g.votes.GetTeamVotes(ctx, gameId)
+		if err != nil {
+			return nil, errors.New("failed to read votes: " + err.Error())
+		}
+		result, notVoted := teamVotes.Result(game.AllPlayers)
+		if notVoted > 0 {
+			logger.Info("not all players voted", stateField(game.State), zap.Int("voted", game.TotalPlayersCount()-notVoted))
 			return nil, errors.New("not all players voted")
 		}
 
-		if g.votes.GetTeamVotesCountForGame(apiIDToUUID(session.GameId)) <= 0 {
-			// Mission Failed
-			game.State = api.GameSession_MISSION_TEAM_PICKING
+		game.LastTeamVote = result
+		if !result.Approved {
+			//Rejected team never goes on a mission, the next leader proposes a new one
 			game.Mission.TeamPickingAttempts++
-			if game.Mission.TeamPickingAttempts == 6 {
-				game.State = api.GameSession_EVIL_TEAM_WON
-				game.EndgameReason = "Прошло 5 неудачных голосований за состав команды"
-				return &game.GameSession, nil
+			game.MissionTeam.Members = nil
+			rules, err := game.RuleSet()
+			if err != nil {
+				return nil, err
 			}
-
-			if game.TotalPlayersCount() == game.CurrentLeaderIndex+1 {
-				game.CurrentLeaderIndex = 0
-			} else {
-				game.CurrentLeaderIndex++
+			if game.Mission.TeamPickingAttempts >= rules.MaxRejectedProposals {
+				setGameOutcome(game, api.GameSession_EVIL_TEAM_WON, &api.EndgameOutcome{
+					Reason:            api.EndgameOutcome_TEAM_PROPOSALS_REJECTED,
+					RejectedProposals: game.Mission.TeamPickingAttempts,
+				})
+				if err := g.storeSessionAndResetVotes(ctx, game); err != nil {
+					return nil, errors.New("failed to store session data: " + err.Error())
+				}
+				g.gameFinished(ctx, game)
+				return &game.GameSession, nil
 			}
 
-			game.Leader = game.AllPlayers[game.CurrentLeaderIndex]
+			game.State = api.GameSession_MISSION_TEAM_PICKING
+			game.PassLeadership()
 
-			if err := g.sessions.StoreSession(game); err != nil {
+			if err := g.storeSessionAndResetVotes(ctx, game); err != nil {
 				return nil, errors.New("failed to store session data: " + err.Error())
 			}
 
@@ -166,7 +266,8 @@ func (g *simpleGameService) PushGameState(_ context.Context, session *api.GameSe
 		//GameInstance.MissionTeam is already set in AssignMissionTeam call, so we just proceed to next state
 		game.State = api.GameSession_MISSION_SUCCESS_VOTING
 
-		if err := g.sessions.StoreSession(game); err != nil {
+		//Team votes are of no use anymore, mission votes start from scratch
+		if err := g.storeSessionAndResetVotes(ctx, game); err != nil {
 			return nil, errors.New("failed to store session data: " + err.Error())
 		}
 
@@ -174,18 +275,26 @@ func (g *simpleGameService) PushGameState(_ context.Context, session *api.GameSe
 	case api.GameSession_MISSION_SUCCESS_VOTING:
 		// TODO check if everyone voted when votes storage are done
 
-		if len(game.MissionTeam.Members) > g.votes.NumberOfPlayersVotedForMission(apiIDToUUID(session.GetGameId())) {
-			log.Println(game.GameId, "not all players in mission team voted")
+		votedCount, err := g.votes.NumberOfPlayersVotedForMission(ctx, gameId)
+		if err != nil {
+			return nil, errors.New("failed to read votes: " + err.Error())
+		}
+		if len(game.MissionTeam.Members) > votedCount {
+			logger.Info("not all players in mission team voted", stateField(game.State), zap.Int("voted", votedCount))
 			return nil, errors.New("not all players in mission team voted")
 		}
 
-		failVotesRequired := 1
-		if game.Mission.MissionNumber == 4 &&
-			game.TotalPlayersCount() >= 7 {
-			failVotesRequired = 2
+		rules, err := game.RuleSet()
+		if err != nil {
+			return nil, err
 		}
+		failVotesRequired := rules.FailsRequired(game.TotalPlayersCount(), game.Mission.MissionNumber)
 
-		failVotes := len(game.MissionTeam.Members) - int(g.votes.GetMissionVotesCountForGame(apiIDToUUID(game.GetGameId())))
+		missionVotes, err := g.votes.GetMissionVotesCountForGame(ctx, gameId)
+		if err != nil {
+			return nil, errors.New("failed to read votes: " + err.Error())
+		}
+		failVotes := len(game.MissionTeam.Members) - int(missionVotes)
 		game.State = api.GameSession_MISSION_ENDED
 		game.LastMissionResult = &api.MissionResult{
 			Failed:        failVotes >= failVotesRequired,
@@ -199,8 +308,7 @@ func (g *simpleGameService) PushGameState(_ context.Context, session *api.GameSe
 			game.MissionsPassed++
 		}
 
-		g.votes.ResetVotes(apiIDToUUID(game.GetGameId()))
-		if err := g.sessions.StoreSession(game); err != nil {
+		if err := g.storeSessionAndResetVotes(ctx, game); err != nil {
 			return nil, errors.New("failed to store session data: " + err.Error())
 		}
 
@@ -212,12 +320,13 @@ func (g *simpleGameService) PushGameState(_ context.Context, session *api.GameSe
 
 		if game.Mission.MissionNumber < 6 {
 			game.State = api.GameSession_MISSION_TEAM_PICKING
+			game.PassLeadership()
 		} else {
 			game.Mission.MissionNumber = 0 // No mission
 			game.State = api.GameSession_POST_MISSIONS_ACTIONS
 		}
 
-		if err := g.sessions.StoreSession(game); err != nil {
+		if err := g.sessions.StoreSession(ctx, game); err != nil {
 			return nil, errors.New("failed to store session data: " + err.Error())
 		}
 
@@ -227,61 +336,86 @@ func (g *simpleGameService) PushGameState(_ context.Context, session *api.GameSe
 		//than the winner is calculated from how many missions were a success
 
 		if game.MissionsPassed >= game.MissionsFailed {
-			game.State = api.GameSession_VIRTUOUS_TEAM_WON
-			game.EndgameReason = fmt.Sprintf("Силы добра победили в %d/5 миссий", game.MissionsPassed)
+			setGameOutcome(game, api.GameSession_VIRTUOUS_TEAM_WON, &api.EndgameOutcome{Reason: api.EndgameOutcome_MISSIONS_PASSED})
 		} else {
-			game.State = api.GameSession_EVIL_TEAM_WON
-			game.EndgameReason = fmt.Sprintf("Силы зла победили в %d/5 миссий, ", game.MissionsFailed)
+			setGameOutcome(game, api.GameSession_EVIL_TEAM_WON, &api.EndgameOutcome{Reason: api.EndgameOutcome_MISSIONS_FAILED})
 		}
 
-		if err := g.sessions.StoreSession(game); err != nil {
+		if err := g.sessions.StoreSession(ctx, game); err != nil {
 			return nil, errors.New("failed to store session data: " + err.Error())
 		}
+		g.gameFinished(ctx, game)
 
 		return &game.GameSession, nil
 	default:
 		return nil, errors.New("unknown game state encountered")
 	}
-
-	//Should never come to this
-	return nil, errors.New("unknown error")
 }
 
-func (g *simpleGameService) GetPendingMission(_ context.Context, session *api.GameSession) (*api.PendingMission, error) {
-	game, err := g.sessions.GetSession(apiIDToUUID(session.GameId))
+func (g *simpleGameService) GetPendingMission(ctx context.Context, session *api.GameSession) (*api.PendingMission, error) {
+	game, _, err := g.readSession(ctx, session.GetGameId())
 	if err != nil {
-		return nil, errors.New("failed to read session data: " + err.Error())
+		return nil, err
 	}
 
 	if game.Mission.GetMissionNumber() == 0 {
 		return nil, errors.New("no mission in progress")
 	} else {
-		return &game.Mission, nil
+		rules, err := game.RuleSet()
+		if err != nil {
+			return nil, err
+		}
+		mission := game.Mission
+		mission.TeamSize = uint32(rules.TeamSize(game.TotalPlayersCount(), mission.MissionNumber))
+		mission.FailsRequired = uint32(rules.FailsRequired(game.TotalPlayersCount(), mission.MissionNumber))
+		mission.MaxTeamPickingAttempts = rules.MaxRejectedProposals
+		return &mission, nil
 	}
 }
 
-func (g *simpleGameService) AssignMissionTeam(_ context.Context, assignReq *api.AssignTeamContext) (*types.Empty, error) {
-	game, err := g.sessions.GetSession(apiIDToUUID(assignReq.Session.GameId))
+func (g *simpleGameService) AssignMissionTeam(ctx context.Context, assignReq *api.AssignTeamContext) (*types.Empty, error) {
+	game, _, err := g.readSession(ctx, assignReq.GetSession().GetGameId())
 	if err != nil {
-		return nil, errors.New("failed to read session data: " + err.Error())
+		return nil, err
 	}
 
 	if game.GetState() != api.GameSession_MISSION_TEAM_PICKING {
 		return nil, errors.New("mission teams assignment only allowed in MISSION_TEAM_PICKING state")
 	}
 
-	game.MissionTeam = *assignReq.Team
-	if err = g.sessions.StoreSession(game); err != nil {
+	rules, err := game.RuleSet()
+	if err != nil {
+		return nil, err
+	}
+	if size := rules.TeamSize(game.TotalPlayersCount(), game.Mission.MissionNumber); len(assignReq.Team.GetMembers()) != size {
+		return nil, fmt.Errorf("mission %d team must have %d players", game.Mission.MissionNumber, size)
+	}
+
+	//Team is kept with players as seated in session, not as sent by client
+	members := make([]*api.Player, 0, len(assignReq.Team.GetMembers()))
+	for _, m := range assignReq.Team.GetMembers() {
+		player := findPlayer(game.AllPlayers, m.GetId())
+		if player == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "player %d is not in the game", m.GetId())
+		}
+		if findPlayer(members, player.Id) != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "player %d is in the team more than once", player.Id)
+		}
+		members = append(members, player)
+	}
+
+	game.MissionTeam = api.MissionTeam{Members: members}
+	if err = g.sessions.StoreSession(ctx, game); err != nil {
 		return nil, err
 	}
 
 	return &types.Empty{}, nil
 }
 
-func (g *simpleGameService) GetMissionTeam(_ context.Context, session *api.GameSession) (*api.MissionTeam, error) {
-	game, err := g.sessions.GetSession(apiIDToUUID(session.GameId))
+func (g *simpleGameService) GetMissionTeam(ctx context.Context, session *api.GameSession) (*api.MissionTeam, error) {
+	game, _, err := g.readSession(ctx, session.GetGameId())
 	if err != nil {
-		return nil, errors.New("failed to read session data: " + err.Error())
+		return nil, err
 	}
 	if game.GetState() > api.GameSession_MISSION_TEAM_PICKING && game.State <= api.GameSession_MISSION_ENDED {
 		return nil, errors.New("no active mission")
@@ -289,10 +423,13 @@ func (g *simpleGameService) GetMissionTeam(_ context.Context, session *api.GameS
 	return &game.MissionTeam, nil
 }
 
-func (g *simpleGameService) VoteForMissionTeam(_ context.Context, ctx *api.VoteContext) (*types.Empty, error) {
-	game, err := g.sessions.GetSession(apiIDToUUID(ctx.Session.GetGameId()))
+func (g *simpleGameService) VoteForMissionTeam(ctx context.Context, vote *api.VoteContext) (*types.Empty, error) {
+	if vote.GetVoter() == nil {
+		return nil, errNoVoter
+	}
+	game, gameId, err := g.readSession(ctx, vote.GetSession().GetGameId())
 	if err != nil {
-		return nil, errors.New("failed to read session data: " + err.Error())
+		return nil, err
 	}
 
 	if len(game.MissionTeam.Members) == 0 {
@@ -303,41 +440,64 @@ func (g *simpleGameService) VoteForMissionTeam(_ context.Context, ctx *api.VoteC
 		return nil, errors.New("mission team votes are only allowed in MISSION_TEAM_VOTING state")
 	}
 
-	if ctx.GetVote() == api.VoteContext_NEGATIVE {
-		g.votes.AddNegativeTeamVote(apiIDToUUID(ctx.Session.GetGameId()), ctx.Voter)
+	if _, known := api.VoteContext_VoteOption_name[int32(vote.GetVote())]; !known {
+		return nil, errors.New("unknown vote option")
 	}
 
-	if ctx.GetVote() == api.VoteContext_POSITIVE {
-		g.votes.AddPositiveTeamVote(apiIDToUUID(ctx.Session.GetGameId()), ctx.Voter)
+	err = g.votes.AddTeamVote(ctx, gameId, vote.Voter, vote.GetVote())
+	if err == ErrRepeatedVote {
+		//First vote stands, repeated one is ignored
+		return &types.Empty{}, nil
 	}
-
+	if err != nil {
+		return nil, errors.New("failed to store vote: " + err.Error())
+	}
+	observeVote("team", vote.GetVote())
 	return &types.Empty{}, nil
 }
 
-func (g *simpleGameService) VoteForMissionSuccess(_ context.Context, ctx *api.VoteContext) (*types.Empty, error) {
-	game, err := g.sessions.GetSession(apiIDToUUID(ctx.Session.GetGameId()))
+func (g *simpleGameService) VoteForMissionSuccess(ctx context.Context, vote *api.VoteContext) (*types.Empty, error) {
+	if vote.GetVoter() == nil {
+		return nil, errNoVoter
+	}
+	game, gameId, err := g.readSession(ctx, vote.GetSession().GetGameId())
 	if err != nil {
-		return nil, errors.New("failed to read session data: " + err.Error())
+		return nil, err
 	}
 
 	if game.GetState() != api.GameSession_MISSION_SUCCESS_VOTING {
 		return nil, errors.New("mission team votes are only allowed in MISSION_SUCCESS_VOTING state")
 	}
+	if findPlayer(game.MissionTeam.Members, vote.Voter.GetId()) == nil {
+		return nil, status.Error(codes.PermissionDenied, "only mission team members vote for mission success")
+	}
 
-	switch ctx.Vote {
+	switch vote.Vote {
 	case api.VoteContext_NEGATIVE:
-		g.votes.AddNegativeMissionVote(apiIDToUUID(game.GetGameId()), ctx.Voter)
+		err = g.votes.AddNegativeMissionVote(ctx, gameId, vote.Voter)
 	case api.VoteContext_POSITIVE:
-		g.votes.AddPositiveMissionVote(apiIDToUUID(game.GetGameId()), ctx.Voter)
+		err = g.votes.AddPositiveMissionVote(ctx, gameId, vote.Voter)
+	default:
+		return nil, errors.New("mission votes can only be positive or negative")
 	}
 
+	if err == ErrRepeatedVote {
+		return &types.Empty{}, nil
+	}
+	if err != nil {
+		return nil, errors.New("failed to store vote: " + err.Error())
+	}
+	observeVote("mission", vote.GetVote())
 	return &types.Empty{}, nil
 }
 
-func (g *simpleGameService) AssassinateAllegedMerlin(_ context.Context, ctx *api.AssassinationContext) (*api.AssassinationOutcome, error) {
-	game, err := g.sessions.GetSession(apiIDToUUID(ctx.Session.GetGameId()))
+func (g *simpleGameService) AssassinateAllegedMerlin(ctx context.Context, assassination *api.AssassinationContext) (*api.AssassinationOutcome, error) {
+	if assassination.GetTarget() == nil {
+		return nil, status.Error(codes.InvalidArgument, "assassination target is not specified")
+	}
+	game, _, err := g.readSession(ctx, assassination.GetSession().GetGameId())
 	if err != nil {
-		return nil, errors.New("failed to read session data: " + err.Error())
+		return nil, err
 	}
 
 	if game.GetState() != api.GameSession_POST_MISSIONS_ACTIONS {
@@ -348,14 +508,17 @@ func (g *simpleGameService) AssassinateAllegedMerlin(_ context.Context, ctx *api
 		return nil, errors.New("evil team is already winning, no assassination required")
 	}
 
-	if game.GoodTeam.Merlin.Id == ctx.Target.Id {
+	if game.GoodTeam.GetMerlin().GetId() == assassination.Target.Id {
 		//Evils successfully found merlin
-		game.State = api.GameSession_EVIL_TEAM_WON
-		game.EndgameReason = "Мерлин был убит ассасином"
+		setGameOutcome(game, api.GameSession_EVIL_TEAM_WON, &api.EndgameOutcome{
+			Reason:       api.EndgameOutcome_MERLIN_ASSASSINATED,
+			Assassinated: assassination.Target,
+		})
 
-		if err := g.sessions.StoreSession(game); err != nil {
+		if err := g.sessions.StoreSession(ctx, game); err != nil {
 			return nil, errors.New("failed to store session data: " + err.Error())
 		}
+		g.gameFinished(ctx, game)
 
 		return &api.AssassinationOutcome{
 			Session:         &game.GameSession,
@@ -363,12 +526,15 @@ func (g *simpleGameService) AssassinateAllegedMerlin(_ context.Context, ctx *api
 		}, nil
 	} else {
 		//Merlin stays alive
-		game.State = api.GameSession_VIRTUOUS_TEAM_WON
-		game.EndgameReason = fmt.Sprintf("%d/5 миссий завершены победой добра и Ассасину не удалось убить Мерлина", game.MissionsPassed)
+		setGameOutcome(game, api.GameSession_VIRTUOUS_TEAM_WON, &api.EndgameOutcome{
+			Reason:       api.EndgameOutcome_ASSASSINATION_FAILED,
+			Assassinated: assassination.Target,
+		})
 
-		if err := g.sessions.StoreSession(game); err != nil {
+		if err := g.sessions.StoreSession(ctx, game); err != nil {
 			return nil, errors.New("failed to store session data: " + err.Error())
 		}
+		g.gameFinished(ctx, game)
 
 		return &api.AssassinationOutcome{
 			Session:         &game.GameSession,
@@ -376,6 +542,165 @@ func (g *simpleGameService) AssassinateAllegedMerlin(_ context.Context, ctx *api
 		}, nil
 	}
 }
-func apiIDToUUID(id *api.UUID) uuid.UUID {
-	return uuid.MustParse(id.GetValue())
+
+func (g *simpleGameService) ListSessions(ctx context.Context, req *api.ListSessionsRequest) (*api.ListSessionsResponse, error) {
+	filter := SessionFilter{
+		States:   req.GetStates(),
+		PlayerId: req.GetPlayerId(),
+		ChatId:   req.GetChatId(),
+		Offset:   uint(req.GetOffset()),
+		Limit:    uint(req.GetLimit()),
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultSessionsListLimit
+	}
+	if req.GetCreatedAfter() != nil {
+		createdAfter, err := types.TimestampFromProto(req.GetCreatedAfter())
+		if err != nil {
+			return nil, errors.New("invalid created_after timestamp: " + err.Error())
+		}
+		filter.CreatedAfter = createdAfter
+	}
+
+	//Request one extra session to find out if there is a next page
+	pageSize := filter.Limit
+	filter.Limit++
+	games, err := g.sessions.ListSessions(ctx, filter)
+	if err != nil {
+		return nil, errors.New("failed to list sessions: " + err.Error())
+	}
+
+	resp := &api.ListSessionsResponse{
+		Sessions: make([]*api.GameSession, 0, len(games)),
+	}
+	if uint(len(games)) > pageSize {
+		resp.HasMore = true
+		games = games[:pageSize]
+	}
+	for _, game := range games {
+		resp.Sessions = append(resp.Sessions, &game.GameSession)
+	}
+
+	return resp, nil
+}
+
+func (g *simpleGameService) GetActiveSessionForChat(ctx context.Context, chat *api.Chat) (*api.GameSession, error) {
+	if chat.GetId() == 0 {
+		return nil, errors.New("chat id is not specified")
+	}
+
+	game, err := g.findActiveSessionForChat(ctx, chat.GetId())
+	if err != nil {
+		return nil, sessionReadError(err)
+	}
+	if game == nil {
+		return nil, ErrNoActiveGameInChat
+	}
+	return &game.GameSession, nil
+}
+
+// findActiveSessionForChat returns nil if there is no unfinished game in chat
+func (g *simpleGameService) findActiveSessionForChat(ctx context.Context, chatId int64) (*GameInstance, error) {
+	//Storages binding sessions to chats find the game without listing every session
+	if _, ok := unwrapStorage(g.sessions).(ChatSessionStorage); ok {
+		return g.sessions.(ChatSessionStorage).ActiveChatSession(ctx, chatId)
+	}
+	games, err := g.sessions.ListSessions(ctx, SessionFilter{
+		States: activeGameStates(),
+		ChatId: chatId,
+		Limit:  1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, nil
+	}
+	return games[0], nil
+}
+
+// storeSessionAndResetVotes updates session and clears votes of the finished round,
+// in a single transaction if session and vote storages are the same transactional storage
+func (g *simpleGameService) storeSessionAndResetVotes(ctx context.Context, game *GameInstance) error {
+	if txStorage, ok := g.sessions.(TransactionalStorage); ok && interface{}(unwrapStorage(g.sessions)) == interface{}(unwrapVoteStorage(g.votes)) {
+		return txStorage.StoreSessionAndResetVotes(ctx, game)
+	}
+
+	gameId, err := apiIDToUUID(game.GetGameId())
+	if err != nil {
+		return err
+	}
+	if err = g.votes.ResetVotes(ctx, gameId); err != nil {
+		return err
+	}
+	return g.sessions.StoreSession(ctx, game)
+}
+
+// storeNewSession stores just created session, claiming its chat atomically if storage supports it
+func (g *simpleGameService) storeNewSession(ctx context.Context, game *GameInstance) error {
+	//Other decorators may implement ChatSessionStorage, while their backend doesn't
+	if _, ok := unwrapStorage(g.sessions).(ChatSessionStorage); !ok || game.GameSession.ChatId == 0 {
+		return g.storeSessionAndResetVotes(ctx, game)
+	}
+
+	gameId, err := apiIDToUUID(game.GetGameId())
+	if err != nil {
+		return err
+	}
+	if err = g.votes.ResetVotes(ctx, gameId); err != nil {
+		return err
+	}
+	return g.sessions.(ChatSessionStorage).CreateChatSession(ctx, game)
+}
+
+// setGameOutcome moves game to one of *_TEAM_WON states, explaining it in session locale
+func setGameOutcome(game *GameInstance, state api.GameSession_GameState, outcome *api.EndgameOutcome) {
+	outcome.MissionsPassed = game.MissionsPassed
+	outcome.MissionsFailed = game.MissionsFailed
+	game.State = state
+	game.EndgameOutcome = outcome
+	game.EndgameReason = endgameReasonText(game.GameSession.Locale, outcome)
+}
+
+// gameFinished must be called once game reached one of *_TEAM_WON states
+func (g *simpleGameService) gameFinished(ctx context.Context, game *GameInstance) {
+	observeGameFinished(game)
+	loggerFromContext(ctx, g.logger).Info("game finished",
+		apiGameIdField(game.GameId),
+		stateField(game.State),
+		zap.Stringer("endgame_reason", game.EndgameOutcome.GetReason()),
+	)
+}
+
+// sessionReadError reports missing sessions with NotFound code
+func sessionReadError(err error) error {
+	if err == ErrSessionNotFound {
+		return status.Error(codes.NotFound, "failed to read session data: "+err.Error())
+	}
+	return errors.New("failed to read session data: " + err.Error())
+}
+
+// apiIDToUUID parses game id sent by client, missing and malformed ids are reported with InvalidArgument code
+func apiIDToUUID(id *api.UUID) (uuid.UUID, error) {
+	if id == nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "session id is not specified")
+	}
+	gameId, err := uuid.Parse(id.Value)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid session id %q", id.Value)
+	}
+	return gameId, nil
+}
+
+// readSession reads session by id sent by client, returning parsed id along with it
+func (g *simpleGameService) readSession(ctx context.Context, id *api.UUID) (*GameInstance, uuid.UUID, error) {
+	gameId, err := apiIDToUUID(id)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	game, err := g.sessions.GetSession(ctx, gameId)
+	if err != nil {
+		return nil, uuid.Nil, sessionReadError(err)
+	}
+	return game, gameId, nil
 }
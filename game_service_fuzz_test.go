@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap"
+)
+
+const (
+	fuzzMaxSteps = 400
+	fuzzEndSteps = 20 //Random calls made after game ended to check it stays ended
+)
+
+// fuzzGame makes random calls to GameService on behalf of players of a single game.
+// Most calls are what current state expects, so games make progress, the rest are random and mostly invalid.
+type fuzzGame struct {
+	ctx      context.Context
+	service  *simpleGameService
+	sessions GameSessionStorage
+	votes    VoteStorage
+	rng      *rand.Rand
+
+	players   []*api.Player
+	config    *api.GameConfig
+	id        uuid.UUID
+	session   *api.GameSession
+	prev      *GameInstance             //Stored game before last call
+	finished  api.GameSession_GameState //Terminal state reported by any call, 0 while game goes on
+	rotations int                       //New proposal rounds that handed leadership over
+	offTeam   int                       //Mission votes sent by players not in mission team
+}
+
+func newFuzzGame(sessions GameSessionStorage, votes VoteStorage, chatId, seed int64, players int) (*fuzzGame, error) {
+	f := &fuzzGame{
+		ctx:      context.Background(),
+		sessions: sessions,
+		votes:    votes,
+		rng:      rand.New(rand.NewSource(seed)),
+	}
+	f.service = NewGameService(sessions, votes, zap.NewNop())
+	f.service.newSeed = func() (int64, error) { return f.rng.Int63(), nil }
+
+	for id := 1; id <= players; id++ {
+		f.players = append(f.players, &api.Player{Id: uint64(id)})
+	}
+	f.config = dealRoles(chatId, f.players, f.rng)
+	var err error
+	if f.session, err = f.service.CreateSession(f.ctx, f.config); err != nil {
+		return nil, err
+	}
+	f.id = testGameId(f.session.GameId)
+	return f, f.checkInvariants()
+}
+
+func (f *fuzzGame) randomPlayer() *api.Player {
+	return f.players[f.rng.Intn(len(f.players))]
+}
+
+func (f *fuzzGame) randomVote() api.VoteContext_VoteOption {
+	switch f.rng.Intn(5) {
+	case 0, 1:
+		return api.VoteContext_NEGATIVE
+	case 2:
+		return api.VoteContext_ABSTAIN
+	}
+	return api.VoteContext_POSITIVE
+}
+
+// randomTeam has the right size for pending mission most of the time
+func (f *fuzzGame) randomTeam() (*api.MissionTeam, error) {
+	rules, err := f.prev.RuleSet()
+	if err != nil {
+		return nil, violation(invariantStorageReadable, "%v", err)
+	}
+	size := rules.TeamSize(len(f.players), f.prev.Mission.MissionNumber)
+	if size == 0 || f.rng.Intn(5) == 0 {
+		size = f.rng.Intn(len(f.players) + 1)
+	}
+	team := &api.MissionTeam{}
+	for _, n := range f.rng.Perm(len(f.players))[:size] {
+		team.Members = append(team.Members, f.players[n])
+	}
+	return team, nil
+}
+
+// step makes a single call to the service and checks invariants after it
+func (f *fuzzGame) step() error {
+	action := f.rng.Intn(7)
+	if f.rng.Intn(10) < 7 {
+		action = f.expectedAction()
+	}
+
+	var returned *api.GameSession
+	switch action {
+	case 0:
+		returned, _ = f.service.PushGameState(f.ctx, f.session)
+	case 1:
+		team, err := f.randomTeam()
+		if err != nil {
+			return err
+		}
+		_, _ = f.service.AssignMissionTeam(f.ctx, &api.AssignTeamContext{Session: f.session, Team: team})
+	case 2:
+		_, _ = f.service.VoteForMissionTeam(f.ctx, &api.VoteContext{Session: f.session, Voter: f.randomPlayer(), Vote: f.randomVote()})
+	case 3:
+		voter := f.randomPlayer()
+		if team := f.prev.MissionTeam.Members; len(team) > 0 && f.rng.Intn(5) > 0 {
+			voter = team[f.rng.Intn(len(team))]
+		}
+		_, err := f.service.VoteForMissionSuccess(f.ctx, &api.VoteContext{Session: f.session, Voter: voter, Vote: f.randomVote()})
+		if findPlayer(f.prev.MissionTeam.Members, voter.Id) == nil {
+			f.offTeam++
+			if err == nil {
+				return violation(invariantMissionVoters, "vote of player %d accepted, team is %v", voter.Id, f.prev.MissionTeam.Members)
+			}
+		}
+	case 4:
+		if outcome, err := f.service.AssassinateAllegedMerlin(f.ctx, &api.AssassinationContext{Session: f.session, Target: f.randomPlayer()}); err == nil {
+			returned = outcome.Session
+		}
+	case 5:
+		_, err := f.service.CreateSession(f.ctx, f.config)
+		if !errors.Is(err, ErrChatAlreadyHasGame) && !isGameOver(f.prev.State) {
+			return violation(invariantSingleChatGame, "second game in chat with %v game: %v", f.prev.State, err)
+		}
+	case 6:
+		_, _ = f.service.GetPendingMission(f.ctx, f.session)
+		_, _ = f.service.GetMissionTeam(f.ctx, f.session)
+	}
+	if returned != nil && isGameOver(returned.State) && f.finished == 0 {
+		f.finished = returned.State
+	}
+	return f.checkInvariants()
+}
+
+// expectedAction picks a call that current state is waiting for
+func (f *fuzzGame) expectedAction() int {
+	switch f.prev.State {
+	case api.GameSession_MISSION_TEAM_PICKING:
+		if len(f.prev.MissionTeam.Members) == 0 || f.rng.Intn(3) == 0 {
+			return 1
+		}
+	case api.GameSession_MISSION_TEAM_VOTING:
+		if f.rng.Intn(len(f.players)+1) > 0 {
+			return 2
+		}
+	case api.GameSession_MISSION_SUCCESS_VOTING:
+		if f.rng.Intn(len(f.prev.MissionTeam.Members)+1) > 0 {
+			return 3
+		}
+	case api.GameSession_POST_MISSIONS_ACTIONS:
+		if f.prev.MissionsPassed >= f.prev.MissionsFailed {
+			return 4
+		}
+	}
+	return 0
+}
+
+func (f *fuzzGame) checkInvariants() error {
+	game, err := f.sessions.GetSession(f.ctx, f.id)
+	if err != nil {
+		return violation(invariantStorageReadable, "%v", err)
+	}
+	//Memory storage returns the stored instance itself, so keep a copy to compare with
+	prev, snapshot := f.prev, *game
+	f.prev = &snapshot
+	if prev == nil {
+		return nil
+	}
+
+	if err := checkSessionInvariants(&game.GameSession, game.AllPlayers); err != nil {
+		return err
+	}
+	if isGameOver(game.State) && f.finished == 0 {
+		f.finished = game.State
+	}
+	if f.finished != 0 && game.State != f.finished {
+		return violation(invariantStickyEnd, "game reported %v, stored state is %v", f.finished, game.State)
+	}
+
+	//Leader only changes to the next player and always changes once a new proposal round starts,
+	//after team is rejected or after a mission
+	newRound := game.State == api.GameSession_MISSION_TEAM_PICKING &&
+		(prev.State == api.GameSession_MISSION_TEAM_VOTING || prev.State == api.GameSession_MISSION_ENDED)
+	if newRound {
+		f.rotations++
+	}
+	if newRound || game.Leader.GetId() != prev.Leader.GetId() {
+		next := (prev.CurrentLeaderIndex + 1) % len(game.AllPlayers)
+		if game.CurrentLeaderIndex != next || game.Leader.GetId() != game.AllPlayers[next].GetId() {
+			return violation(invariantLeaderRotation, "leader %d at %d replaced %d at %d",
+				game.Leader.GetId(), game.CurrentLeaderIndex, prev.Leader.GetId(), prev.CurrentLeaderIndex)
+		}
+	}
+
+	if game.State == api.GameSession_MISSION_SUCCESS_VOTING {
+		voted, err := f.votes.NumberOfPlayersVotedForMission(f.ctx, f.id)
+		if err != nil {
+			return err
+		}
+		if voted > len(game.MissionTeam.Members) {
+			return violation(invariantMissionVoters, "%d mission votes for team of %d", voted, len(game.MissionTeam.Members))
+		}
+	}
+
+	if prev.State == api.GameSession_MISSION_TEAM_VOTING && game.State != prev.State {
+		if err := checkTeamMajority(game); err != nil {
+			return err
+		}
+	}
+	if game.State != prev.State {
+		return f.checkVotesReset(prev.State)
+	}
+	return nil
+}
+
+// checkTeamMajority makes sure finished team vote counted every player and decided by strict majority
+func checkTeamMajority(game *GameInstance) error {
+	res := game.LastTeamVote
+	if res == nil {
+		return violation(invariantTeamMajority, "no result of team vote")
+	}
+	if total := res.Approvals + res.Rejections + res.Abstentions; int(total) != len(game.AllPlayers) {
+		return violation(invariantTeamMajority, "%d votes counted for %d players", total, len(game.AllPlayers))
+	}
+	majority := 2*int(res.Approvals) > len(game.AllPlayers)
+	approved := game.State == api.GameSession_MISSION_SUCCESS_VOTING
+	if res.Approved != majority || approved != majority {
+		return violation(invariantTeamMajority, "%d of %d approvals led to %v", res.Approvals, len(game.AllPlayers), game.State)
+	}
+	return nil
+}
+
+// checkVotesReset makes sure votes of a finished voting round don't count in the next one
+func (f *fuzzGame) checkVotesReset(finishedRound api.GameSession_GameState) error {
+	id := f.id
+	switch finishedRound {
+	case api.GameSession_MISSION_TEAM_VOTING:
+		votes, err := f.votes.GetTeamVotes(f.ctx, id)
+		if err != nil {
+			return err
+		}
+		if left := len(votes.Approvals) + len(votes.Rejections) + len(votes.Abstentions); left != 0 {
+			return violation(invariantFreshVotes, "%d team votes left after team vote", left)
+		}
+	case api.GameSession_MISSION_SUCCESS_VOTING:
+		voted, _ := f.votes.NumberOfPlayersVotedForMission(f.ctx, id)
+		successes, _ := f.votes.GetMissionVotesCountForGame(f.ctx, id)
+		if voted != 0 || successes != 0 {
+			return violation(invariantFreshVotes, "%d mission votes with %d successes left after mission", voted, successes)
+		}
+	}
+	return nil
+}
+
+// play makes random calls until game ends and a bit after that
+func (f *fuzzGame) play() error {
+	endSteps := 0
+	for step := 0; step < fuzzMaxSteps && endSteps < fuzzEndSteps; step++ {
+		if err := f.step(); err != nil {
+			return fmt.Errorf("step %d: %w", step, err)
+		}
+		if f.finished != 0 {
+			endSteps++
+		}
+	}
+	return nil
+}
+
+// TestGameServiceFuzz plays random call sequences, run with -quickchecks=10000 for a thorough check.
+// Redis storage serializes sessions, so it catches changes that are returned but never stored.
+func TestGameServiceFuzz(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		logger := zap.NewNop()
+		fuzzGameService(t, NewMemoryStorage(time.Hour), NewVoteStorage(logger), 1)
+	})
+	t.Run("redis", func(t *testing.T) {
+		_, rdb := newTestRedis(t)
+		logger := zap.NewNop()
+		//Every call is a few round trips to miniredis, so fewer games are played
+		fuzzGameService(t, NewRedisSessionStorage(rdb, time.Hour, logger), NewRedisVoteStorage(rdb, time.Hour, logger), 0.2)
+	})
+}
+
+// fuzzGameService plays -quickchecks games scaled by gamesScale against service with given storages
+func fuzzGameService(t *testing.T, sessions GameSessionStorage, votes VoteStorage, gamesScale float64) {
+	games, finished, rotations, offTeam := int64(0), 0, 0, 0
+	property := func(seed int64, players uint8) bool {
+		//Every game gets its own chat, so games left unfinished don't block the next ones
+		games++
+		f, err := newFuzzGame(sessions, votes, games, seed, 5+int(players%6))
+		if err == nil {
+			err = f.play()
+		}
+		if f != nil {
+			if f.finished != 0 {
+				finished++
+			}
+			rotations += f.rotations
+			offTeam += f.offTeam
+		}
+		if err != nil {
+			t.Error(err)
+		}
+		return err == nil
+	}
+	if err := quick.Check(property, &quick.Config{MaxCountScale: gamesScale}); err != nil {
+		t.Error(err)
+	}
+
+	if finished == 0 {
+		t.Error("not a single random game finished")
+	}
+	if rotations == 0 {
+		t.Error("not a single proposal round started, leader rotation was never checked")
+	}
+	if offTeam == 0 {
+		t.Error("not a single mission vote came from outside mission team")
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFiveRejectedProposalsEndGame(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		logger := zaptest.NewLogger(t)
+		testFiveRejectedProposals(t, NewMemoryStorage(time.Minute), NewVoteStorage(logger))
+	})
+	t.Run("redis", func(t *testing.T) {
+		logger := zaptest.NewLogger(t)
+		_, rdb := newTestRedis(t)
+		//Redis storage serializes sessions, so changes that are not stored get lost
+		testFiveRejectedProposals(t, NewRedisSessionStorage(rdb, time.Minute, logger), NewRedisVoteStorage(rdb, time.Minute, logger))
+	})
+	t.Run("bolt", func(t *testing.T) {
+		stor := newTestBoltStorage(t)
+		testFiveRejectedProposals(t, stor, stor)
+	})
+	t.Run("mongo", func(t *testing.T) {
+		stor := newTestMongoStorage(t)
+		testFiveRejectedProposals(t, stor, NewMongoVoteStorage(stor, zaptest.NewLogger(t)))
+	})
+}
+
+// testFiveRejectedProposals rejects every proposed team until evil team wins
+func testFiveRejectedProposals(t *testing.T, sessions GameSessionStorage, votes VoteStorage) {
+	ctx := context.Background()
+	service := NewGameService(sessions, votes, zaptest.NewLogger(t))
+
+	config := testGameConfig(-437)
+	session, err := service.CreateSession(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session, err = service.PushGameState(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	game, err := sessions.GetSession(ctx, testGameId(session.GameId))
+	if err != nil {
+		t.Fatal(err)
+	}
+	players := game.AllPlayers
+
+	for rejection := uint32(1); rejection <= builtinRules.MaxRejectedProposals; rejection++ {
+		leaderIndex := game.CurrentLeaderIndex
+		team := &api.MissionTeam{Members: players[:2]}
+		if _, err = service.AssignMissionTeam(ctx, &api.AssignTeamContext{Session: session, Team: team}); err != nil {
+			t.Fatal(err)
+		}
+		if session, err = service.PushGameState(ctx, session); err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range players {
+			if _, err = service.VoteForMissionTeam(ctx, &api.VoteContext{Session: session, Voter: p, Vote: api.VoteContext_NEGATIVE}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if session, err = service.PushGameState(ctx, session); err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err := sessions.GetSession(ctx, testGameId(session.GameId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		game = stored
+		teamVotes, err := votes.GetTeamVotes(ctx, testGameId(session.GameId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if left := len(teamVotes.Approvals) + len(teamVotes.Rejections) + len(teamVotes.Abstentions); left != 0 {
+			t.Errorf("rejection %d: %d votes left for the next proposal", rejection, left)
+		}
+		if len(stored.MissionTeam.Members) != 0 {
+			t.Errorf("rejection %d: rejected team %v was kept", rejection, stored.MissionTeam.Members)
+		}
+		if rejection < builtinRules.MaxRejectedProposals {
+			next := (leaderIndex + 1) % len(players)
+			if stored.State != api.GameSession_MISSION_TEAM_PICKING || stored.Mission.TeamPickingAttempts != rejection {
+				t.Fatalf("rejection %d: state %v, attempts %d", rejection, stored.State, stored.Mission.TeamPickingAttempts)
+			}
+			if stored.CurrentLeaderIndex != next || stored.Leader.Id != players[next].Id {
+				t.Errorf("rejection %d: leader %v at %d; want %v at %d", rejection, stored.Leader, stored.CurrentLeaderIndex, players[next], next)
+			}
+			continue
+		}
+
+		if stored.State != api.GameSession_EVIL_TEAM_WON || session.State != api.GameSession_EVIL_TEAM_WON {
+			t.Fatalf("game after %d rejections: stored %v, returned %v", rejection, stored.State, session.State)
+		}
+		if outcome := stored.EndgameOutcome; outcome.GetReason() != api.EndgameOutcome_TEAM_PROPOSALS_REJECTED || outcome.RejectedProposals != builtinRules.MaxRejectedProposals {
+			t.Errorf("endgame outcome = %+v", outcome)
+		}
+	}
+
+	if _, err = service.PushGameState(ctx, session); err == nil {
+		t.Error("finished game was pushed further")
+	}
+	if _, err = service.GetActiveSessionForChat(ctx, &api.Chat{Id: config.ChatId}); !errors.Is(err, ErrNoActiveGameInChat) {
+		t.Errorf("finished game is still active in chat: %v", err)
+	}
+}
+
+func TestHouseRules(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	sessions := NewMemoryStorage(time.Minute)
+	service := NewGameService(sessions, NewVoteStorage(logger), logger)
+
+	config := testGameConfig(0)
+	config.Rules = &api.RuleSet{
+		MaxRejectedProposals: 3,
+		PlayerCounts: []*api.PlayerCountRules{
+			{Players: 5, EvilPlayers: 2, TeamSizes: []uint32{3, 3, 3, 3, 3}, FailsRequired: []uint32{2, 1, 1, 1, 1}},
+		},
+	}
+	session, err := service.CreateSession(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session, err = service.PushGameState(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	mission, err := service.GetPendingMission(ctx, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mission.TeamSize != 3 || mission.FailsRequired != 2 || mission.MaxTeamPickingAttempts != 3 {
+		t.Errorf("pending mission %+v does not follow house rules", mission)
+	}
+
+	players := append(config.EvilTeam.Members, config.GoodTeam.Members...)
+	if _, err = service.AssignMissionTeam(ctx, &api.AssignTeamContext{Session: session, Team: &api.MissionTeam{Members: players[:2]}}); err == nil {
+		t.Error("team of built-in size was accepted")
+	}
+	stranger := &api.Player{Id: 1 << 40, UserName: "stranger"}
+	for problem, members := range map[string][]*api.Player{
+		"not in the game": {players[0], players[1], stranger},
+		"more than once":  {players[0], players[1], players[0]},
+	} {
+		_, err = service.AssignMissionTeam(ctx, &api.AssignTeamContext{Session: session, Team: &api.MissionTeam{Members: members}})
+		if err == nil || !strings.Contains(err.Error(), problem) {
+			t.Errorf("error %v does not mention %q", err, problem)
+		}
+	}
+	//Both evil players are on the team, but only one of them fails the mission
+	team := players[:3]
+	renamed := &api.Player{Id: team[2].Id, UserName: "renamed"}
+	if _, err = service.AssignMissionTeam(ctx, &api.AssignTeamContext{Session: session, Team: &api.MissionTeam{Members: []*api.Player{team[0], team[1], renamed}}}); err != nil {
+		t.Fatal(err)
+	}
+	if assigned, err := service.GetMissionTeam(ctx, session); err != nil || !reflect.DeepEqual(assigned.Members, team) {
+		t.Errorf("team %v was not assigned with seated players %v: %v", assigned, team, err)
+	}
+	if session, err = service.PushGameState(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range players {
+		if _, err = service.VoteForMissionTeam(ctx, &api.VoteContext{Session: session, Voter: p, Vote: api.VoteContext_POSITIVE}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if session, err = service.PushGameState(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	for n, p := range team {
+		vote := api.VoteContext_POSITIVE
+		if n == 0 {
+			vote = api.VoteContext_NEGATIVE
+		}
+		if _, err = service.VoteForMissionSuccess(ctx, &api.VoteContext{Session: session, Voter: p, Vote: vote}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if session, err = service.PushGameState(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	if session.LastMissionResult.GetFailed() || session.MissionsPassed != 1 {
+		t.Errorf("mission with a single fail vote out of 2 required failed: %+v", session.LastMissionResult)
+	}
+
+	config.Rules.PlayerCounts[0].FailsRequired[0] = 4
+	if _, err = service.CreateSession(ctx, config); err == nil {
+		t.Error("session with impossible rules was created")
+	}
+
+	//Damaged rules of stored session are reported instead of being replaced with built-in ones
+	game, err := sessions.GetSession(ctx, testGameId(session.GameId))
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.GameConfig.Rules.PlayerCounts[0].FailsRequired[0] = 4
+	if err = sessions.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = service.GetPendingMission(ctx, session); err == nil || !strings.Contains(err.Error(), "invalid rules") {
+		t.Errorf("error %v does not mention invalid rules", err)
+	}
+}
+
+func TestListSessionsPages(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	sessions := NewMemoryStorage(time.Minute)
+	service := NewGameService(sessions, NewVoteStorage(logger), logger)
+
+	start := time.Now().Add(-time.Hour)
+	var games []*GameInstance
+	for n := 0; n < defaultSessionsListLimit+2; n++ {
+		game := newTestGame(0, start.Add(time.Duration(n)*time.Second), 1, 2)
+		if n%2 == 1 {
+			game.State = api.GameSession_EVIL_TEAM_WON
+		}
+		if err := sessions.StoreSession(ctx, game); err != nil {
+			t.Fatal(err)
+		}
+		games = append(games, game)
+	}
+
+	resp, err := service.ListSessions(ctx, &api.ListSessionsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Sessions) != defaultSessionsListLimit || !resp.HasMore {
+		t.Errorf("default page has %d sessions, has_more %v; want %d and more", len(resp.Sessions), resp.HasMore, defaultSessionsListLimit)
+	}
+
+	createdAfter, _ := types.TimestampProto(games[1].CreatedAt)
+	resp, err = service.ListSessions(ctx, &api.ListSessionsRequest{
+		States:       []api.GameSession_GameState{api.GameSession_EVIL_TEAM_WON},
+		CreatedAfter: createdAfter,
+		Offset:       1,
+		Limit:        2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Sessions) != 2 || !resp.HasMore || resp.Sessions[0].GameId.Value != games[5].GameId.Value || resp.Sessions[1].GameId.Value != games[7].GameId.Value {
+		t.Errorf("filtered page = %v, has_more %v; want sessions #5 and #7 and more", resp.Sessions, resp.HasMore)
+	}
+
+	resp, err = service.ListSessions(ctx, &api.ListSessionsRequest{Offset: uint32(len(games) - 1), Limit: 5})
+	if err != nil || len(resp.Sessions) != 1 || resp.HasMore {
+		t.Errorf("last page = %v, has_more %v, %v; want single session and no more", resp.GetSessions(), resp.GetHasMore(), err)
+	}
+
+	if _, err = service.ListSessions(ctx, &api.ListSessionsRequest{CreatedAfter: &types.Timestamp{Nanos: -1}}); err == nil {
+		t.Error("invalid created_after was accepted")
+	}
+}
+
+func TestSingleChatGameAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	_, rdb := newTestRedis(t)
+	//Every replica has its own service and lock, sharing only redis
+	var replicas []*simpleGameService
+	for n := 0; n < 4; n++ {
+		replicas = append(replicas, NewGameService(NewRedisSessionStorage(rdb, time.Minute, logger), NewRedisVoteStorage(rdb, time.Minute, logger), logger))
+	}
+
+	config := testGameConfig(-437)
+	created := make(chan *api.GameSession, len(replicas))
+	errs := make(chan error, len(replicas))
+	for _, replica := range replicas {
+		go func(replica *simpleGameService) {
+			session, err := replica.CreateSession(ctx, config)
+			if err != nil {
+				errs <- err
+				return
+			}
+			created <- session
+		}(replica)
+	}
+	var session *api.GameSession
+	for range replicas {
+		select {
+		case s := <-created:
+			if session != nil {
+				t.Fatalf("second game %s was created in chat with game %s", s.GameId.Value, session.GameId.Value)
+			}
+			session = s
+		case err := <-errs:
+			if !errors.Is(err, ErrChatAlreadyHasGame) {
+				t.Errorf("CreateSession = %v; want %v", err, ErrChatAlreadyHasGame)
+			}
+		}
+	}
+	if session == nil {
+		t.Fatal("not a single game was created")
+	}
+	if active, err := replicas[2].GetActiveSessionForChat(ctx, &api.Chat{Id: config.ChatId}); err != nil || active.GameId.Value != session.GameId.Value {
+		t.Errorf("active game of chat = %v, %v; want %s", active, err, session.GameId.Value)
+	}
+
+	//Finished game frees the chat
+	if _, err := replicas[0].TerminateSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replicas[2].GetActiveSessionForChat(ctx, &api.Chat{Id: config.ChatId}); !errors.Is(err, ErrNoActiveGameInChat) {
+		t.Errorf("active game of chat after it was closed = %v; want %v", err, ErrNoActiveGameInChat)
+	}
+	if _, err := replicas[1].CreateSession(ctx, config); err != nil {
+		t.Errorf("game in chat after previous one was closed: %v", err)
+	}
+}
+
+func TestMalformedRequests(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	service := NewGameService(NewMemoryStorage(time.Minute), NewVoteStorage(logger), logger)
+	session, err := service.CreateSession(ctx, testGameConfig(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	malformed := &api.GameSession{GameId: &api.UUID{Value: "not-a-uuid"}}
+	voter := &api.Player{Id: 1}
+
+	//Requests come from browsers too, none of them may crash the server
+	for name, call := range map[string]func() error{
+		"GetSession":           func() error { _, err := service.GetSession(ctx, nil); return err },
+		"GetSession malformed": func() error { _, err := service.GetSession(ctx, malformed.GameId); return err },
+		"TerminateSession":     func() error { _, err := service.TerminateSession(ctx, &api.GameSession{}); return err },
+		"TerminateSession nil": func() error { _, err := service.TerminateSession(ctx, nil); return err },
+		"GetEvilTeam":          func() error { _, err := service.GetEvilTeam(ctx, malformed); return err },
+		"GetVirtuousTeam":      func() error { _, err := service.GetVirtuousTeam(ctx, nil); return err },
+		"PushGameState":        func() error { _, err := service.PushGameState(ctx, malformed); return err },
+		"GetPendingMission":    func() error { _, err := service.GetPendingMission(ctx, nil); return err },
+		"AssignMissionTeam":    func() error { _, err := service.AssignMissionTeam(ctx, &api.AssignTeamContext{}); return err },
+		"GetMissionTeam":       func() error { _, err := service.GetMissionTeam(ctx, malformed); return err },
+		"VoteForMissionTeam":   func() error { _, err := service.VoteForMissionTeam(ctx, &api.VoteContext{Voter: voter}); return err },
+		"VoteForMissionTeam nil": func() error {
+			_, err := service.VoteForMissionTeam(ctx, &api.VoteContext{Session: session})
+			return err
+		},
+		"VoteForMissionSuccess": func() error {
+			_, err := service.VoteForMissionSuccess(ctx, &api.VoteContext{Session: malformed, Voter: voter})
+			return err
+		},
+		"VoteForMissionSuccess nil": func() error {
+			_, err := service.VoteForMissionSuccess(ctx, &api.VoteContext{Session: session})
+			return err
+		},
+		"AssassinateAllegedMerlin": func() error {
+			_, err := service.AssassinateAllegedMerlin(ctx, &api.AssassinationContext{Target: voter})
+			return err
+		},
+		"AssassinateAllegedMerlin nil": func() error {
+			_, err := service.AssassinateAllegedMerlin(ctx, &api.AssassinationContext{Session: session})
+			return err
+		},
+	} {
+		if code := status.Code(call()); code != codes.InvalidArgument {
+			t.Errorf("%s returned %v; want %v", name, code, codes.InvalidArgument)
+		}
+	}
+}
@@ -1,30 +1,190 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/google/uuid"
 	"github.com/justmax437/avalonBacker/api"
+	"math/rand"
+	"sort"
+	"time"
 )
 
 type GameInstance struct {
-	api.GameSession
-	api.GameConfig
-	MissionTeam api.MissionTeam
-	Mission     api.PendingMission
+	api.GameSession `bson:",inline"`
+	api.GameConfig  `json:"config" bson:"config"`
+	MissionTeam     api.MissionTeam
+	Mission         api.PendingMission
 
 	//Next two are set during game creation
 	CurrentLeaderIndex int `json:"current_leader_index" bson:"current_leader_index"`
 	//AllPlayers are shuffled sum of Good and Evil teams
 	AllPlayers []*api.Player `json:"all_players" bson:"all_players"`
+	CreatedAt  time.Time     `json:"created_at" bson:"created_at"`
+	//Seed of every random decision in game, so the game could be reproduced
+	Seed int64      `json:"seed" bson:"seed"`
+	rng  *rand.Rand //Created from Seed on first use
 }
 
 func (gi *GameInstance) TotalPlayersCount() int {
 	return len(gi.AllPlayers)
 }
 
+// RuleSet returns rules the game is played by, sessions stored before rules were configurable get built-in ones.
+// Rules are validated on session creation, so error means stored session was damaged.
+func (gi *GameInstance) RuleSet() (*RuleSet, error) {
+	rules, err := newRuleSet(gi.GameConfig.Rules, gi.GameConfig.Extensions)
+	if err != nil {
+		return nil, errors.New("invalid rules of session: " + err.Error())
+	}
+	return rules, nil
+}
+
+// Rand returns random numbers generator of the game, seeded with game seed on first use.
+// Every random decision draws from the same generator, so same sequence of decisions gives the same game.
+func (gi *GameInstance) Rand() *rand.Rand {
+	if gi.rng == nil {
+		gi.rng = rand.New(rand.NewSource(gi.Seed))
+	}
+	return gi.rng
+}
+
+// PassLeadership hands leadership to the next player in AllPlayers order, every new team proposal has a new leader
+func (gi *GameInstance) PassLeadership() {
+	gi.CurrentLeaderIndex = (gi.CurrentLeaderIndex + 1) % gi.TotalPlayersCount()
+	gi.Leader = gi.AllPlayers[gi.CurrentLeaderIndex]
+}
+
+func (gi *GameInstance) HasPlayer(playerId uint64) bool {
+	for _, p := range gi.AllPlayers {
+		if p.GetId() == playerId {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionFilter narrows down ListSessions results, zero values are ignored
+type SessionFilter struct {
+	States       []api.GameSession_GameState //Match sessions in any of these states
+	PlayerId     uint64
+	ChatId       int64
+	CreatedAfter time.Time
+	Offset       uint
+	Limit        uint
+}
+
+// Match checks everything except pagination
+func (f *SessionFilter) Match(gi *GameInstance) bool {
+	if len(f.States) > 0 {
+		stateMatched := false
+		for _, s := range f.States {
+			if gi.State == s {
+				stateMatched = true
+				break
+			}
+		}
+		if !stateMatched {
+			return false
+		}
+	}
+
+	if f.PlayerId != 0 && !gi.HasPlayer(f.PlayerId) {
+		return false
+	}
+
+	if f.ChatId != 0 && gi.GameSession.ChatId != f.ChatId {
+		return false
+	}
+
+	return f.CreatedAfter.IsZero() || gi.CreatedAt.After(f.CreatedAfter)
+}
+
+// sortSessionsByCreation orders sessions the way ListSessions should return them
+func sortSessionsByCreation(games []*GameInstance) {
+	sort.Slice(games, func(a, b int) bool {
+		if games[a].CreatedAt.Equal(games[b].CreatedAt) {
+			return games[a].GameId.GetValue() < games[b].GameId.GetValue()
+		}
+		return games[a].CreatedAt.Before(games[b].CreatedAt)
+	})
+}
+
+// Paginate applies Offset and Limit to already filtered and sorted sessions
+func (f *SessionFilter) Paginate(games []*GameInstance) []*GameInstance {
+	if f.Offset >= uint(len(games)) {
+		return []*GameInstance{}
+	}
+	games = games[f.Offset:]
+	if f.Limit > 0 && f.Limit < uint(len(games)) {
+		games = games[:f.Limit]
+	}
+	return games
+}
+
 type GameSessionStorage interface {
-	StoreSession(instance *GameInstance) error
-	GetSession(id uuid.UUID) (*GameInstance, error)
-	CloseSession(id uuid.UUID) error
-	CheckExistence(id uuid.UUID) (bool, error)
-	NumberOfGames() (uint, error)
+	StoreSession(ctx context.Context, instance *GameInstance) error
+	GetSession(ctx context.Context, id uuid.UUID) (*GameInstance, error)
+	CloseSession(ctx context.Context, id uuid.UUID) error
+	CheckExistence(ctx context.Context, id uuid.UUID) (bool, error)
+	NumberOfGames(ctx context.Context) (uint, error)
+	//ListSessions returns sessions matching filter, sorted by creation time
+	ListSessions(ctx context.Context, filter SessionFilter) ([]*GameInstance, error)
+	FindSessionsByPlayer(ctx context.Context, playerId uint64) ([]*GameInstance, error)
+}
+
+// TransactionalStorage is implemented by storages keeping both sessions and votes,
+// so session and votes could be updated at once
+type TransactionalStorage interface {
+	StoreSessionAndResetVotes(ctx context.Context, instance *GameInstance) error
+}
+
+// ChatSessionStorage is implemented by storages shared between backend replicas,
+// so concurrent CreateSession calls on different replicas can't start two games in one chat
+type ChatSessionStorage interface {
+	//CreateChatSession stores a new session bound to a chat,
+	//ErrChatAlreadyHasGame is returned if unfinished session of that chat is already stored
+	CreateChatSession(ctx context.Context, instance *GameInstance) error
+	//ActiveChatSession returns unfinished session of a chat without scanning every stored session,
+	//nil is returned if chat has no such session
+	ActiveChatSession(ctx context.Context, chatId int64) (*GameInstance, error)
+}
+
+// unwrapStorage returns the innermost storage behind decorators like cachedSessionStorage
+func unwrapStorage(s GameSessionStorage) GameSessionStorage {
+	for {
+		wrapper, ok := s.(interface{ Unwrap() GameSessionStorage })
+		if !ok {
+			return s
+		}
+		s = wrapper.Unwrap()
+	}
+}
+
+// StoragePinger is implemented by storages backed by external database,
+// so health checks could tell whether it is reachable
+type StoragePinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SessionChange describes a single update of stored session
+type SessionChange struct {
+	GameId uuid.UUID `json:"game_id"`
+	Closed bool      `json:"closed"` //true if session was removed from storage
+	Origin string    `json:"origin"` //processId of the replica that made the change
+}
+
+// SessionChangeNotifier is implemented by storages that can report changes made by any backend replica.
+// Returned channel is closed when ctx is done.
+type SessionChangeNotifier interface {
+	WatchSessions(ctx context.Context) (<-chan SessionChange, error)
+}
+
+// LeaseStorage is implemented by storages shared between backend replicas,
+// so work that must be done by a single replica at a time could be handed to one of them
+type LeaseStorage interface {
+	//AcquireLease takes or renews lease for ttl, false is returned if it is held by someone else
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	//ReleaseLease lets others take lease right away, lease of another holder is left untouched
+	ReleaseLease(ctx context.Context, name, holder string) error
 }
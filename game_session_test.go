@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/justmax437/avalonBacker/api"
+)
+
+func TestSessionFilter(t *testing.T) {
+	start := time.Now()
+	game := newTestGame(-1, start, 1, 2, 3)
+	game.State = api.GameSession_MISSION_TEAM_VOTING
+
+	cases := []struct {
+		name   string
+		filter SessionFilter
+		match  bool
+	}{
+		{"empty", SessionFilter{}, true},
+		{"any of states", SessionFilter{States: []api.GameSession_GameState{api.GameSession_GAME_CREATED, api.GameSession_MISSION_TEAM_VOTING}}, true},
+		{"other state", SessionFilter{States: []api.GameSession_GameState{api.GameSession_GAME_CREATED}}, false},
+		{"player", SessionFilter{PlayerId: 2}, true},
+		{"other player", SessionFilter{PlayerId: 4}, false},
+		{"chat", SessionFilter{ChatId: -1}, true},
+		{"other chat", SessionFilter{ChatId: -2}, false},
+		{"created after", SessionFilter{CreatedAfter: start.Add(-time.Second)}, true},
+		{"created before", SessionFilter{CreatedAfter: start}, false},
+		{"pagination is ignored", SessionFilter{Offset: 10, Limit: 1}, true},
+	}
+	for _, c := range cases {
+		if got := c.filter.Match(game); got != c.match {
+			t.Errorf("%s: Match = %v; want %v", c.name, got, c.match)
+		}
+	}
+}
+
+func TestSessionFilterPaginate(t *testing.T) {
+	games := make([]*GameInstance, 5)
+	for n := range games {
+		games[n] = newTestGame(0, time.Now())
+	}
+	cases := []struct {
+		offset, limit uint
+		want          []*GameInstance
+	}{
+		{0, 0, games},
+		{1, 2, games[1:3]},
+		{3, 10, games[3:]},
+		{5, 1, nil},
+	}
+	for _, c := range cases {
+		filter := SessionFilter{Offset: c.offset, Limit: c.limit}
+		got := filter.Paginate(games)
+		if len(got) != len(c.want) || (len(got) > 0 && got[0] != c.want[0]) {
+			t.Errorf("offset %d, limit %d: got %d sessions; want %d", c.offset, c.limit, len(got), len(c.want))
+		}
+	}
+}
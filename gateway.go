@@ -0,0 +1,449 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Limit for JSON request bodies, the largest request is GameConfig with a dozen players
+const gatewayMaxBodySize = 1 << 20
+
+// Headers passed to interceptors as gRPC metadata, so REST calls are authenticated and traced the same way
+var gatewayForwardedHeaders = []string{"authorization", "traceparent", "tracestate", "baggage"}
+
+// restGateway maps HTTP/JSON routes to GameService calls, running them through the same interceptors as gRPC server.
+// Messages are encoded with encoding/json, using json tags of generated proto structs.
+type restGateway struct {
+	service      api.GameServiceServer
+	interceptors []grpc.UnaryServerInterceptor
+	routes       []gatewayRoute
+	logger       *zap.Logger
+}
+
+// gatewayRoute binds HTTP method and path to a single RPC.
+// Path segments in braces are variables, last segment could end with a custom verb like {id}:push.
+type gatewayRoute struct {
+	method  string
+	pattern string
+	rpc     string //GameService method name
+	//request builds RPC request from path variables, query and body
+	request func(r *http.Request, vars map[string]string) (interface{}, error)
+	call    func(ctx context.Context, req interface{}) (interface{}, error)
+}
+
+func NewRESTGateway(service api.GameServiceServer, interceptors []grpc.UnaryServerInterceptor, logger *zap.Logger) *restGateway {
+	g := &restGateway{service: service, interceptors: interceptors, logger: logger}
+	g.routes = g.gameServiceRoutes()
+	return g
+}
+
+func (g *restGateway) gameServiceRoutes() []gatewayRoute {
+	s := g.service
+	return []gatewayRoute{
+		{
+			method: http.MethodPost, pattern: "/v1/sessions", rpc: "CreateSession",
+			request: func(r *http.Request, _ map[string]string) (interface{}, error) {
+				config := new(api.GameConfig)
+				return config, decodeGatewayBody(r, config)
+			},
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.CreateSession(ctx, req.(*api.GameConfig))
+			},
+		},
+		{
+			method: http.MethodGet, pattern: "/v1/sessions", rpc: "ListSessions",
+			request: func(r *http.Request, _ map[string]string) (interface{}, error) {
+				return listSessionsRequestFromQuery(r)
+			},
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.ListSessions(ctx, req.(*api.ListSessionsRequest))
+			},
+		},
+		{
+			method: http.MethodGet, pattern: "/v1/sessions/{id}", rpc: "GetSession",
+			request: func(_ *http.Request, vars map[string]string) (interface{}, error) {
+				return gameIdFromPath(vars)
+			},
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.GetSession(ctx, req.(*api.UUID))
+			},
+		},
+		{
+			method: http.MethodDelete, pattern: "/v1/sessions/{id}", rpc: "TerminateSession",
+			request: sessionFromPath,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.TerminateSession(ctx, req.(*api.GameSession))
+			},
+		},
+		{
+			method: http.MethodPost, pattern: "/v1/sessions/{id}:push", rpc: "PushGameState",
+			request: sessionFromPath,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.PushGameState(ctx, req.(*api.GameSession))
+			},
+		},
+		{
+			method: http.MethodGet, pattern: "/v1/sessions/{id}/evil-team", rpc: "GetEvilTeam",
+			request: sessionFromPath,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.GetEvilTeam(ctx, req.(*api.GameSession))
+			},
+		},
+		{
+			method: http.MethodGet, pattern: "/v1/sessions/{id}/virtuous-team", rpc: "GetVirtuousTeam",
+			request: sessionFromPath,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.GetVirtuousTeam(ctx, req.(*api.GameSession))
+			},
+		},
+		{
+			method: http.MethodGet, pattern: "/v1/sessions/{id}/mission", rpc: "GetPendingMission",
+			request: sessionFromPath,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.GetPendingMission(ctx, req.(*api.GameSession))
+			},
+		},
+		{
+			method: http.MethodGet, pattern: "/v1/sessions/{id}/mission-team", rpc: "GetMissionTeam",
+			request: sessionFromPath,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.GetMissionTeam(ctx, req.(*api.GameSession))
+			},
+		},
+		{
+			method: http.MethodPut, pattern: "/v1/sessions/{id}/mission-team", rpc: "AssignMissionTeam",
+			request: func(r *http.Request, vars map[string]string) (interface{}, error) {
+				team := new(api.MissionTeam)
+				if err := decodeGatewayBody(r, team); err != nil {
+					return nil, err
+				}
+				session, err := sessionWithIdFromPath(vars)
+				if err != nil {
+					return nil, err
+				}
+				return &api.AssignTeamContext{Session: session, Team: team}, nil
+			},
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.AssignMissionTeam(ctx, req.(*api.AssignTeamContext))
+			},
+		},
+		{
+			method: http.MethodPost, pattern: "/v1/sessions/{id}/team-votes", rpc: "VoteForMissionTeam",
+			request: voteFromPathAndBody,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.VoteForMissionTeam(ctx, req.(*api.VoteContext))
+			},
+		},
+		{
+			method: http.MethodPost, pattern: "/v1/sessions/{id}/mission-votes", rpc: "VoteForMissionSuccess",
+			request: voteFromPathAndBody,
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.VoteForMissionSuccess(ctx, req.(*api.VoteContext))
+			},
+		},
+		{
+			method: http.MethodPost, pattern: "/v1/sessions/{id}:assassinate", rpc: "AssassinateAllegedMerlin",
+			request: func(r *http.Request, vars map[string]string) (interface{}, error) {
+				assassination := new(api.AssassinationContext)
+				if err := decodeGatewayBody(r, assassination); err != nil {
+					return nil, err
+				}
+				session, err := sessionWithIdFromPath(vars)
+				if err != nil {
+					return nil, err
+				}
+				assassination.Session = session
+				return assassination, nil
+			},
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.AssassinateAllegedMerlin(ctx, req.(*api.AssassinationContext))
+			},
+		},
+		{
+			method: http.MethodGet, pattern: "/v1/chats/{chat_id}/active-session", rpc: "GetActiveSessionForChat",
+			request: func(_ *http.Request, vars map[string]string) (interface{}, error) {
+				chatId, err := strconv.ParseInt(vars["chat_id"], 10, 64)
+				if err != nil {
+					return nil, status.Errorf(codes.InvalidArgument, "invalid chat id %q", vars["chat_id"])
+				}
+				return &api.Chat{Id: chatId}, nil
+			},
+			call: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.GetActiveSessionForChat(ctx, req.(*api.Chat))
+			},
+		},
+	}
+}
+
+func (g *restGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, vars, pathMatched := g.findRoute(r.Method, r.URL.Path)
+	if route == nil {
+		if pathMatched {
+			writeGatewayError(w, http.StatusMethodNotAllowed, codes.Unimplemented, "method "+r.Method+" is not allowed")
+		} else {
+			writeGatewayError(w, http.StatusNotFound, codes.NotFound, "no route for "+r.URL.Path)
+		}
+		return
+	}
+
+	req, err := route.request(r, vars)
+	if err != nil {
+		g.writeStatus(w, err)
+		return
+	}
+
+	info := &grpc.UnaryServerInfo{Server: g.service, FullMethod: "/" + gameServiceName + "/" + route.rpc}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return route.call(ctx, req)
+	}
+	for i := len(g.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := g.interceptors[i], handler
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+
+	resp, err := handler(metadata.NewIncomingContext(r.Context(), gatewayMetadata(r)), req)
+	if err != nil {
+		g.writeStatus(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		g.logger.Warn("failed to write gateway response", zap.String("rpc", route.rpc), zap.Error(err))
+	}
+}
+
+// findRoute returns route matching method and path with its path variables,
+// pathMatched tells if there is a route for the path, but with another method
+func (g *restGateway) findRoute(method, path string) (route *gatewayRoute, vars map[string]string, pathMatched bool) {
+	for i := range g.routes {
+		vars, ok := matchGatewayPath(g.routes[i].pattern, path)
+		if !ok {
+			continue
+		}
+		if g.routes[i].method == method {
+			return &g.routes[i], vars, true
+		}
+		pathMatched = true
+	}
+	return nil, nil, pathMatched
+}
+
+func matchGatewayPath(pattern, path string) (map[string]string, bool) {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	vars := make(map[string]string)
+	for i, segment := range patternSegments {
+		if !strings.HasPrefix(segment, "{") {
+			if segment != pathSegments[i] {
+				return nil, false
+			}
+			continue
+		}
+
+		name, verb := segment[1:], ""
+		if end := strings.Index(name, "}"); end >= 0 {
+			name, verb = name[:end], name[end+1:]
+		}
+		value := pathSegments[i]
+		if !strings.HasSuffix(value, verb) || len(value) == len(verb) {
+			return nil, false
+		}
+		value = strings.TrimSuffix(value, verb)
+		if verb == "" && strings.Contains(value, ":") {
+			return nil, false //Custom verb of another route
+		}
+		vars[name] = value
+	}
+	return vars, true
+}
+
+func gatewayMetadata(r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	for _, header := range gatewayForwardedHeaders {
+		if values := r.Header.Values(header); len(values) > 0 {
+			md.Set(header, values...)
+		}
+	}
+	return md
+}
+
+func decodeGatewayBody(r *http.Request, dst interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, gatewayMaxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// gameIdFromPath validates {id}, service expects well-formed UUIDs
+func gameIdFromPath(vars map[string]string) (*api.UUID, error) {
+	if _, err := uuid.Parse(vars["id"]); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid session id %q", vars["id"])
+	}
+	return &api.UUID{Value: vars["id"]}, nil
+}
+
+func sessionWithIdFromPath(vars map[string]string) (*api.GameSession, error) {
+	gameId, err := gameIdFromPath(vars)
+	if err != nil {
+		return nil, err
+	}
+	return &api.GameSession{GameId: gameId}, nil
+}
+
+func sessionFromPath(_ *http.Request, vars map[string]string) (interface{}, error) {
+	session, err := sessionWithIdFromPath(vars)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// voteFromPathAndBody expects {"voter": {...}, "vote": 1} in body
+func voteFromPathAndBody(r *http.Request, vars map[string]string) (interface{}, error) {
+	vote := new(api.VoteContext)
+	if err := decodeGatewayBody(r, vote); err != nil {
+		return nil, err
+	}
+	session, err := sessionWithIdFromPath(vars)
+	if err != nil {
+		return nil, err
+	}
+	vote.Session = session
+	return vote, nil
+}
+
+// listSessionsRequestFromQuery reads filters from ?state=GAME_CREATED&player_id=1&chat_id=2&created_after=<RFC 3339>&offset=0&limit=10.
+// state could be repeated and given either by name or by number.
+func listSessionsRequestFromQuery(r *http.Request) (*api.ListSessionsRequest, error) {
+	query := r.URL.Query()
+	req := new(api.ListSessionsRequest)
+
+	for _, state := range query["state"] {
+		if value, known := api.GameSession_GameState_value[state]; known {
+			req.States = append(req.States, api.GameSession_GameState(value))
+			continue
+		}
+		value, err := strconv.ParseInt(state, 10, 32)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown game state %q", state)
+		}
+		req.States = append(req.States, api.GameSession_GameState(value))
+	}
+
+	var err error
+	parseUint := func(name string, bits int) uint64 {
+		value := query.Get(name)
+		if value == "" || err != nil {
+			return 0
+		}
+		n, parseErr := strconv.ParseUint(value, 10, bits)
+		if parseErr != nil {
+			err = status.Errorf(codes.InvalidArgument, "invalid %s %q", name, value)
+		}
+		return n
+	}
+	req.PlayerId = parseUint("player_id", 64)
+	req.Offset = uint32(parseUint("offset", 32))
+	req.Limit = uint32(parseUint("limit", 32))
+	if err != nil {
+		return nil, err
+	}
+
+	if chatId := query.Get("chat_id"); chatId != "" {
+		if req.ChatId, err = strconv.ParseInt(chatId, 10, 64); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid chat_id %q", chatId)
+		}
+	}
+	if createdAfter := query.Get("created_after"); createdAfter != "" {
+		t, err := time.Parse(time.RFC3339, createdAfter)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid created_after %q, expected RFC 3339 time", createdAfter)
+		}
+		if req.CreatedAfter, err = types.TimestampProto(t); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid created_after: %v", err)
+		}
+	}
+	return req, nil
+}
+
+func (g *restGateway) writeStatus(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	writeGatewayError(w, httpStatusFromCode(st.Code()), st.Code(), st.Message())
+}
+
+// gatewayError is a JSON body of failed gateway calls
+type gatewayError struct {
+	Code    codes.Code `json:"code"` //gRPC status code
+	Message string     `json:"message"`
+}
+
+func writeGatewayError(w http.ResponseWriter, httpStatus int, code codes.Code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	_ = json.NewEncoder(w).Encode(gatewayError{Code: code, Message: message})
+}
+
+// httpStatusFromCode follows mapping of google.rpc.Code to HTTP statuses
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 //Client closed request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// serveGateway serves REST gateway over HTTPS if TLS is configured, until server is shut down
+func serveGateway(server *http.Server, tls TLSConfig, logger *zap.Logger) {
+	logger.Info("serving REST gateway", zap.String("addr", server.Addr))
+	var err error
+	if tls.Enabled() {
+		err = server.ListenAndServeTLS(tls.CertFile, tls.KeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		logger.Error("REST gateway stopped", zap.Error(err))
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func newTestGateway(t *testing.T, interceptors ...grpc.UnaryServerInterceptor) *httptest.Server {
+	t.Helper()
+	logger := zaptest.NewLogger(t)
+	service := NewGameService(NewMemoryStorage(time.Minute), NewVoteStorage(logger), logger)
+	server := httptest.NewServer(NewRESTGateway(service, interceptors, logger))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// doJSON sends request with body encoded to JSON and decodes response into resp, returns HTTP status
+func doJSON(t *testing.T, method, url string, body, resp interface{}, headers ...string) int {
+	t.Helper()
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, url, &reqBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	httpResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpResp.Body.Close()
+	if resp != nil {
+		if err = json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+			t.Fatalf("%s %s: failed to decode response: %v", method, url, err)
+		}
+	}
+	return httpResp.StatusCode
+}
+
+func TestRESTGatewaySessionLifecycle(t *testing.T) {
+	server := newTestGateway(t)
+
+	created := new(api.GameSession)
+	if code := doJSON(t, http.MethodPost, server.URL+"/v1/sessions", testGameConfig(-42), created); code != http.StatusOK {
+		t.Fatalf("create session status = %d", code)
+	}
+	id := created.GetGameId().GetValue()
+	if id == "" || created.State != api.GameSession_GAME_CREATED {
+		t.Fatalf("unexpected created session %v", created)
+	}
+
+	fetched := new(api.GameSession)
+	if code := doJSON(t, http.MethodGet, server.URL+"/v1/sessions/"+id, nil, fetched); code != http.StatusOK || fetched.GetGameId().GetValue() != id {
+		t.Errorf("get session status = %d, session %v", code, fetched)
+	}
+
+	pushed := new(api.GameSession)
+	if code := doJSON(t, http.MethodPost, server.URL+"/v1/sessions/"+id+":push", nil, pushed); code != http.StatusOK || pushed.State == api.GameSession_GAME_CREATED {
+		t.Errorf("push status = %d, session state %v", code, pushed.State)
+	}
+
+	list := new(api.ListSessionsResponse)
+	query := fmt.Sprintf("/v1/sessions?player_id=1&state=GAME_CREATED&state=%d", pushed.State)
+	if code := doJSON(t, http.MethodGet, server.URL+query, nil, list); code != http.StatusOK || len(list.Sessions) != 1 {
+		t.Errorf("list sessions status = %d, got %d sessions; want 1", code, len(list.Sessions))
+	}
+
+	active := new(api.GameSession)
+	if code := doJSON(t, http.MethodGet, server.URL+"/v1/chats/-42/active-session", nil, active); code != http.StatusOK || active.GetGameId().GetValue() != id {
+		t.Errorf("active session status = %d, session %v", code, active)
+	}
+
+	if code := doJSON(t, http.MethodDelete, server.URL+"/v1/sessions/"+id, nil, nil); code != http.StatusOK {
+		t.Errorf("terminate session status = %d", code)
+	}
+}
+
+func TestRESTGatewayErrors(t *testing.T) {
+	server := newTestGateway(t, newTokenAuth([]string{"secret"}).unaryInterceptor)
+	auth := []string{"Authorization", "Bearer secret"}
+
+	for _, tc := range []struct {
+		method, path string
+		body         interface{}
+		headers      []string
+		wantStatus   int
+		wantCode     codes.Code
+	}{
+		{http.MethodGet, "/v1/games", nil, auth, http.StatusNotFound, codes.NotFound},
+		{http.MethodPatch, "/v1/sessions", nil, auth, http.StatusMethodNotAllowed, codes.Unimplemented},
+		{http.MethodPost, "/v1/sessions/id:explode", nil, auth, http.StatusNotFound, codes.NotFound},
+		{http.MethodPost, "/v1/sessions", testGameConfig(0), nil, http.StatusUnauthorized, codes.Unauthenticated},
+		{http.MethodPost, "/v1/sessions", map[string]int{"players": 5}, auth, http.StatusBadRequest, codes.InvalidArgument},
+		{http.MethodGet, "/v1/sessions?limit=-1", nil, auth, http.StatusBadRequest, codes.InvalidArgument},
+		{http.MethodGet, "/v1/chats/general/active-session", nil, auth, http.StatusBadRequest, codes.InvalidArgument},
+		{http.MethodGet, "/v1/sessions/not-a-uuid", nil, auth, http.StatusBadRequest, codes.InvalidArgument},
+		{http.MethodPost, "/v1/sessions/not-a-uuid/team-votes", map[string]int{"vote": 1}, auth, http.StatusBadRequest, codes.InvalidArgument},
+		{http.MethodGet, "/v1/sessions/" + uuid.New().String(), nil, auth, http.StatusNotFound, codes.NotFound},
+		{http.MethodDelete, "/v1/sessions/" + uuid.New().String(), nil, auth, http.StatusNotFound, codes.NotFound},
+	} {
+		t.Run(fmt.Sprint(tc.method, " ", tc.path), func(t *testing.T) {
+			var resp gatewayError
+			status := doJSON(t, tc.method, server.URL+tc.path, tc.body, &resp, tc.headers...)
+			if status != tc.wantStatus || resp.Code != tc.wantCode {
+				t.Errorf("got status %d, code %v (%s); want %d, %v", status, resp.Code, resp.Message, tc.wantStatus, tc.wantCode)
+			}
+		})
+	}
+
+	created := new(api.GameSession)
+	if code := doJSON(t, http.MethodPost, server.URL+"/v1/sessions", testGameConfig(-42), created, auth...); code != http.StatusOK {
+		t.Errorf("authenticated call status = %d", code)
+	}
+	var resp gatewayError
+	if code := doJSON(t, http.MethodPost, server.URL+"/v1/sessions", testGameConfig(-42), &resp, auth...); code != http.StatusConflict || resp.Code != codes.AlreadyExists {
+		t.Errorf("second game in chat status = %d, code %v; want %d, %v", code, resp.Code, http.StatusConflict, codes.AlreadyExists)
+	}
+}
+
+func TestMatchGatewayPath(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, path string
+		wantId        string
+		wantMatch     bool
+	}{
+		{"/v1/sessions", "/v1/sessions", "", true},
+		{"/v1/sessions", "/v1/sessions/", "", true},
+		{"/v1/sessions/{id}", "/v1/sessions/abc", "abc", true},
+		{"/v1/sessions/{id}", "/v1/sessions/abc:push", "", false},
+		{"/v1/sessions/{id}:push", "/v1/sessions/abc:push", "abc", true},
+		{"/v1/sessions/{id}:push", "/v1/sessions/:push", "", false},
+		{"/v1/sessions/{id}:push", "/v1/sessions/abc", "", false},
+		{"/v1/sessions/{id}/mission", "/v1/sessions/abc/mission-team", "", false},
+	} {
+		vars, ok := matchGatewayPath(tc.pattern, tc.path)
+		if ok != tc.wantMatch || vars["id"] != tc.wantId {
+			t.Errorf("matchGatewayPath(%q, %q) = %v, %v; want id %q, %v", tc.pattern, tc.path, vars, ok, tc.wantId, tc.wantMatch)
+		}
+	}
+}
@@ -5,8 +5,23 @@ go 1.15
 
 require (
 	github.com/OrlovEvgeny/go-mcache v0.0.0-20200121124330-1a8195b34f3a
+	github.com/alicebob/miniredis/v2 v2.14.1
+	github.com/desertbit/timer v0.0.0-20180107155436-c41aec40b27f // indirect
+	github.com/go-redis/redis/v8 v8.4.0
 	github.com/gogo/protobuf v1.3.1
 	github.com/google/uuid v1.1.2
+	github.com/gorilla/websocket v1.4.2
+	github.com/improbable-eng/grpc-web v0.13.0
+	github.com/prometheus/client_golang v1.8.0
+	github.com/rs/cors v1.7.0
+	go.etcd.io/bbolt v1.3.5
 	go.mongodb.org/mongo-driver v1.4.3
-	google.golang.org/grpc v1.33.1
+	go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.14.0
+	go.opentelemetry.io/otel v0.14.0
+	go.opentelemetry.io/otel/exporters/otlp v0.14.0
+	go.opentelemetry.io/otel/exporters/stdout v0.14.0
+	go.opentelemetry.io/otel/sdk v0.14.0
+	go.uber.org/zap v1.16.0
+	google.golang.org/grpc v1.33.2
+	gopkg.in/yaml.v2 v2.3.0
 )
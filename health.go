@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// gameServiceName is the name of api.GameService used in health checks
+const gameServiceName = "proto.GameService"
+
+// Storage must answer ping in that time to be considered healthy
+const storagePingTimeout = 3 * time.Second
+
+// watchStorageHealth pings storage backend every interval and reports NOT_SERVING while it fails.
+// Storages without external database are always considered healthy. Blocks until ctx is done.
+func watchStorageHealth(ctx context.Context, healthServer *health.Server, storage GameSessionStorage, interval time.Duration, logger *zap.Logger) {
+	pinger, ok := unwrapStorage(storage).(StoragePinger)
+	if !ok {
+		setServingStatus(healthServer, healthpb.HealthCheckResponse_SERVING)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	healthy := true
+	for {
+		err := pingStorage(ctx, pinger)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil && healthy:
+			logger.Error("storage is unreachable, reporting NOT_SERVING", zap.Error(err))
+		case err == nil && !healthy:
+			logger.Info("storage is reachable again, reporting SERVING")
+		}
+		healthy = err == nil
+
+		if healthy {
+			setServingStatus(healthServer, healthpb.HealthCheckResponse_SERVING)
+		} else {
+			setServingStatus(healthServer, healthpb.HealthCheckResponse_NOT_SERVING)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func pingStorage(ctx context.Context, pinger StoragePinger) error {
+	ctx, cancel := context.WithTimeout(ctx, storagePingTimeout)
+	defer cancel()
+	return pinger.Ping(ctx)
+}
+
+// setServingStatus updates both overall server status and status of GameService
+func setServingStatus(healthServer *health.Server, status healthpb.HealthCheckResponse_ServingStatus) {
+	healthServer.SetServingStatus("", status)
+	healthServer.SetServingStatus(gameServiceName, status)
+}
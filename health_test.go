@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func waitForServingStatus(t *testing.T, healthServer *health.Server, want healthpb.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: gameServiceName})
+		if err == nil && resp.Status == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("health status = %v (%v); want %v", resp.GetStatus(), err, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchStorageHealth(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	healthServer := health.NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stor := NewInstrumentedSessionStorage(NewRedisSessionStorage(rdb, time.Minute, zaptest.NewLogger(t)), storageRedis)
+	go watchStorageHealth(ctx, healthServer, stor, 10*time.Millisecond, zaptest.NewLogger(t))
+	waitForServingStatus(t, healthServer, healthpb.HealthCheckResponse_SERVING)
+
+	mr.Close()
+	waitForServingStatus(t, healthServer, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	if err := mr.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	waitForServingStatus(t, healthServer, healthpb.HealthCheckResponse_SERVING)
+}
+
+func TestWatchStorageHealthWithoutPing(t *testing.T) {
+	healthServer := health.NewServer()
+	watchStorageHealth(context.Background(), healthServer, NewMemoryStorage(time.Minute), time.Minute, zaptest.NewLogger(t))
+	waitForServingStatus(t, healthServer, healthpb.HealthCheckResponse_SERVING)
+}
+
+// blockingSessionStorage never answers GetSession until call is cancelled
+type blockingSessionStorage struct {
+	GameSessionStorage
+	called chan struct{}
+}
+
+func (s *blockingSessionStorage) GetSession(ctx context.Context, _ uuid.UUID) (*GameInstance, error) {
+	close(s.called)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestGracefulStop(t *testing.T) {
+	stor := &blockingSessionStorage{GameSessionStorage: NewMemoryStorage(time.Minute), called: make(chan struct{})}
+	server := grpc.NewServer()
+	api.RegisterGameServiceServer(server, NewGameService(stor, NewVoteStorage(zaptest.NewLogger(t)), zaptest.NewLogger(t)))
+
+	socket, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(socket) }()
+
+	conn, err := grpc.Dial(socket.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	callErr := make(chan error, 1)
+	go func() {
+		_, err := api.NewGameServiceClient(conn).GetSession(context.Background(), &api.UUID{Value: uuid.New().String()})
+		callErr <- err
+	}()
+	<-stor.called
+
+	if gracefulStop(server, 50*time.Millisecond) {
+		t.Error("stuck call finished in time")
+	}
+	if err := <-callErr; err == nil {
+		t.Error("stuck call succeeded after server stop")
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+)
+
+// testGameConfig seats 3 virtuous and 2 evil players with ids 1 to 5
+func testGameConfig(chatId int64) *api.GameConfig {
+	players := make([]*api.Player, 0, 5)
+	for id := uint64(1); id <= 5; id++ {
+		players = append(players, &api.Player{Id: id})
+	}
+	return &api.GameConfig{
+		GoodTeam: &api.VirtuousTeam{Members: players[:3]},
+		EvilTeam: &api.EvilTeam{Members: players[3:]},
+		ChatId:   chatId,
+	}
+}
+
+// testGameId parses id of session created by service, which is always well-formed
+func testGameId(id *api.UUID) uuid.UUID {
+	return uuid.MustParse(id.GetValue())
+}
+
+// specialRolesTeams deals every role of extensions to 10 players with ids 11 to 20:
+// Merlin 11 and Percival 12 are virtuous, assassin 17, Morgana 18, Oberon 19 and Mordred 20 are evil
+func specialRolesTeams() (*api.VirtuousTeam, *api.EvilTeam) {
+	players := make([]*api.Player, 0, 10)
+	for id := uint64(11); id <= 20; id++ {
+		players = append(players, &api.Player{Id: id, UserName: fmt.Sprint("player", id)})
+	}
+	good := &api.VirtuousTeam{Members: players[:6], Merlin: players[0], Percival: players[1]}
+	evil := &api.EvilTeam{Members: players[6:], Assassin: players[6], Morgana: players[7], Oberon: players[8], Mordred: players[9]}
+	return good, evil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	"go.opentelemetry.io/otel/label"
+)
+
+func gameIdLabel(id fmt.Stringer) label.KeyValue {
+	return label.Stringer("game.id", id)
+}
+
+// instrumentation records latency histogram and tracing span of a single storage operation
+type instrumentation struct {
+	name string //Storage backend name
+}
+
+// begin starts span for storage operation, returned function must be called with operation result
+func (i instrumentation) begin(ctx context.Context, operation string, attrs ...label.KeyValue) (context.Context, func(error)) {
+	start := time.Now()
+	attrs = append(attrs, label.String("storage.backend", i.name))
+	ctx, span := startSpan(ctx, "storage."+operation, attrs...)
+	return ctx, func(err error) {
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+		storageDuration.WithLabelValues(i.name, operation, result).Observe(time.Since(start).Seconds())
+		endSpan(span, err)
+	}
+}
+
+// instrumentedSessionStorage measures latency and traces every backend operation
+type instrumentedSessionStorage struct {
+	instrumentation
+	backend GameSessionStorage
+}
+
+func NewInstrumentedSessionStorage(backend GameSessionStorage, name string) *instrumentedSessionStorage {
+	return &instrumentedSessionStorage{instrumentation: instrumentation{name: name}, backend: backend}
+}
+
+// Unwrap returns measured storage
+func (i *instrumentedSessionStorage) Unwrap() GameSessionStorage {
+	return i.backend
+}
+
+func (i *instrumentedSessionStorage) StoreSession(ctx context.Context, session *GameInstance) (err error) {
+	ctx, finish := i.begin(ctx, "store", label.String("game.id", session.GetGameId().GetValue()))
+	defer func() { finish(err) }()
+	return i.backend.StoreSession(ctx, session)
+}
+
+func (i *instrumentedSessionStorage) GetSession(ctx context.Context, id uuid.UUID) (game *GameInstance, err error) {
+	ctx, finish := i.begin(ctx, "get", gameIdLabel(id))
+	defer func() { finish(err) }()
+	return i.backend.GetSession(ctx, id)
+}
+
+func (i *instrumentedSessionStorage) CloseSession(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, finish := i.begin(ctx, "close", gameIdLabel(id))
+	defer func() { finish(err) }()
+	return i.backend.CloseSession(ctx, id)
+}
+
+func (i *instrumentedSessionStorage) CheckExistence(ctx context.Context, id uuid.UUID) (exist bool, err error) {
+	ctx, finish := i.begin(ctx, "check_existence", gameIdLabel(id))
+	defer func() { finish(err) }()
+	return i.backend.CheckExistence(ctx, id)
+}
+
+func (i *instrumentedSessionStorage) NumberOfGames(ctx context.Context) (n uint, err error) {
+	ctx, finish := i.begin(ctx, "count")
+	defer func() { finish(err) }()
+	return i.backend.NumberOfGames(ctx)
+}
+
+func (i *instrumentedSessionStorage) ListSessions(ctx context.Context, filter SessionFilter) (games []*GameInstance, err error) {
+	ctx, finish := i.begin(ctx, "list")
+	defer func() { finish(err) }()
+	return i.backend.ListSessions(ctx, filter)
+}
+
+func (i *instrumentedSessionStorage) FindSessionsByPlayer(ctx context.Context, playerId uint64) (games []*GameInstance, err error) {
+	ctx, finish := i.begin(ctx, "find_by_player", label.Uint64("player.id", playerId))
+	defer func() { finish(err) }()
+	return i.backend.FindSessionsByPlayer(ctx, playerId)
+}
+
+func (i *instrumentedSessionStorage) StoreSessionAndResetVotes(ctx context.Context, session *GameInstance) (err error) {
+	ctx, finish := i.begin(ctx, "store_and_reset_votes", label.String("game.id", session.GetGameId().GetValue()))
+	defer func() { finish(err) }()
+	txStorage, ok := i.backend.(TransactionalStorage)
+	if !ok {
+		return errors.New("instrumented storage backend is not transactional")
+	}
+	return txStorage.StoreSessionAndResetVotes(ctx, session)
+}
+
+func (i *instrumentedSessionStorage) CreateChatSession(ctx context.Context, session *GameInstance) (err error) {
+	ctx, finish := i.begin(ctx, "create_chat_session", label.String("game.id", session.GetGameId().GetValue()))
+	defer func() {
+		//Occupied chat is an expected outcome, not a storage failure
+		if err == ErrChatAlreadyHasGame {
+			finish(nil)
+		} else {
+			finish(err)
+		}
+	}()
+	chatStorage, ok := i.backend.(ChatSessionStorage)
+	if !ok {
+		return errors.New("instrumented storage backend can't bind sessions to chats")
+	}
+	return chatStorage.CreateChatSession(ctx, session)
+}
+
+func (i *instrumentedSessionStorage) ActiveChatSession(ctx context.Context, chatId int64) (game *GameInstance, err error) {
+	ctx, finish := i.begin(ctx, "active_chat_session", label.Int64("chat.id", chatId))
+	defer func() { finish(err) }()
+	chatStorage, ok := i.backend.(ChatSessionStorage)
+	if !ok {
+		return nil, errors.New("instrumented storage backend can't bind sessions to chats")
+	}
+	return chatStorage.ActiveChatSession(ctx, chatId)
+}
+
+func (i *instrumentedSessionStorage) WatchSessions(ctx context.Context) (<-chan SessionChange, error) {
+	notifier, ok := i.backend.(SessionChangeNotifier)
+	if !ok {
+		return nil, errors.New("instrumented storage backend does not report session changes")
+	}
+	return notifier.WatchSessions(ctx)
+}
+
+// instrumentedVoteStorage measures latency and traces every vote storage operation
+type instrumentedVoteStorage struct {
+	instrumentation
+	backend VoteStorage
+}
+
+func NewInstrumentedVoteStorage(backend VoteStorage, name string) *instrumentedVoteStorage {
+	return &instrumentedVoteStorage{instrumentation: instrumentation{name: name}, backend: backend}
+}
+
+// Unwrap returns measured storage
+func (i *instrumentedVoteStorage) Unwrap() VoteStorage {
+	return i.backend
+}
+
+// voteFailure hides repeated votes, they are expected outcome, not a storage failure
+func voteFailure(err error) error {
+	if err == ErrRepeatedVote {
+		return nil
+	}
+	return err
+}
+
+func (i *instrumentedVoteStorage) AddPositiveMissionVote(ctx context.Context, gameId uuid.UUID, player *api.Player) (err error) {
+	ctx, finish := i.begin(ctx, "add_mission_vote", gameIdLabel(gameId), label.Uint64("player.id", player.GetId()))
+	defer func() { finish(voteFailure(err)) }()
+	return i.backend.AddPositiveMissionVote(ctx, gameId, player)
+}
+
+func (i *instrumentedVoteStorage) AddNegativeMissionVote(ctx context.Context, gameId uuid.UUID, player *api.Player) (err error) {
+	ctx, finish := i.begin(ctx, "add_mission_vote", gameIdLabel(gameId), label.Uint64("player.id", player.GetId()))
+	defer func() { finish(voteFailure(err)) }()
+	return i.backend.AddNegativeMissionVote(ctx, gameId, player)
+}
+
+func (i *instrumentedVoteStorage) GetMissionVotesCountForGame(ctx context.Context, id uuid.UUID) (n int8, err error) {
+	ctx, finish := i.begin(ctx, "count_mission_votes", gameIdLabel(id))
+	defer func() { finish(err) }()
+	return i.backend.GetMissionVotesCountForGame(ctx, id)
+}
+
+func (i *instrumentedVoteStorage) NumberOfPlayersVotedForMission(ctx context.Context, id uuid.UUID) (n int, err error) {
+	ctx, finish := i.begin(ctx, "count_mission_voters", gameIdLabel(id))
+	defer func() { finish(err) }()
+	return i.backend.NumberOfPlayersVotedForMission(ctx, id)
+}
+
+func (i *instrumentedVoteStorage) AddTeamVote(ctx context.Context, gameId uuid.UUID, player *api.Player, vote api.VoteContext_VoteOption) (err error) {
+	ctx, finish := i.begin(ctx, "add_team_vote", gameIdLabel(gameId), label.Uint64("player.id", player.GetId()))
+	defer func() { finish(voteFailure(err)) }()
+	return i.backend.AddTeamVote(ctx, gameId, player, vote)
+}
+
+func (i *instrumentedVoteStorage) GetTeamVotes(ctx context.Context, id uuid.UUID) (votes *TeamVotes, err error) {
+	ctx, finish := i.begin(ctx, "get_team_votes", gameIdLabel(id))
+	defer func() { finish(err) }()
+	return i.backend.GetTeamVotes(ctx, id)
+}
+
+func (i *instrumentedVoteStorage) ResetVotes(ctx context.Context, gameId uuid.UUID) (err error) {
+	ctx, finish := i.begin(ctx, "reset_votes", gameIdLabel(gameId))
+	defer func() { finish(err) }()
+	return i.backend.ResetVotes(ctx, gameId)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// runAsLeader runs work only while holder has lease name in storage, so one replica at a time does it.
+// Lease is renewed every third of ttl, work is cancelled as soon as lease could not be renewed
+// and started again once lease is taken back. Storages not shared between replicas run work right away.
+// Blocks until ctx is done and work has returned.
+func runAsLeader(ctx context.Context, storage GameSessionStorage, name, holder string, ttl time.Duration, logger *zap.Logger, work func(ctx context.Context)) {
+	leases, ok := unwrapStorage(storage).(LeaseStorage)
+	if !ok {
+		work(ctx)
+		return
+	}
+	logger = logger.With(zap.String("lease", name))
+
+	var stopWork context.CancelFunc
+	var workDone chan struct{}
+	stop := func() {
+		if stopWork != nil {
+			stopWork()
+			<-workDone
+			stopWork = nil
+		}
+	}
+	defer func() {
+		stop()
+		//ctx is already done, so others are let in with a separate deadline
+		releaseCtx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		defer cancel()
+		if err := leases.ReleaseLease(releaseCtx, name, holder); err != nil {
+			logger.Warn("failed to release lease", zap.Error(err))
+		}
+	}()
+
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		held, err := leases.AcquireLease(ctx, name, holder, ttl)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			//Lease could expire before storage is reachable again
+			logger.Error("failed to renew lease", zap.Error(err))
+		}
+
+		switch {
+		case held && stopWork == nil:
+			logger.Info("lease acquired, starting work")
+			workCtx, cancel := context.WithCancel(ctx)
+			stopWork = cancel
+			workDone = make(chan struct{})
+			go func(done chan struct{}) {
+				defer close(done)
+				work(workCtx)
+			}(workDone)
+		case !held && stopWork != nil:
+			logger.Warn("lease lost, stopping work")
+			stop()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
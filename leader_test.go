@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRedisLease(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	stor := NewRedisSessionStorage(rdb, time.Minute, zaptest.NewLogger(t))
+
+	acquire := func(holder string) bool {
+		t.Helper()
+		held, err := stor.AcquireLease(ctx, "work", holder, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return held
+	}
+	if !acquire("a") || !acquire("a") {
+		t.Fatal("holder could not take and renew free lease")
+	}
+	if acquire("b") {
+		t.Fatal("lease was taken while held by another holder")
+	}
+	//Only holder releases lease
+	if err := stor.ReleaseLease(ctx, "work", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if acquire("b") {
+		t.Fatal("lease was released by another holder")
+	}
+
+	mr.FastForward(2 * time.Second)
+	if !acquire("b") {
+		t.Fatal("expired lease was not taken")
+	}
+	if err := stor.ReleaseLease(ctx, "work", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if !acquire("a") {
+		t.Fatal("released lease was not taken")
+	}
+}
+
+func TestMongoLease(t *testing.T) {
+	ctx := context.Background()
+	stor := newTestMongoStorage(t)
+
+	acquire := func(holder string, ttl time.Duration) bool {
+		t.Helper()
+		held, err := stor.AcquireLease(ctx, "work", holder, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return held
+	}
+	if !acquire("a", time.Minute) || !acquire("a", time.Minute) {
+		t.Fatal("holder could not take and renew free lease")
+	}
+	if acquire("b", time.Minute) {
+		t.Fatal("lease was taken while held by another holder")
+	}
+	if err := stor.ReleaseLease(ctx, "work", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if !acquire("b", -time.Second) {
+		t.Fatal("released lease was not taken")
+	}
+	if !acquire("a", time.Minute) {
+		t.Fatal("expired lease was not taken")
+	}
+}
+
+func TestRunAsLeader(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	stor := NewRedisSessionStorage(rdb, time.Minute, zaptest.NewLogger(t))
+	const ttl = 300 * time.Millisecond
+
+	var working [2]int32
+	stopped := make([]chan struct{}, 2)
+	cancels := make([]context.CancelFunc, 2)
+	for n, holder := range []string{"a", "b"} {
+		n := n
+		var ctx context.Context
+		ctx, cancels[n] = context.WithCancel(context.Background())
+		stopped[n] = make(chan struct{})
+		go func(holder string) {
+			defer close(stopped[n])
+			runAsLeader(ctx, stor, "work", holder, ttl, zaptest.NewLogger(t), func(ctx context.Context) {
+				atomic.StoreInt32(&working[n], 1)
+				<-ctx.Done()
+				atomic.StoreInt32(&working[n], 0)
+			})
+		}(holder)
+		//Makes "a" the first leader
+		waitFor(t, "first replica to start work", func() bool { return atomic.LoadInt32(&working[0]) == 1 })
+	}
+	defer cancels[1]()
+
+	time.Sleep(ttl)
+	if atomic.LoadInt32(&working[1]) != 0 {
+		t.Fatal("both replicas are working")
+	}
+
+	//Lease taken over while leader could not renew it stops work of the old leader
+	mr.Set(redisLeaseKey("work"), "b")
+	waitFor(t, "leadership to move to second replica", func() bool {
+		return atomic.LoadInt32(&working[0]) == 0 && atomic.LoadInt32(&working[1]) == 1
+	})
+
+	//Leader stepping down lets others in
+	cancels[1]()
+	<-stopped[1]
+	waitFor(t, "leadership to move back to first replica", func() bool { return atomic.LoadInt32(&working[0]) == 1 })
+	cancels[0]()
+	<-stopped[0]
+	if mr.Exists(redisLeaseKey("work")) {
+		t.Error("lease was not released on shutdown")
+	}
+}
+
+func TestRunAsLeaderWithoutLeases(t *testing.T) {
+	ran := false
+	runAsLeader(context.Background(), NewMemoryStorage(time.Minute), "work", "a", time.Second, zaptest.NewLogger(t), func(ctx context.Context) {
+		ran = true
+	})
+	if !ran {
+		t.Error("work was not run with storage of a single replica")
+	}
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for " + what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/justmax437/avalonBacker/api"
+)
+
+const (
+	localeRussian = "ru"
+	localeEnglish = "en"
+	defaultLocale = localeRussian
+)
+
+// endgameMessages is a catalog of endgame reason texts by locale
+var endgameMessages = map[string]map[api.EndgameOutcome_Reason]func(o *api.EndgameOutcome) string{
+	localeRussian: {
+		api.EndgameOutcome_MISSIONS_PASSED: func(o *api.EndgameOutcome) string {
+			return fmt.Sprintf("Силы добра победили в %d/5 миссий", o.MissionsPassed)
+		},
+		api.EndgameOutcome_MISSIONS_FAILED: func(o *api.EndgameOutcome) string {
+			return fmt.Sprintf("Силы зла победили в %d/5 миссий", o.MissionsFailed)
+		},
+		api.EndgameOutcome_TEAM_PROPOSALS_REJECTED: func(o *api.EndgameOutcome) string {
+			return fmt.Sprintf("Прошло %d неудачных голосований за состав команды", o.RejectedProposals)
+		},
+		api.EndgameOutcome_MERLIN_ASSASSINATED: func(o *api.EndgameOutcome) string {
+			return "Мерлин был убит ассасином"
+		},
+		api.EndgameOutcome_ASSASSINATION_FAILED: func(o *api.EndgameOutcome) string {
+			return fmt.Sprintf("%d/5 миссий завершены победой добра и Ассасину не удалось убить Мерлина", o.MissionsPassed)
+		},
+	},
+	localeEnglish: {
+		api.EndgameOutcome_MISSIONS_PASSED: func(o *api.EndgameOutcome) string {
+			return fmt.Sprintf("The forces of good won %d/5 missions", o.MissionsPassed)
+		},
+		api.EndgameOutcome_MISSIONS_FAILED: func(o *api.EndgameOutcome) string {
+			return fmt.Sprintf("The forces of evil won %d/5 missions", o.MissionsFailed)
+		},
+		api.EndgameOutcome_TEAM_PROPOSALS_REJECTED: func(o *api.EndgameOutcome) string {
+			return fmt.Sprintf("%d mission team proposals in a row were rejected", o.RejectedProposals)
+		},
+		api.EndgameOutcome_MERLIN_ASSASSINATED: func(o *api.EndgameOutcome) string {
+			return "Merlin was killed by the assassin"
+		},
+		api.EndgameOutcome_ASSASSINATION_FAILED: func(o *api.EndgameOutcome) string {
+			return fmt.Sprintf("The forces of good won %d/5 missions and the assassin failed to kill Merlin", o.MissionsPassed)
+		},
+	},
+}
+
+// normalizeLocale reduces language tags like en-US to a supported locale, empty locale means default one
+func normalizeLocale(locale string) (string, error) {
+	if locale == "" {
+		return defaultLocale, nil
+	}
+	language := strings.ToLower(strings.SplitN(strings.ReplaceAll(locale, "_", "-"), "-", 2)[0])
+	if _, supported := endgameMessages[language]; !supported {
+		return "", fmt.Errorf("unsupported locale %q, expected %s or %s", locale, localeRussian, localeEnglish)
+	}
+	return language, nil
+}
+
+// endgameReasonText renders outcome in session locale, falling back to default locale
+func endgameReasonText(locale string, outcome *api.EndgameOutcome) string {
+	messages, supported := endgameMessages[locale]
+	if !supported {
+		messages = endgameMessages[defaultLocale]
+	}
+	if message, exist := messages[outcome.GetReason()]; exist {
+		return message(outcome)
+	}
+	return outcome.GetReason().String()
+}
+
+// botMessage identifies Telegram bot text in botMessages catalog
+type botMessage int
+
+const (
+	msgFailed botMessage = iota
+	msgHelp
+	msgPrivateChat
+	msgLobbyAlreadyOpen
+	msgGameInProgress
+	msgLobbyOpened
+	msgNoLobby
+	msgAlreadyJoined
+	msgLobbyFull
+	msgJoined
+	msgLeft
+	msgNotEnoughPlayers
+	msgGameStarted
+	msgSeatBotName
+	msgRoleServant
+	msgRoleMerlin
+	msgRoleMinion
+	msgRoleAssassin
+	msgRolePercival
+	msgRoleMorgana
+	msgRoleOberon
+	msgRoleMordred
+	msgCantSendRole
+	msgLobbyCancelled
+	msgNoGame
+	msgGameCancelled
+	msgTeamPicking
+	msgFailsRequired
+	msgTeamVoting
+	msgTeamOnMission
+	msgMissionQuestion
+	msgMissionPassed
+	msgMissionFailed
+	msgLastChance
+	msgWhoIsMerlin
+	msgGoodWon
+	msgEvilWon
+	msgGameOver
+	msgProposeTeamButton
+	msgApproveButton
+	msgRejectButton
+	msgAbstainButton
+	msgSuccessButton
+	msgFailButton
+	msgGameIsOver
+	msgNotInGame
+	msgTeamAlreadyPicked
+	msgLeaderPicks
+	msgTeamVotingOver
+	msgNotOnMission
+	msgAssassinPicks
+	msgTeamSizeLimit
+	msgPickPlayers
+	msgTeamProposed
+	msgAlreadyVoted
+	msgVoteCounted
+	msgTeamApproved
+	msgTeamRejected
+	msgTeamVotes
+	msgNobody
+	msgServantsCantFail
+	msgMerlinKilled
+	msgMerlinMissed
+)
+
+// botMessages is a catalog of Telegram bot texts by locale, texts are fmt formats
+var botMessages = map[string]map[botMessage]string{
+	localeRussian: {
+		msgFailed: "Что-то пошло не так, попробуйте ещё раз",
+		msgHelp: `Команды для группового чата:
+/newgame — открыть набор в игру, /newgame en — на английском
+/join — присоединиться к игре
+/leave — выйти из набора
+/startgame — начать игру (от 5 до 10 игроков, недостающих до 5 могут заменить боты)
+/cancel — отменить набор или прервать игру
+Напишите боту в личные сообщения, чтобы он мог сообщить вашу роль.`,
+		msgPrivateChat:       "Добавьте меня в групповой чат, чтобы сыграть в Avalon.",
+		msgLobbyAlreadyOpen:  "Набор в игру уже открыт, /join чтобы присоединиться",
+		msgGameInProgress:    "В этом чате уже идёт игра, /cancel чтобы её прервать",
+		msgLobbyOpened:       "%s открыл набор в игру. /join — присоединиться, /startgame — начать, когда соберётся от %d до %d игроков.",
+		msgNoLobby:           "Набор в игру не открыт, /newgame чтобы начать",
+		msgAlreadyJoined:     "%s уже в игре",
+		msgLobbyFull:         "Все места заняты, /startgame чтобы начать",
+		msgJoined:            "%s присоединился к игре (%d/%d)",
+		msgLeft:              "%s вышел из игры (%d/%d)",
+		msgNotEnoughPlayers:  "Для игры нужно хотя бы %d игроков, сейчас %d",
+		msgGameStarted:       "Игра началась! Игроки: %s. Злодеев среди вас: %d. Роли отправлены в личные сообщения.",
+		msgSeatBotName:       "🤖 Бот %d",
+		msgRoleServant:       "Вы — верный слуга Артура. Ваша цель — успешно завершить три миссии.",
+		msgRoleMerlin:        "Вы — Мерлин. Вам известны злодеи: %s. Не дайте Ассасину себя вычислить!",
+		msgRoleMinion:        "Вы — приспешник Мордреда. Злодеи: %s. Ваша цель — провалить три миссии.",
+		msgRoleAssassin:      " Вы — Ассасин: если добро победит в миссиях, у вас будет шанс убить Мерлина.",
+		msgRolePercival:      "Вы — Персиваль. Мерлин — один из них: %s, но Моргана выдаёт себя за Мерлина. Ваша цель — успешно завершить три миссии.",
+		msgRoleMorgana:       " Вы — Моргана: Персиваль видит вас как Мерлина.",
+		msgRoleOberon:        "Вы — Оберон, приспешник Мордреда. Злодеи не знают вас, а вы не знаете их. Ваша цель — провалить три миссии.",
+		msgRoleMordred:       " Вы — Мордред: Мерлин не знает о вас.",
+		msgCantSendRole:      "%s, не могу отправить вам роль. Напишите мне в личные сообщения и начните игру заново.",
+		msgLobbyCancelled:    "Набор в игру отменён",
+		msgNoGame:            "В этом чате нет игры",
+		msgGameCancelled:     "Игра прервана",
+		msgTeamPicking:       "Миссия %d, попытка %d из %d. Лидер %s выбирает %d игроков в команду.",
+		msgFailsRequired:     " Миссия провалится только при %d голосах «Провал».",
+		msgTeamVoting:        "%s предлагает отправить на миссию: %s. Голосуйте все!",
+		msgTeamOnMission:     "Команда отправляется на миссию: %s. Ждём их решения.",
+		msgMissionQuestion:   "Миссия %d. Чем она закончится?",
+		msgMissionPassed:     "Миссия %d успешно завершена, голосов за провал: %d. Счёт: добро %d, зло %d.",
+		msgMissionFailed:     "Миссия %d провалена, голосов за провал: %d. Счёт: добро %d, зло %d.",
+		msgLastChance:        "Добро побеждает в миссиях, но у Ассасина %s остался последний шанс: убить Мерлина.",
+		msgWhoIsMerlin:       "Кто из игроков Мерлин?",
+		msgGoodWon:           "Победа добра!",
+		msgEvilWon:           "Победа зла!",
+		msgGameOver:          "%s %s\nМерлин: %s. Ассасин: %s. Злодеи: %s.",
+		msgProposeTeamButton: "Предложить команду (%d/%d)",
+		msgApproveButton:     "👍 За",
+		msgRejectButton:      "👎 Против",
+		msgAbstainButton:     "🤷 Воздержаться",
+		msgSuccessButton:     "Успех",
+		msgFailButton:        "Провал",
+		msgGameIsOver:        "Эта игра уже закончилась",
+		msgNotInGame:         "Вы не участвуете в этой игре",
+		msgTeamAlreadyPicked: "Команда уже выбрана",
+		msgLeaderPicks:       "Команду выбирает лидер %s",
+		msgTeamVotingOver:    "Голосование за команду уже закончилось",
+		msgNotOnMission:      "Вы не участвуете в миссии",
+		msgAssassinPicks:     "Мерлина выбирает Ассасин",
+		msgTeamSizeLimit:     "В команде может быть только %d игроков",
+		msgPickPlayers:       "Выберите %d игроков",
+		msgTeamProposed:      "Команда предложена",
+		msgAlreadyVoted:      "Вы уже проголосовали",
+		msgVoteCounted:       "Голос учтён",
+		msgTeamApproved:      "Команда одобрена",
+		msgTeamRejected:      "Команда отклонена",
+		msgTeamVotes:         "Все проголосовали. За: %s. Против: %s. Воздержались: %s. %s.",
+		msgNobody:            "никто",
+		msgServantsCantFail:  "Слуги Артура не могут провалить миссию",
+		msgMerlinKilled:      "Ассасин убил %s, и это был Мерлин!",
+		msgMerlinMissed:      "Ассасин убил %s, и это был не Мерлин.",
+	},
+	localeEnglish: {
+		msgFailed: "Something went wrong, please try again",
+		msgHelp: `Group chat commands:
+/newgame — open a game lobby, /newgame ru — in Russian
+/join — join the game
+/leave — leave the lobby
+/startgame — start the game (5 to 10 players, bots could fill seats up to 5)
+/cancel — cancel the lobby or stop the game
+Write to the bot in private messages, so it could tell you your role.`,
+		msgPrivateChat:       "Add me to a group chat to play Avalon.",
+		msgLobbyAlreadyOpen:  "Game lobby is already open, /join to join it",
+		msgGameInProgress:    "A game is already in progress in this chat, /cancel to stop it",
+		msgLobbyOpened:       "%s opened a game lobby. /join to join, /startgame to start once there are %d to %d players.",
+		msgNoLobby:           "Game lobby is not open, /newgame to open one",
+		msgAlreadyJoined:     "%s is already in the game",
+		msgLobbyFull:         "All seats are taken, /startgame to start",
+		msgJoined:            "%s joined the game (%d/%d)",
+		msgLeft:              "%s left the game (%d/%d)",
+		msgNotEnoughPlayers:  "At least %d players are needed, there are %d now",
+		msgGameStarted:       "The game has started! Players: %s. Villains among you: %d. Roles were sent in private messages.",
+		msgSeatBotName:       "🤖 Bot %d",
+		msgRoleServant:       "You are a loyal servant of Arthur. Your goal is to complete three missions.",
+		msgRoleMerlin:        "You are Merlin. You know the villains: %s. Don't let the Assassin find you out!",
+		msgRoleMinion:        "You are a minion of Mordred. Villains: %s. Your goal is to fail three missions.",
+		msgRoleAssassin:      " You are the Assassin: if good wins the missions, you get a chance to kill Merlin.",
+		msgRolePercival:      "You are Percival. Merlin is one of: %s, but Morgana poses as Merlin. Your goal is to complete three missions.",
+		msgRoleMorgana:       " You are Morgana: Percival sees you as Merlin.",
+		msgRoleOberon:        "You are Oberon, a minion of Mordred. Villains don't know you and you don't know them. Your goal is to fail three missions.",
+		msgRoleMordred:       " You are Mordred: Merlin doesn't know you.",
+		msgCantSendRole:      "%s, I can't send you your role. Write me a private message and start the game again.",
+		msgLobbyCancelled:    "Game lobby was cancelled",
+		msgNoGame:            "There is no game in this chat",
+		msgGameCancelled:     "The game was stopped",
+		msgTeamPicking:       "Mission %d, attempt %d of %d. Leader %s picks %d players for the team.",
+		msgFailsRequired:     " The mission fails only with %d «Fail» votes.",
+		msgTeamVoting:        "%s proposes to send on the mission: %s. Everyone vote!",
+		msgTeamOnMission:     "The team goes on the mission: %s. Waiting for their decision.",
+		msgMissionQuestion:   "Mission %d. How will it end?",
+		msgMissionPassed:     "Mission %d succeeded, fail votes: %d. Score: good %d, evil %d.",
+		msgMissionFailed:     "Mission %d failed, fail votes: %d. Score: good %d, evil %d.",
+		msgLastChance:        "Good wins the missions, but the Assassin %s has one last chance: to kill Merlin.",
+		msgWhoIsMerlin:       "Which player is Merlin?",
+		msgGoodWon:           "Good wins!",
+		msgEvilWon:           "Evil wins!",
+		msgGameOver:          "%s %s\nMerlin: %s. Assassin: %s. Villains: %s.",
+		msgProposeTeamButton: "Propose team (%d/%d)",
+		msgApproveButton:     "👍 Approve",
+		msgRejectButton:      "👎 Reject",
+		msgAbstainButton:     "🤷 Abstain",
+		msgSuccessButton:     "Success",
+		msgFailButton:        "Fail",
+		msgGameIsOver:        "This game is already over",
+		msgNotInGame:         "You are not playing this game",
+		msgTeamAlreadyPicked: "The team is already picked",
+		msgLeaderPicks:       "Leader %s picks the team",
+		msgTeamVotingOver:    "Team voting is already over",
+		msgNotOnMission:      "You are not on the mission",
+		msgAssassinPicks:     "The Assassin picks Merlin",
+		msgTeamSizeLimit:     "The team can only have %d players",
+		msgPickPlayers:       "Pick %d players",
+		msgTeamProposed:      "Team proposed",
+		msgAlreadyVoted:      "You have already voted",
+		msgVoteCounted:       "Vote counted",
+		msgTeamApproved:      "Team approved",
+		msgTeamRejected:      "Team rejected",
+		msgTeamVotes:         "Everyone voted. Approved: %s. Rejected: %s. Abstained: %s. %s.",
+		msgNobody:            "nobody",
+		msgServantsCantFail:  "Servants of Arthur can't fail the mission",
+		msgMerlinKilled:      "The Assassin killed %s, and it was Merlin!",
+		msgMerlinMissed:      "The Assassin killed %s, and it was not Merlin.",
+	},
+}
+
+// botText renders Telegram bot text in locale, falling back to default locale
+func botText(locale string, msg botMessage, args ...interface{}) string {
+	format, exist := botMessages[locale][msg]
+	if !exist {
+		format = botMessages[defaultLocale][msg]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
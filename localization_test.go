@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	for locale, want := range map[string]string{"": localeRussian, "ru": localeRussian, "en": localeEnglish, "en-US": localeEnglish, "EN_gb": localeEnglish} {
+		if got, err := normalizeLocale(locale); err != nil || got != want {
+			t.Errorf("normalizeLocale(%q) = %q, %v; want %q", locale, got, err, want)
+		}
+	}
+	for _, locale := range []string{"fr", "-", "english"} {
+		if got, err := normalizeLocale(locale); err == nil {
+			t.Errorf("normalizeLocale(%q) = %q; want error", locale, got)
+		}
+	}
+}
+
+func TestEndgameReasonCatalog(t *testing.T) {
+	for locale := range endgameMessages {
+		for reason := range api.EndgameOutcome_Reason_name {
+			if reason == int32(api.EndgameOutcome_REASON_UNSPECIFIED) {
+				continue
+			}
+			if _, exist := endgameMessages[locale][api.EndgameOutcome_Reason(reason)]; !exist {
+				t.Errorf("no %s text for %v", locale, api.EndgameOutcome_Reason(reason))
+			}
+		}
+	}
+
+	game := &GameInstance{}
+	game.MissionsPassed, game.MissionsFailed = 2, 3
+	game.GameSession.Locale = localeRussian
+	setGameOutcome(game, api.GameSession_EVIL_TEAM_WON, &api.EndgameOutcome{Reason: api.EndgameOutcome_MISSIONS_FAILED})
+	if game.EndgameReason != "Силы зла победили в 3/5 миссий" {
+		t.Errorf("russian endgame reason = %q", game.EndgameReason)
+	}
+	if outcome := game.EndgameOutcome; outcome.MissionsPassed != 2 || outcome.MissionsFailed != 3 {
+		t.Errorf("outcome has no missions score: %+v", outcome)
+	}
+
+	game.GameSession.Locale = localeEnglish
+	setGameOutcome(game, api.GameSession_EVIL_TEAM_WON, &api.EndgameOutcome{Reason: api.EndgameOutcome_TEAM_PROPOSALS_REJECTED, RejectedProposals: 5})
+	if game.EndgameReason != "5 mission team proposals in a row were rejected" {
+		t.Errorf("english endgame reason = %q", game.EndgameReason)
+	}
+}
+
+func TestBotMessagesCatalog(t *testing.T) {
+	for locale, messages := range botMessages {
+		for msg := msgFailed; msg <= msgMerlinMissed; msg++ {
+			if messages[msg] == "" {
+				t.Errorf("no %s text for bot message %d", locale, msg)
+			}
+		}
+	}
+	if got := botText("fr", msgJoined, "@player", 2, 10); got != "@player присоединился к игре (2/10)" {
+		t.Errorf("text in unknown locale = %q; want default locale text", got)
+	}
+}
+
+func TestSessionLocale(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	service := NewGameService(NewMemoryStorage(time.Minute), NewVoteStorage(logger), logger)
+
+	config := testGameConfig(0)
+	config.Locale = "fr"
+	if _, err := service.CreateSession(ctx, config); err == nil || !strings.Contains(err.Error(), "locale") {
+		t.Errorf("session with unsupported locale was created, error %v", err)
+	}
+
+	config.Locale = "en-US"
+	session, err := service.CreateSession(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Locale != localeEnglish {
+		t.Errorf("session locale = %q; want %q", session.Locale, localeEnglish)
+	}
+	if session, err = service.CreateSession(ctx, testGameConfig(0)); err != nil || session.Locale != defaultLocale {
+		t.Errorf("session without locale = %v, %v; want default locale", session, err)
+	}
+}
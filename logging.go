@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/justmax437/avalonBacker/api"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	logFormatJSON    = "json"
+	logFormatConsole = "console"
+)
+
+// newLogger builds JSON logger for production or human readable one for local runs
+func newLogger(cfg LogConfig) (*zap.Logger, error) {
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+
+	var zapCfg zap.Config
+	switch cfg.Format {
+	case logFormatJSON:
+		zapCfg = zap.NewProductionConfig()
+	case logFormatConsole:
+		zapCfg = zap.NewDevelopmentConfig()
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+	zapCfg.Level = zap.NewAtomicLevelAt(level)
+	zapCfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	return zapCfg.Build()
+}
+
+type loggerCtxKey struct{}
+
+func contextWithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey{}, logger)
+}
+
+// loggerFromContext returns request logger set by loggingInterceptor or fallback, if there is none
+func loggerFromContext(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if logger, ok := ctx.Value(loggerCtxKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// Field helpers keep the same keys in every log entry
+func gameIdField(id fmt.Stringer) zap.Field {
+	return zap.Stringer("game_id", id)
+}
+
+func apiGameIdField(id *api.UUID) zap.Field {
+	return zap.String("game_id", id.GetValue())
+}
+
+func stateField(state api.GameSession_GameState) zap.Field {
+	return zap.Stringer("state", state)
+}
+
+func playerIdField(player *api.Player) zap.Field {
+	return zap.Uint64("player_id", player.GetId())
+}
+
+// requestLogFields extracts game and player ids from any request of GameService
+func requestLogFields(req interface{}) []zap.Field {
+	var session *api.GameSession
+	var player *api.Player
+	switch r := req.(type) {
+	case *api.UUID:
+		return []zap.Field{apiGameIdField(r)}
+	case *api.GameSession:
+		session = r
+	case *api.AssignTeamContext:
+		session = r.GetSession()
+	case *api.VoteContext:
+		session, player = r.GetSession(), r.GetVoter()
+	case *api.AssassinationContext:
+		session, player = r.GetSession(), r.GetTarget()
+	case *api.ListSessionsRequest:
+		if r.GetPlayerId() != 0 {
+			return []zap.Field{zap.Uint64("player_id", r.GetPlayerId())}
+		}
+	case *api.Chat:
+		return []zap.Field{zap.Int64("chat_id", r.GetId())}
+	}
+
+	fields := make([]zap.Field, 0, 2)
+	if session != nil {
+		fields = append(fields, apiGameIdField(session.GetGameId()))
+	}
+	if player != nil {
+		fields = append(fields, playerIdField(player))
+	}
+	return fields
+}
+
+// traceLogFields links log entries to the trace of the request, if it is traced
+func traceLogFields(ctx context.Context) []zap.Field {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.HasTraceID() {
+		return nil
+	}
+	return []zap.Field{zap.Stringer("trace_id", spanCtx.TraceID)}
+}
+
+// loggingInterceptor puts logger tagged with RPC method and request ids into the context and logs every call
+func loggingInterceptor(base *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		logger := base.With(zap.String("method", info.FullMethod)).With(requestLogFields(req)...).With(traceLogFields(ctx)...)
+		start := time.Now()
+		resp, err := handler(contextWithLogger(ctx, logger), req)
+
+		fields := []zap.Field{zap.Duration("duration", time.Since(start))}
+		if session, ok := resp.(*api.GameSession); ok {
+			fields = append(fields, stateField(session.GetState()))
+		}
+		if err != nil {
+			fields = append(fields, zap.Stringer("code", status.Code(err)), zap.Error(err))
+			logger.Warn("rpc failed", fields...)
+		} else {
+			logger.Debug("rpc finished", fields...)
+		}
+		return resp, err
+	}
+}
+
+func streamLoggingInterceptor(base *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		logger := base.With(zap.String("method", info.FullMethod)).With(traceLogFields(ss.Context())...)
+		start := time.Now()
+		err := handler(srv, &loggingServerStream{ServerStream: ss, ctx: contextWithLogger(ss.Context(), logger)})
+		if err != nil {
+			logger.Warn("stream failed", zap.Duration("duration", time.Since(start)), zap.Error(err))
+		} else {
+			logger.Debug("stream finished", zap.Duration("duration", time.Since(start)))
+		}
+		return err
+	}
+}
+
+type loggingServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggingServerStream) Context() context.Context {
+	return s.ctx
+}
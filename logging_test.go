@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+)
+
+func TestLoggingInterceptorTagsEntries(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	interceptor := loggingInterceptor(zap.New(core))
+
+	req := &api.VoteContext{
+		Session: &api.GameSession{GameId: &api.UUID{Value: "game"}},
+		Voter:   &api.Player{Id: 42},
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto.GameService/VoteForMissionTeam"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		loggerFromContext(ctx, zap.NewNop()).Info("inside handler")
+		return nil, errors.New("vote rejected")
+	}
+
+	if _, err := interceptor(context.Background(), req, info, handler); err == nil {
+		t.Fatal("interceptor swallowed handler error")
+	}
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("got %d log entries; want 2", len(entries))
+	}
+	for _, entry := range entries {
+		fields := entry.ContextMap()
+		if fields["method"] != info.FullMethod || fields["game_id"] != "game" || fields["player_id"] != uint64(42) {
+			t.Errorf("entry %q is not tagged with request context: %v", entry.Message, fields)
+		}
+	}
+	if entries[1].Level != zapcore.WarnLevel {
+		t.Errorf("failed call logged at %v; want warn", entries[1].Level)
+	}
+}
+
+func TestNewLoggerValidatesConfig(t *testing.T) {
+	if _, err := newLogger(LogConfig{Level: "info", Format: logFormatJSON}); err != nil {
+		t.Error(err)
+	}
+	if _, err := newLogger(LogConfig{Level: "loud", Format: logFormatJSON}); err == nil {
+		t.Error("unknown level accepted")
+	}
+	if _, err := newLogger(LogConfig{Level: "info", Format: "xml"}); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
@@ -1,33 +1,55 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"github.com/OrlovEvgeny/go-mcache"
 	"github.com/google/uuid"
 	"github.com/justmax437/avalonBacker/api"
+	"sync"
 	"time"
 )
 
 var ErrSessionNotFound = errors.New("no session with specified UUID")
 
 type memoryStorage struct {
+	sessionChangeBroadcaster
+
 	stor *mcache.CacheDriver
 	ttl  time.Duration
+
+	//mcache can't iterate over its keys, so we keep track of them for ListSessions.
+	//Keys of expired sessions are dropped lazily during listing.
+	idsLock sync.Mutex
+	ids     map[uuid.UUID]struct{}
 }
 
 func NewMemoryStorage(ttl time.Duration) GameSessionStorage {
-	return &memoryStorage{mcache.New(), ttl}
+	return &memoryStorage{
+		stor: mcache.New(),
+		ttl:  ttl,
+		ids:  make(map[uuid.UUID]struct{}),
+	}
 }
 
-func (i *memoryStorage) StoreSession(session *GameInstance) error {
+func (i *memoryStorage) StoreSession(_ context.Context, session *GameInstance) error {
 	gameId, err := uuid.Parse(session.GameId.Value)
 	if err != nil {
 		return err
 	}
-	return i.stor.Set(gameId.String(), session, i.ttl)
+	if err = i.stor.Set(gameId.String(), session, i.ttl); err != nil {
+		return err
+	}
+
+	i.idsLock.Lock()
+	i.ids[gameId] = struct{}{}
+	i.idsLock.Unlock()
+
+	i.notify(SessionChange{GameId: gameId, Origin: processId})
+	return nil
 }
 
-func (i *memoryStorage) GetSession(id uuid.UUID) (*GameInstance, error) {
+func (i *memoryStorage) GetSession(_ context.Context, id uuid.UUID) (*GameInstance, error) {
 	data, found := i.stor.Get(id.String())
 	if !found {
 		return nil, ErrSessionNotFound
@@ -35,12 +57,18 @@ func (i *memoryStorage) GetSession(id uuid.UUID) (*GameInstance, error) {
 	return data.(*GameInstance), nil
 }
 
-func (i *memoryStorage) CloseSession(id uuid.UUID) error {
+func (i *memoryStorage) CloseSession(_ context.Context, id uuid.UUID) error {
 	i.stor.Remove(id.String())
+
+	i.idsLock.Lock()
+	delete(i.ids, id)
+	i.idsLock.Unlock()
+
+	i.notify(SessionChange{GameId: id, Closed: true, Origin: processId})
 	return nil
 }
 
-func (i *memoryStorage) CheckExistence(id uuid.UUID) (bool, error) {
+func (i *memoryStorage) CheckExistence(_ context.Context, id uuid.UUID) (bool, error) {
 	_, found := i.stor.Get(id.String())
 	return found, nil
 }
@@ -61,6 +89,31 @@ func (i *memoryStorage) MissionVoteSuccess(id uuid.UUID) (bool, error) {
 	return game.(*GameInstance).State == api.GameSession_VIRTUOUS_TEAM_WON, nil
 }
 
-func (i *memoryStorage) NumberOfGames() (uint, error) {
+func (i *memoryStorage) NumberOfGames(_ context.Context) (uint, error) {
 	return uint(i.stor.Len()), nil
 }
+
+func (i *memoryStorage) ListSessions(_ context.Context, filter SessionFilter) ([]*GameInstance, error) {
+	i.idsLock.Lock()
+	matched := make([]*GameInstance, 0)
+	for id := range i.ids {
+		data, found := i.stor.Get(id.String())
+		if !found {
+			//Session expired
+			delete(i.ids, id)
+			continue
+		}
+		if game := data.(*GameInstance); filter.Match(game) {
+			matched = append(matched, game)
+		}
+	}
+	i.idsLock.Unlock()
+
+	sortSessionsByCreation(matched)
+
+	return filter.Paginate(matched), nil
+}
+
+func (i *memoryStorage) FindSessionsByPlayer(ctx context.Context, playerId uint64) ([]*GameInstance, error) {
+	return i.ListSessions(ctx, SessionFilter{PlayerId: playerId})
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/justmax437/avalonBacker/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	metricsNamespace     = "avalon"
+	metricsScrapeTimeout = 5 * time.Second
+	//Listing sessions is expensive, so several scrapers share counts for this long
+	activeSessionsCacheTTL = 15 * time.Second
+)
+
+var (
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "gRPC calls latency by method and status code.",
+	}, []string{"method", "code"})
+	rpcErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rpc_errors_total",
+		Help:      "gRPC calls finished with non-OK status by method and status code.",
+	}, []string{"method", "code"})
+
+	gamesStarted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "games_started_total",
+		Help:      "Game sessions created.",
+	})
+	gamesFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "games_finished_total",
+		Help:      "Game sessions that reached one of *_TEAM_WON states by winner.",
+	}, []string{"winner"})
+	//Average duration is rate(sum) / rate(count)
+	gameDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "game_duration_seconds",
+		Help:      "Time from session creation till one of the teams won.",
+		Buckets:   []float64{60, 300, 600, 900, 1200, 1800, 2700, 3600, 5400, 7200},
+	})
+	votesCast = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "votes_total",
+		Help:      "Accepted votes by kind (team or mission) and option.",
+	}, []string{"kind", "vote"})
+
+	storageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "storage_operation_duration_seconds",
+		Help:      "Session storage operations latency by backend and operation.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"backend", "operation", "result"})
+)
+
+// newMetricsRegistry registers all server metrics, session states and cache stats are read from sessions on scrape
+func newMetricsRegistry(sessions GameSessionStorage, logger *zap.Logger) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		rpcDuration, rpcErrors,
+		gamesStarted, gamesFinished, gameDuration, votesCast,
+		storageDuration,
+		newSessionsCollector(sessions, logger),
+	)
+	if cache := findSessionCache(sessions); cache != nil {
+		registry.MustRegister(&cacheCollector{cache: cache})
+	}
+	return registry
+}
+
+// serveMetrics exposes registry on /metrics of a separate HTTP listener
+func serveMetrics(addr string, registry *prometheus.Registry, logger *zap.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	logger.Info("serving metrics", zap.String("addr", addr))
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		logger.Error("metrics server stopped", zap.Error(err))
+	}
+}
+
+func metricsUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observeRPC(info.FullMethod, start, err)
+	return resp, err
+}
+
+func metricsStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observeRPC(info.FullMethod, start, err)
+	return err
+}
+
+func observeRPC(method string, start time.Time, err error) {
+	code := status.Code(err)
+	rpcDuration.WithLabelValues(method, code.String()).Observe(time.Since(start).Seconds())
+	if err != nil {
+		rpcErrors.WithLabelValues(method, code.String()).Inc()
+	}
+}
+
+func observeGameFinished(game *GameInstance) {
+	winner := "virtuous"
+	if game.State == api.GameSession_EVIL_TEAM_WON {
+		winner = "evil"
+	}
+	gamesFinished.WithLabelValues(winner).Inc()
+	if !game.CreatedAt.IsZero() {
+		gameDuration.Observe(time.Since(game.CreatedAt).Seconds())
+	}
+}
+
+func observeVote(kind string, vote api.VoteContext_VoteOption) {
+	votesCast.WithLabelValues(kind, vote.String()).Inc()
+}
+
+var activeSessionsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(metricsNamespace, "", "active_sessions"),
+	"Sessions that are not finished yet by game state.",
+	[]string{"state"}, nil,
+)
+
+// sessionsCollector counts active sessions in storage, so the numbers are right even when sessions are shared between replicas.
+// Counts are kept for activeSessionsCacheTTL, so frequent scrapes don't list sessions every time.
+type sessionsCollector struct {
+	sessions GameSessionStorage
+	logger   *zap.Logger
+
+	lock      sync.Mutex
+	counts    map[api.GameSession_GameState]int
+	countedAt time.Time
+}
+
+func newSessionsCollector(sessions GameSessionStorage, logger *zap.Logger) *sessionsCollector {
+	return &sessionsCollector{sessions: sessions, logger: logger}
+}
+
+func (c *sessionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeSessionsDesc
+}
+
+func (c *sessionsCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.activeCounts()
+	if err != nil {
+		c.logger.Error("failed to list sessions for metrics", zap.Error(err))
+		ch <- prometheus.NewInvalidMetric(activeSessionsDesc, err)
+		return
+	}
+
+	for _, state := range activeGameStates() {
+		ch <- prometheus.MustNewConstMetric(activeSessionsDesc, prometheus.GaugeValue, float64(counts[state]), state.String())
+	}
+}
+
+// activeCounts returns number of active sessions by state, listing sessions only if cached counts are stale
+func (c *sessionsCollector) activeCounts() (map[api.GameSession_GameState]int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.counts != nil && time.Since(c.countedAt) < activeSessionsCacheTTL {
+		return c.counts, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metricsScrapeTimeout)
+	defer cancel()
+
+	states := activeGameStates()
+	games, err := c.sessions.ListSessions(ctx, SessionFilter{States: states})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[api.GameSession_GameState]int, len(states))
+	for _, game := range games {
+		counts[game.State]++
+	}
+	c.counts, c.countedAt = counts, time.Now()
+	return counts, nil
+}
+
+var (
+	cacheHitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "session_cache", "hits_total"),
+		"Session cache hits.", nil, nil)
+	cacheMissesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "session_cache", "misses_total"),
+		"Session cache misses.", nil, nil)
+	cacheEvictionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "session_cache", "evictions_total"),
+		"Sessions evicted from cache to fit its size.", nil, nil)
+	cacheSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "session_cache", "size"),
+		"Sessions currently in cache.", nil, nil)
+)
+
+type cacheCollector struct {
+	cache interface{ Stats() CacheStats }
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheEvictionsDesc
+	ch <- cacheSizeDesc
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.cache.Stats()
+	ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(cacheEvictionsDesc, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(cacheSizeDesc, prometheus.GaugeValue, float64(stats.Size))
+}
+
+// findSessionCache looks for cache among storage decorators
+func findSessionCache(s GameSessionStorage) interface{ Stats() CacheStats } {
+	for {
+		if cache, ok := s.(interface{ Stats() CacheStats }); ok {
+			return cache
+		}
+		wrapper, ok := s.(interface{ Unwrap() GameSessionStorage })
+		if !ok {
+			return nil
+		}
+		s = wrapper.Unwrap()
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justmax437/avalonBacker/api"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestInstrumentedSessionStorageConformance(t *testing.T) {
+	testGameSessionStorage(t, func(t *testing.T) GameSessionStorage {
+		return NewInstrumentedSessionStorage(NewMemoryStorage(time.Minute), "test")
+	})
+}
+
+func TestSessionsCollector(t *testing.T) {
+	ctx := context.Background()
+	stor := NewMemoryStorage(time.Minute)
+	for _, state := range []api.GameSession_GameState{
+		api.GameSession_GAME_CREATED,
+		api.GameSession_MISSION_TEAM_VOTING,
+		api.GameSession_MISSION_TEAM_VOTING,
+		api.GameSession_EVIL_TEAM_WON,
+	} {
+		game := newTestGame(0, time.Now(), 1)
+		game.State = state
+		if err := stor.StoreSession(ctx, game); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := `
+# HELP avalon_active_sessions Sessions that are not finished yet by game state.
+# TYPE avalon_active_sessions gauge
+avalon_active_sessions{state="GAME_CREATED"} 1
+avalon_active_sessions{state="MISSION_ENDED"} 0
+avalon_active_sessions{state="MISSION_SUCCESS_VOTING"} 0
+avalon_active_sessions{state="MISSION_TEAM_PICKING"} 0
+avalon_active_sessions{state="MISSION_TEAM_VOTING"} 2
+avalon_active_sessions{state="POST_MISSIONS_ACTIONS"} 0
+`
+	collector := newSessionsCollector(stor, zaptest.NewLogger(t))
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	//Scrapes within cache ttl don't list sessions again
+	game := newTestGame(0, time.Now(), 1)
+	if err := stor.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+	collector.countedAt = time.Now().Add(-activeSessionsCacheTTL)
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(strings.Replace(expected, `{state="GAME_CREATED"} 1`, `{state="GAME_CREATED"} 2`, 1))); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRepeatedVoteMetrics(t *testing.T) {
+	ctx := context.Background()
+	stor := NewMemoryStorage(time.Minute)
+	service := NewGameService(stor, NewVoteStorage(zaptest.NewLogger(t)), zaptest.NewLogger(t))
+
+	game := fullTestGame()
+	game.State = api.GameSession_MISSION_SUCCESS_VOTING
+	game.MissionTeam.Members = game.AllPlayers[:2]
+	if err := stor.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+
+	votes := votesCast.WithLabelValues("mission", api.VoteContext_POSITIVE.String())
+	before := testutil.ToFloat64(votes)
+	vote := &api.VoteContext{Session: &game.GameSession, Voter: game.AllPlayers[0], Vote: api.VoteContext_POSITIVE}
+	for n := 0; n < 2; n++ {
+		if _, err := service.VoteForMissionSuccess(ctx, vote); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := testutil.ToFloat64(votes) - before; got != 1 {
+		t.Errorf("votes cast increased by %v; want repeated vote not to be counted", got)
+	}
+}
+
+func TestCacheCollector(t *testing.T) {
+	ctx := context.Background()
+	cache := newTestCache(t, NewInstrumentedSessionStorage(newTestBoltStorage(t), "bolt"), 10)
+
+	game := newTestGame(0, time.Now(), 1)
+	if err := cache.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetSession(ctx, testGameId(game.GameId)); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := newMetricsRegistry(cache, zaptest.NewLogger(t))
+	if n, err := testutil.GatherAndCount(registry, "avalon_session_cache_hits_total", "avalon_session_cache_size"); err != nil || n != 2 {
+		t.Errorf("GatherAndCount = %d, %v; want cache metrics to be registered", n, err)
+	}
+}
+
+func TestMetricsUnaryInterceptor(t *testing.T) {
+	const method = "/proto.GameService/TestMethod"
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	failing := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "no such game")
+	}
+
+	before := testutil.ToFloat64(rpcErrors.WithLabelValues(method, codes.NotFound.String()))
+	if _, err := metricsUnaryInterceptor(context.Background(), nil, info, failing); status.Code(err) != codes.NotFound {
+		t.Fatalf("interceptor changed handler error: %v", err)
+	}
+	if got := testutil.ToFloat64(rpcErrors.WithLabelValues(method, codes.NotFound.String())) - before; got != 1 {
+		t.Errorf("rpc errors increased by %v; want 1", got)
+	}
+}
+
+func TestGameFinishedMetrics(t *testing.T) {
+	ctx := context.Background()
+	stor := NewMemoryStorage(time.Minute)
+	service := NewGameService(stor, NewVoteStorage(zaptest.NewLogger(t)), zaptest.NewLogger(t))
+
+	game := fullTestGame()
+	game.State = api.GameSession_POST_MISSIONS_ACTIONS
+	game.MissionsPassed, game.MissionsFailed = 3, 2
+	game.CreatedAt = time.Now().Add(-time.Hour)
+	if err := stor.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+
+	before := testutil.ToFloat64(gamesFinished.WithLabelValues("evil"))
+	_, err := service.AssassinateAllegedMerlin(context.Background(), &api.AssassinationContext{
+		Session: &game.GameSession,
+		Target:  game.GoodTeam.Merlin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(gamesFinished.WithLabelValues("evil")) - before; got != 1 {
+		t.Errorf("evil wins increased by %v; want 1", got)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+// Migration upgrades stored data to its Version.
+// Migrations must be idempotent, since replicas starting at the same time could apply them concurrently.
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(ctx context.Context) error
+}
+
+// AppliedMigration is a record about migration that was already applied to the storage
+type AppliedMigration struct {
+	Version     int       `json:"version" bson:"_id"`
+	Description string    `json:"description" bson:"description"`
+	AppliedAt   time.Time `json:"applied_at" bson:"applied_at"`
+}
+
+// MigrationLog is implemented by every storage that needs migrations, to remember what was applied
+type MigrationLog interface {
+	AppliedMigrations(ctx context.Context) (map[int]AppliedMigration, error)
+	RecordMigration(ctx context.Context, applied AppliedMigration) error
+}
+
+// RunMigrations applies every migration not yet recorded in migrationLog in order of versions
+func RunMigrations(ctx context.Context, logger *zap.Logger, migrationLog MigrationLog, migrations []Migration) error {
+	ordered := make([]Migration, len(migrations))
+	copy(ordered, migrations)
+	sort.Slice(ordered, func(a, b int) bool {
+		return ordered[a].Version < ordered[b].Version
+	})
+	for n := 1; n < len(ordered); n++ {
+		if ordered[n].Version == ordered[n-1].Version {
+			return fmt.Errorf("duplicate migration version %d", ordered[n].Version)
+		}
+	}
+
+	applied, err := migrationLog.AppliedMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	for _, m := range ordered {
+		if _, done := applied[m.Version]; done {
+			continue
+		}
+
+		logger.Info("applying storage migration", zap.Int("version", m.Version), zap.String("description", m.Description))
+		if err = m.Apply(ctx); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+
+		err = migrationLog.RecordMigration(ctx, AppliedMigration{
+			Version:     m.Version,
+			Description: m.Description,
+			AppliedAt:   time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+	}
+	return nil
+}
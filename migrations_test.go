@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+)
+
+type memoryMigrationLog map[int]AppliedMigration
+
+func (l memoryMigrationLog) AppliedMigrations(_ context.Context) (map[int]AppliedMigration, error) {
+	return l, nil
+}
+
+func (l memoryMigrationLog) RecordMigration(_ context.Context, applied AppliedMigration) error {
+	l[applied.Version] = applied
+	return nil
+}
+
+func TestRunMigrations(t *testing.T) {
+	var order []int
+	migration := func(version int, err error) Migration {
+		return Migration{
+			Version:     version,
+			Description: "test",
+			Apply: func(context.Context) error {
+				order = append(order, version)
+				return err
+			},
+		}
+	}
+
+	migrationLog := memoryMigrationLog{1: {Version: 1}}
+	err := RunMigrations(context.Background(), zaptest.NewLogger(t), migrationLog, []Migration{
+		migration(3, nil), migration(1, nil), migration(2, nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(order, []int{2, 3}) {
+		t.Errorf("applied migrations %v; want [2 3]", order)
+	}
+	if _, recorded := migrationLog[3]; !recorded {
+		t.Error("applied migration was not recorded")
+	}
+
+	order = nil
+	failure := errors.New("boom")
+	err = RunMigrations(context.Background(), zaptest.NewLogger(t), migrationLog, []Migration{
+		migration(5, nil), migration(4, failure),
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("RunMigrations = %v; want migration error", err)
+	}
+	if _, recorded := migrationLog[4]; recorded || len(order) != 1 {
+		t.Error("failed migration must be neither recorded nor followed by later ones")
+	}
+
+	err = RunMigrations(context.Background(), zaptest.NewLogger(t), migrationLog, []Migration{migration(6, nil), migration(6, nil)})
+	if err == nil {
+		t.Error("duplicate versions were accepted")
+	}
+}
+
+func TestBoltStorageMigrationsAreRecorded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "avalon.db")
+	stor, err := NewBoltStorage(path, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = stor.Close()
+
+	//Reopening must not fail on already applied migrations
+	stor, err = NewBoltStorage(path, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stor.Close()
+
+	applied, err := stor.AppliedMigrations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range stor.boltMigrations() {
+		if _, done := applied[m.Version]; !done {
+			t.Errorf("migration %d is not recorded", m.Version)
+		}
+	}
+}
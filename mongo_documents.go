@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"github.com/justmax437/avalonBacker/api"
+	"time"
+)
+
+// mongoSchemaVersion is stored in every session document, bump it whenever
+// mongoSessionDocument layout changes and add a migration upgrading older documents
+const mongoSchemaVersion = 4
+
+// mongoSessionDocument is how GameInstance is persisted in mongo.
+// Mapping is explicit, so changes in avalonGame.proto don't silently change stored documents.
+type mongoSessionDocument struct {
+	Id            string `bson:"_id"` //Game UUID
+	SchemaVersion int    `bson:"schema_version"`
+
+	State             int32                        `bson:"state"`
+	EndgameReason     string                       `bson:"endgame_reason,omitempty"`
+	EndgameOutcome    *mongoEndgameOutcomeDocument `bson:"endgame_outcome,omitempty"`
+	Leader            *mongoPlayerDocument         `bson:"leader,omitempty"`
+	LastMissionResult *mongoMissionResultDocument  `bson:"last_mission_result,omitempty"`
+	LastTeamVote      *mongoTeamVoteResultDocument `bson:"last_team_vote,omitempty"`
+	MissionsPassed    int32                        `bson:"missions_passed"`
+	MissionsFailed    int32                        `bson:"missions_failed"`
+	ChatId            int64                        `bson:"chat_id,omitempty"`
+	ActiveChatId      int64                        `bson:"active_chat_id,omitempty"` //Only set while game is unfinished, unique index allows one such game per chat
+	Locale            string                       `bson:"locale"`                   //Same for session and config
+
+	Config              mongoConfigDocument   `bson:"config"`
+	MissionTeam         []mongoPlayerDocument `bson:"mission_team"`
+	MissionNumber       uint32                `bson:"mission_number"`
+	TeamPickingAttempts uint32                `bson:"team_picking_attempts"`
+	CurrentLeaderIndex  int                   `bson:"current_leader_index"`
+	AllPlayers          []mongoPlayerDocument `bson:"all_players"`
+	CreatedAt           time.Time             `bson:"created_at"`
+	Seed                int64                 `bson:"seed,omitempty"`        //Not set for sessions created before games were seeded
+	LastActivity        time.Time             `bson:"last_activity"`         //Updated on every store, used by TTL index and idle sessions sweeper
+	Origin              string                `bson:"origin,omitempty"`      //processId of the replica that stored the document, reported by change stream
+	ArchivedAt          *time.Time            `bson:"archived_at,omitempty"` //Only set in archive collection
+}
+
+// checkSchemaVersion refuses documents stored by newer versions of backend, fields they added would be lost
+func (doc *mongoSessionDocument) checkSchemaVersion() error {
+	if doc.SchemaVersion > mongoSchemaVersion {
+		return fmt.Errorf("session is stored with schema version %d, newer than supported %d", doc.SchemaVersion, mongoSchemaVersion)
+	}
+	return nil
+}
+
+type mongoPlayerDocument struct {
+	Id       uint64 `bson:"id"`
+	UserName string `bson:"user_name,omitempty"`
+	Bot      bool   `bson:"bot,omitempty"`
+}
+
+type mongoMissionResultDocument struct {
+	Failed        bool  `bson:"failed"`
+	PositiveVotes int32 `bson:"positive_votes"`
+	NegativeVotes int32 `bson:"negative_votes"`
+}
+
+type mongoTeamVoteResultDocument struct {
+	Approved    bool  `bson:"approved"`
+	Approvals   int32 `bson:"approvals"`
+	Rejections  int32 `bson:"rejections"`
+	Abstentions int32 `bson:"abstentions"`
+}
+
+type mongoEndgameOutcomeDocument struct {
+	Reason            int32                `bson:"reason"`
+	MissionsPassed    int32                `bson:"missions_passed"`
+	MissionsFailed    int32                `bson:"missions_failed"`
+	RejectedProposals uint32               `bson:"rejected_proposals,omitempty"`
+	Assassinated      *mongoPlayerDocument `bson:"assassinated,omitempty"`
+}
+
+type mongoConfigDocument struct {
+	GoodTeam   *mongoGoodTeamDocument   `bson:"good_team,omitempty"`
+	EvilTeam   *mongoEvilTeamDocument   `bson:"evil_team,omitempty"`
+	ChatId     int64                    `bson:"chat_id,omitempty"`
+	Rules      *mongoRuleSetDocument    `bson:"rules,omitempty"` //Not set for sessions created before rules were configurable
+	Extensions *mongoExtensionsDocument `bson:"extensions,omitempty"`
+}
+
+type mongoRuleSetDocument struct {
+	PlayerCounts         []mongoPlayerCountRulesDocument `bson:"player_counts"`
+	MaxRejectedProposals uint32                          `bson:"max_rejected_proposals"`
+}
+
+type mongoPlayerCountRulesDocument struct {
+	Players       uint32   `bson:"players"`
+	EvilPlayers   uint32   `bson:"evil_players"`
+	TeamSizes     []uint32 `bson:"team_sizes"`
+	FailsRequired []uint32 `bson:"fails_required"`
+}
+
+type mongoGoodTeamDocument struct {
+	Members  []mongoPlayerDocument `bson:"members"`
+	Merlin   *mongoPlayerDocument  `bson:"merlin,omitempty"`
+	Percival *mongoPlayerDocument  `bson:"percival,omitempty"`
+}
+
+type mongoEvilTeamDocument struct {
+	Members  []mongoPlayerDocument `bson:"members"`
+	Assassin *mongoPlayerDocument  `bson:"assassin,omitempty"`
+	Oberon   *mongoPlayerDocument  `bson:"oberon,omitempty"`
+	Morgana  *mongoPlayerDocument  `bson:"morgana,omitempty"`
+	Mordred  *mongoPlayerDocument  `bson:"mordred,omitempty"`
+}
+
+type mongoExtensionsDocument struct {
+	PercivalAndMorgana bool `bson:"percival_and_morgana"`
+	Oberon             bool `bson:"oberon"`
+	Mordred            bool `bson:"mordred"`
+}
+
+func newMongoSessionDocument(gi *GameInstance) *mongoSessionDocument {
+	doc := &mongoSessionDocument{
+		Id:                  gi.GameId.GetValue(),
+		SchemaVersion:       mongoSchemaVersion,
+		State:               int32(gi.State),
+		EndgameReason:       gi.EndgameReason,
+		Leader:              newMongoPlayerDocument(gi.Leader),
+		MissionsPassed:      gi.MissionsPassed,
+		MissionsFailed:      gi.MissionsFailed,
+		ChatId:              gi.GameSession.ChatId,
+		ActiveChatId:        mongoActiveChatId(gi),
+		Locale:              gi.GameSession.Locale,
+		MissionTeam:         newMongoPlayerDocuments(gi.MissionTeam.Members),
+		MissionNumber:       gi.Mission.MissionNumber,
+		TeamPickingAttempts: gi.Mission.TeamPickingAttempts,
+		CurrentLeaderIndex:  gi.CurrentLeaderIndex,
+		AllPlayers:          newMongoPlayerDocuments(gi.AllPlayers),
+		CreatedAt:           gi.CreatedAt,
+		Seed:                gi.Seed,
+		LastActivity:        time.Now().UTC(),
+		Origin:              processId,
+		Config: mongoConfigDocument{
+			ChatId: gi.GameConfig.ChatId,
+		},
+	}
+
+	if team := gi.GoodTeam; team != nil {
+		doc.Config.GoodTeam = &mongoGoodTeamDocument{
+			Members:  newMongoPlayerDocuments(team.Members),
+			Merlin:   newMongoPlayerDocument(team.Merlin),
+			Percival: newMongoPlayerDocument(team.Percival),
+		}
+	}
+	if team := gi.EvilTeam; team != nil {
+		doc.Config.EvilTeam = &mongoEvilTeamDocument{
+			Members:  newMongoPlayerDocuments(team.Members),
+			Assassin: newMongoPlayerDocument(team.Assassin),
+			Oberon:   newMongoPlayerDocument(team.Oberon),
+			Morgana:  newMongoPlayerDocument(team.Morgana),
+			Mordred:  newMongoPlayerDocument(team.Mordred),
+		}
+	}
+	if rules := gi.GameConfig.Rules; rules != nil {
+		doc.Config.Rules = &mongoRuleSetDocument{MaxRejectedProposals: rules.MaxRejectedProposals}
+		for _, r := range rules.PlayerCounts {
+			doc.Config.Rules.PlayerCounts = append(doc.Config.Rules.PlayerCounts, mongoPlayerCountRulesDocument{
+				Players:       r.Players,
+				EvilPlayers:   r.EvilPlayers,
+				TeamSizes:     r.TeamSizes,
+				FailsRequired: r.FailsRequired,
+			})
+		}
+	}
+	if ext := gi.Extensions; ext != nil {
+		doc.Config.Extensions = &mongoExtensionsDocument{
+			PercivalAndMorgana: ext.PercivalAndMorgana,
+			Oberon:             ext.Oberon,
+			Mordred:            ext.Mordred,
+		}
+	}
+
+	if outcome := gi.EndgameOutcome; outcome != nil {
+		doc.EndgameOutcome = &mongoEndgameOutcomeDocument{
+			Reason:            int32(outcome.Reason),
+			MissionsPassed:    outcome.MissionsPassed,
+			MissionsFailed:    outcome.MissionsFailed,
+			RejectedProposals: outcome.RejectedProposals,
+			Assassinated:      newMongoPlayerDocument(outcome.Assassinated),
+		}
+	}
+	if res := gi.LastMissionResult; res != nil {
+		doc.LastMissionResult = &mongoMissionResultDocument{
+			Failed:        res.Failed,
+			PositiveVotes: res.PositiveVotes,
+			NegativeVotes: res.NegativeVotes,
+		}
+	}
+	if res := gi.LastTeamVote; res != nil {
+		doc.LastTeamVote = &mongoTeamVoteResultDocument{
+			Approved:    res.Approved,
+			Approvals:   res.Approvals,
+			Rejections:  res.Rejections,
+			Abstentions: res.Abstentions,
+		}
+	}
+	return doc
+}
+
+func (doc *mongoSessionDocument) GameInstance() *GameInstance {
+	gi := new(GameInstance)
+	gi.GameId = &api.UUID{Value: doc.Id}
+	gi.State = api.GameSession_GameState(doc.State)
+	gi.EndgameReason = doc.EndgameReason
+	gi.Leader = doc.Leader.Player()
+	gi.MissionsPassed = doc.MissionsPassed
+	gi.MissionsFailed = doc.MissionsFailed
+	gi.GameSession.ChatId = doc.ChatId
+	gi.GameSession.Locale = doc.Locale
+	if outcome := doc.EndgameOutcome; outcome != nil {
+		gi.EndgameOutcome = &api.EndgameOutcome{
+			Reason:            api.EndgameOutcome_Reason(outcome.Reason),
+			MissionsPassed:    outcome.MissionsPassed,
+			MissionsFailed:    outcome.MissionsFailed,
+			RejectedProposals: outcome.RejectedProposals,
+			Assassinated:      outcome.Assassinated.Player(),
+		}
+	}
+	if res := doc.LastMissionResult; res != nil {
+		gi.LastMissionResult = &api.MissionResult{
+			Failed:        res.Failed,
+			PositiveVotes: res.PositiveVotes,
+			NegativeVotes: res.NegativeVotes,
+		}
+	}
+	if res := doc.LastTeamVote; res != nil {
+		gi.LastTeamVote = &api.TeamVoteResult{
+			Approved:    res.Approved,
+			Approvals:   res.Approvals,
+			Rejections:  res.Rejections,
+			Abstentions: res.Abstentions,
+		}
+	}
+
+	if team := doc.Config.GoodTeam; team != nil {
+		gi.GoodTeam = &api.VirtuousTeam{
+			Members:  mongoPlayers(team.Members),
+			Merlin:   team.Merlin.Player(),
+			Percival: team.Percival.Player(),
+		}
+	}
+	if team := doc.Config.EvilTeam; team != nil {
+		gi.EvilTeam = &api.EvilTeam{
+			Members:  mongoPlayers(team.Members),
+			Assassin: team.Assassin.Player(),
+			Oberon:   team.Oberon.Player(),
+			Morgana:  team.Morgana.Player(),
+			Mordred:  team.Mordred.Player(),
+		}
+	}
+	gi.GameConfig.ChatId = doc.Config.ChatId
+	gi.GameConfig.Locale = doc.Locale
+	if rules := doc.Config.Rules; rules != nil {
+		gi.GameConfig.Rules = &api.RuleSet{MaxRejectedProposals: rules.MaxRejectedProposals}
+		for _, r := range rules.PlayerCounts {
+			gi.GameConfig.Rules.PlayerCounts = append(gi.GameConfig.Rules.PlayerCounts, &api.PlayerCountRules{
+				Players:       r.Players,
+				EvilPlayers:   r.EvilPlayers,
+				TeamSizes:     r.TeamSizes,
+				FailsRequired: r.FailsRequired,
+			})
+		}
+	}
+	if ext := doc.Config.Extensions; ext != nil {
+		gi.Extensions = &api.GameExtensions{
+			PercivalAndMorgana: ext.PercivalAndMorgana,
+			Oberon:             ext.Oberon,
+			Mordred:            ext.Mordred,
+		}
+	}
+
+	gi.MissionTeam = api.MissionTeam{Members: mongoPlayers(doc.MissionTeam)}
+	gi.Mission = api.PendingMission{
+		MissionNumber:       doc.MissionNumber,
+		TeamPickingAttempts: doc.TeamPickingAttempts,
+	}
+	gi.CurrentLeaderIndex = doc.CurrentLeaderIndex
+	gi.AllPlayers = mongoPlayers(doc.AllPlayers)
+	gi.CreatedAt = doc.CreatedAt.UTC()
+	gi.Seed = doc.Seed
+	return gi
+}
+
+func mongoActiveChatId(gi *GameInstance) int64 {
+	if isGameOver(gi.State) {
+		return 0
+	}
+	return gi.GameSession.ChatId
+}
+
+func newMongoPlayerDocument(p *api.Player) *mongoPlayerDocument {
+	if p == nil {
+		return nil
+	}
+	return &mongoPlayerDocument{Id: p.Id, UserName: p.UserName, Bot: p.Bot}
+}
+
+func newMongoPlayerDocuments(players []*api.Player) []mongoPlayerDocument {
+	docs := make([]mongoPlayerDocument, 0, len(players))
+	for _, p := range players {
+		docs = append(docs, *newMongoPlayerDocument(p))
+	}
+	return docs
+}
+
+func (doc *mongoPlayerDocument) Player() *api.Player {
+	if doc == nil {
+		return nil
+	}
+	return &api.Player{Id: doc.Id, UserName: doc.UserName, Bot: doc.Bot}
+}
+
+func mongoPlayers(docs []mongoPlayerDocument) []*api.Player {
+	if len(docs) == 0 {
+		return nil
+	}
+	players := make([]*api.Player, 0, len(docs))
+	for n := range docs {
+		players = append(players, docs[n].Player())
+	}
+	return players
+}
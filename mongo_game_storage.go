@@ -2,148 +2,456 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	. "go.mongodb.org/mongo-driver/bson"
 	mgo "go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"log"
-	"os"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+	"go.uber.org/zap"
 	"time"
 )
 
 type mongoSessionStorage struct {
-	mClient *mgo.Client
-	mColl   *mgo.Collection
-}
-
-func NewMongoSessionStorage() *mongoSessionStorage {
-	mUser, mPass, mHost := os.Getenv("MONGO_USER"), os.Getenv("MONGO_PASS"), os.Getenv("MONGO_HOST")
-	mDBName := os.Getenv("MONGO_DBNAME")
-	mConnUrl := fmt.Sprintf(
-		"mongodb+srv://%s:%s@%s/%s?retryWrites=true&w=majority",
-		mUser,
-		mPass,
-		mHost,
-		mDBName,
-	)
+	mClient      *mgo.Client
+	mColl        *mgo.Collection
+	mArchiveColl *mgo.Collection //Idle sessions are moved here by sweeper if archiving is enabled
+	mLeaseColl   *mgo.Collection
+	mVoteColl    *mgo.Collection //Shared with mongoVoteStorage
+	logger       *zap.Logger
+}
+
+func NewMongoSessionStorage(cfg MongoConfig, logger *zap.Logger) (*mongoSessionStorage, error) {
+	mConnUrl := cfg.URI
+	if mConnUrl == "" {
+		mConnUrl = fmt.Sprintf(
+			"mongodb+srv://%s:%s@%s/%s?retryWrites=true&w=majority",
+			cfg.User,
+			cfg.Pass,
+			cfg.Host,
+			cfg.DBName,
+		)
+	}
 
 	mConnOpts := options.Client()
 	mConnOpts.ApplyURI(mConnUrl)
+	mConnOpts.SetMonitor(newMongoTracingMonitor())
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	mClient, err := mgo.Connect(ctx, mConnOpts)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("failed to create mongodb client: %w", err)
 	}
 
 	if err = mClient.Ping(ctx, nil); err != nil {
-		log.Fatal("failed to connect to mongodb: ", err)
+		return nil, fmt.Errorf("failed to connect to mongodb: %w", err)
 	}
 
-	return &mongoSessionStorage{
-		mClient: mClient,
-		mColl:   ensureCollectionAndIndexes(mClient),
+	mDB := mClient.Database(mongoDBName(cfg, mConnUrl))
+	stor := &mongoSessionStorage{
+		logger:       logger,
+		mClient:      mClient,
+		mColl:        mDB.Collection("avalonGames"),
+		mArchiveColl: mDB.Collection("avalonGamesArchive"),
+		mLeaseColl:   mDB.Collection("avalonLeases"),
+		mVoteColl:    mDB.Collection("avalonVotes"),
+	}
+
+	if err = stor.Migrate(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to migrate mongo storage: %w", err)
+	}
+	if err = ensureTTLIndexes(stor.mColl, stor.mArchiveColl, cfg.SessionTTL, cfg.ArchiveIdle); err != nil {
+		return nil, fmt.Errorf("failed to set up mongo ttl index: %w", err)
+	}
+	if err = ensureTTLIndex(stor.mVoteColl, "voted_at", cfg.SessionTTL); err != nil {
+		return nil, fmt.Errorf("failed to set up mongo votes ttl index: %w", err)
 	}
+
+	return stor, nil
+}
+
+// mongoDBName prefers explicitly configured database name over the one from connection URI
+func mongoDBName(cfg MongoConfig, connUrl string) string {
+	if cfg.DBName != "" {
+		return cfg.DBName
+	}
+	if cs, err := connstring.Parse(connUrl); err == nil && cs.Database != "" {
+		return cs.Database
+	}
+	return "avalon"
+}
+
+func (i *mongoSessionStorage) Ping(ctx context.Context) error {
+	return i.mClient.Ping(ctx, readpref.Primary())
 }
 
-func (i *mongoSessionStorage) StoreSession(session *GameInstance) error {
+// Disconnect closes connections to mongo, waiting for operations in progress until ctx is done
+func (i *mongoSessionStorage) Disconnect(ctx context.Context) error {
+	return i.mClient.Disconnect(ctx)
+}
+
+func (i *mongoSessionStorage) StoreSession(ctx context.Context, session *GameInstance) error {
 	gameId, err := uuid.Parse(session.GameId.Value)
 	if err != nil {
 		return err
 	}
 
-	_, err = i.mColl.UpdateOne(
-		context.Background(),
-		M{"game_id": M{"eq": gameId}}, //Update only game session with matching uuid
-		session,
-		options.Update().SetUpsert(true),
+	_, err = i.mColl.ReplaceOne(
+		ctx,
+		M{"_id": gameId.String()}, //Replace only game session with matching uuid
+		newMongoSessionDocument(session),
+		options.Replace().SetUpsert(true),
 	)
 	if err != nil {
-		log.Println("failed to store session in mongo: ", err)
+		i.logger.Error("failed to store session in mongo", gameIdField(gameId), stateField(session.State), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// CreateChatSession relies on unique index of active_chat_id, so the chat is claimed atomically across replicas
+func (i *mongoSessionStorage) CreateChatSession(ctx context.Context, session *GameInstance) error {
+	gameId, err := uuid.Parse(session.GameId.Value)
+	if err != nil {
 		return err
 	}
 
+	_, err = i.mColl.InsertOne(ctx, newMongoSessionDocument(session))
+	if isMongoDuplicateKeyError(err) {
+		return ErrChatAlreadyHasGame
+	}
+	if err != nil {
+		i.logger.Error("failed to store chat session in mongo", gameIdField(gameId), zap.Error(err))
+		return err
+	}
 	return nil
 }
 
-func (i *mongoSessionStorage) GetSession(id uuid.UUID) (*GameInstance, error) {
-	singleRes := i.mColl.FindOne(
-		context.Background(),
-		M{"game_id": M{"eq": id.String()}}, //Get only game session with matching uuid
+func isMongoDuplicateKeyError(err error) bool {
+	var writeErr mgo.WriteException
+	if !errors.As(err, &writeErr) {
+		return false
+	}
+	for _, e := range writeErr.WriteErrors {
+		if e.Code == 11000 {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *mongoSessionStorage) GetSession(ctx context.Context, id uuid.UUID) (*GameInstance, error) {
+	return i.findSession(
+		ctx,
+		M{"_id": id.String()}, //Get only game session with matching uuid
+		gameIdField(id),
 	)
+}
+
+// ActiveChatSession finds session by active_chat_id, which is only set while game is unfinished
+func (i *mongoSessionStorage) ActiveChatSession(ctx context.Context, chatId int64) (*GameInstance, error) {
+	game, err := i.findSession(ctx, M{"active_chat_id": chatId}, zap.Int64("chat_id", chatId))
+	if err == ErrSessionNotFound {
+		return nil, nil
+	}
+	return game, err
+}
+
+// findSession returns the only session matching filter, field describes the session in logs
+func (i *mongoSessionStorage) findSession(ctx context.Context, filter M, field zap.Field) (*GameInstance, error) {
+	singleRes := i.mColl.FindOne(ctx, filter)
 
 	if err := singleRes.Err(); err != nil {
-		log.Println("failed to fetch session data from mongo: ", err)
+		if err == mgo.ErrNoDocuments {
+			return nil, ErrSessionNotFound
+		}
+		i.logger.Error("failed to fetch session data from mongo", field, zap.Error(err))
 		return nil, err
 	}
 
-	ret := new(GameInstance)
-	err := singleRes.Decode(ret)
+	doc := new(mongoSessionDocument)
+	err := singleRes.Decode(doc)
 	if err != nil {
-		log.Println("failed to decode session data from mongo: ", err)
+		i.logger.Error("failed to decode session data from mongo", field, zap.Error(err))
+		return nil, err
+	}
+	if err = doc.checkSchemaVersion(); err != nil {
 		return nil, err
 	}
 
-	return ret, nil
+	return doc.GameInstance(), nil
 }
 
-func (i *mongoSessionStorage) CloseSession(id uuid.UUID) error {
+func (i *mongoSessionStorage) CloseSession(ctx context.Context, id uuid.UUID) error {
 	_, err := i.mColl.DeleteOne(
-		context.Background(),
-		M{"game_id": M{"eq": id.String()}}, //Delete only game session with matching uuid
+		ctx,
+		M{"_id": id.String()}, //Delete only game session with matching uuid
 	)
 
 	if err != nil {
-		log.Println("failed to delete session data from mongo: ", err)
+		i.logger.Error("failed to delete session data from mongo", gameIdField(id), zap.Error(err))
+		return err
 	}
-	return err
+	return nil
 }
 
-func (i *mongoSessionStorage) CheckExistence(id uuid.UUID) (bool, error) {
+func (i *mongoSessionStorage) CheckExistence(ctx context.Context, id uuid.UUID) (bool, error) {
 	n, err := i.mColl.CountDocuments(
-		context.Background(),
-		M{"game_id": M{"eq": id.String()}},
+		ctx,
+		M{"_id": id.String()},
+		options.Count().SetLimit(1),
 	)
 	return n > 0, err
 }
 
-func (i *mongoSessionStorage) NumberOfGames() (uint, error) {
+func (i *mongoSessionStorage) NumberOfGames(ctx context.Context) (uint, error) {
 	n, err := i.mColl.CountDocuments(
-		context.Background(),
+		ctx,
 		M{}, // Empty filter to count everything
 	)
 	return uint(n), err
 }
 
-func ensureCollectionAndIndexes(mClient *mgo.Client) *mgo.Collection {
-	mDB := mClient.Database(os.Getenv("MONGO_DBNAME"))
-	err := mDB.CreateCollection(context.Background(), "avalonGames")
+func (i *mongoSessionStorage) ListSessions(ctx context.Context, filter SessionFilter) ([]*GameInstance, error) {
+	query := M{}
+	if len(filter.States) > 0 {
+		query["state"] = M{"$in": filter.States}
+	}
+	if filter.PlayerId != 0 {
+		query["all_players.id"] = filter.PlayerId
+	}
+	if filter.ChatId != 0 {
+		query["chat_id"] = filter.ChatId
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query["created_at"] = M{"$gt": filter.CreatedAfter}
+	}
+
+	findOpts := options.Find().
+		SetSort(D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(filter.Offset))
+	if filter.Limit > 0 {
+		findOpts.SetLimit(int64(filter.Limit))
+	}
+
+	cur, err := i.mColl.Find(ctx, query, findOpts)
+	if err != nil {
+		i.logger.Error("failed to list sessions in mongo", zap.Error(err))
+		return nil, err
+	}
+
+	docs := make([]*mongoSessionDocument, 0)
+	if err = cur.All(ctx, &docs); err != nil {
+		i.logger.Error("failed to decode session list from mongo", zap.Error(err))
+		return nil, err
+	}
+
+	ret := make([]*GameInstance, 0, len(docs))
+	for _, doc := range docs {
+		if err = doc.checkSchemaVersion(); err != nil {
+			i.logger.Error("failed to read session list from mongo", zap.String("game_id", doc.Id), zap.Error(err))
+			return nil, err
+		}
+		ret = append(ret, doc.GameInstance())
+	}
+	return ret, nil
+}
+
+func (i *mongoSessionStorage) FindSessionsByPlayer(ctx context.Context, playerId uint64) ([]*GameInstance, error) {
+	return i.ListSessions(ctx, SessionFilter{PlayerId: playerId})
+}
+
+// mongoChangeEvent is the part of change stream event needed to report session change
+type mongoChangeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		Id string `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument struct {
+		Origin string `bson:"origin"`
+	} `bson:"fullDocument"` //Not set for deleted documents
+}
+
+// WatchSessions reports changes made by every replica, including sweepers and TTL index, through mongo change stream.
+// Change streams are only available with replica sets.
+func (i *mongoSessionStorage) WatchSessions(ctx context.Context) (<-chan SessionChange, error) {
+	pipeline := mgo.Pipeline{
+		{{Key: "$match", Value: M{"operationType": M{"$in": A{"insert", "update", "replace", "delete"}}}}},
+		{{Key: "$project", Value: M{"operationType": 1, "documentKey": 1, "fullDocument.origin": 1}}},
+	}
+	stream, err := i.mColl.Watch(ctx, pipeline)
 	if err != nil {
-		//Collection already exists?
-		if _, exist := err.(mgo.CommandError); exist {
-			//Yes, no need to create one
-			return mDB.Collection("avalonGames")
-		} else {
-			//No, its generic error
-			log.Fatal(err)
+		return nil, fmt.Errorf("failed to open mongo change stream: %w", err)
+	}
+
+	changes := make(chan SessionChange)
+	go func() {
+		defer close(changes)
+		defer stream.Close(context.Background())
+
+		for stream.Next(ctx) {
+			var event mongoChangeEvent
+			if err := stream.Decode(&event); err != nil {
+				i.logger.Warn("malformed mongo change event", zap.Error(err))
+				continue
+			}
+			gameId, err := uuid.Parse(event.DocumentKey.Id)
+			if err != nil {
+				continue
+			}
+
+			change := SessionChange{GameId: gameId, Closed: event.OperationType == "delete", Origin: event.FullDocument.Origin}
+			select {
+			case changes <- change:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			i.logger.Error("mongo change stream failed", zap.Error(err))
+		}
+	}()
+	return changes, nil
+}
+
+// RunIdleSessionsSweeper periodically terminates sessions without activity for idleTimeout,
+// moving them to archive collection first if archive is set. Blocks until ctx is done.
+func (i *mongoSessionStorage) RunIdleSessionsSweeper(ctx context.Context, interval, idleTimeout time.Duration, archive bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := i.sweepIdleSessions(ctx, time.Now().Add(-idleTimeout), archive)
+		if err != nil {
+			i.logger.Error("failed to sweep idle sessions", zap.Error(err))
+		} else if n > 0 {
+			i.logger.Info("terminated idle sessions", zap.Int("count", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 	}
+}
 
-	mColl := mDB.Collection("avalonGames")
-	_, err = mColl.Indexes().CreateOne(
-		context.Background(),
-		mgo.IndexModel{
-			Keys: M{"game_id": 1},
-			Options: options.Index().
-				SetUnique(true),
-		},
-	)
+// sweepIdleSessions terminates every session without activity since cutoff, returns number of terminated sessions
+func (i *mongoSessionStorage) sweepIdleSessions(ctx context.Context, cutoff time.Time, archive bool) (int, error) {
+	idleFilter := M{"last_activity": M{"$lt": cutoff}}
+	cur, err := i.mColl.Find(ctx, idleFilter, options.Find().SetProjection(M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
 
+	ids := make([]struct {
+		Id string `bson:"_id"`
+	}, 0)
+	if err = cur.All(ctx, &ids); err != nil {
+		return 0, err
+	}
+
+	terminated := 0
+	for _, idDoc := range ids {
+		//Session could've been updated since it was found, so activity is checked once again.
+		//Only the deleted document is archived, so archive never gets a stale copy of a live session.
+		doc := new(mongoSessionDocument)
+		err = i.mColl.FindOneAndDelete(ctx, M{"_id": idDoc.Id, "last_activity": M{"$lt": cutoff}}).Decode(doc)
+		if err == mgo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return terminated, fmt.Errorf("failed to delete idle session %s: %w", idDoc.Id, err)
+		}
+
+		terminated++
+		if archive {
+			archivedAt := time.Now().UTC()
+			doc.ArchivedAt = &archivedAt
+			_, err = i.mArchiveColl.ReplaceOne(ctx, M{"_id": doc.Id}, doc, options.Replace().SetUpsert(true))
+			if err != nil {
+				i.logger.Error("idle session was deleted, but not archived", zap.String("game_id", doc.Id), zap.Any("session", doc), zap.Error(err))
+				return terminated, fmt.Errorf("failed to archive session %s: %w", doc.Id, err)
+			}
+		}
+	}
+
+	return terminated, nil
+}
+
+const mongoActiveChatIndexName = "active_chat_id_unique"
+
+// ensureTTLIndexes expires sessions after ttl of inactivity.
+// If idle sessions are archived, it is archived sessions that expire, otherwise sessions would vanish without being archived.
+func ensureTTLIndexes(mColl, mArchiveColl *mgo.Collection, ttl time.Duration, archive bool) error {
+	sessionsTTL := ttl
+	if archive {
+		sessionsTTL = 0
+	}
+	if err := ensureTTLIndex(mColl, "last_activity", sessionsTTL); err != nil {
+		return err
+	}
+	return ensureTTLIndex(mArchiveColl, "archived_at", ttl)
+}
+
+// ensureTTLIndex creates, updates or drops index expiring documents by field
+func ensureTTLIndex(mColl *mgo.Collection, field string, ttl time.Duration) error {
+	ctx := context.Background()
+	indexName := field + "_ttl"
+	if ttl <= 0 {
+		_, err := mColl.Indexes().DropOne(ctx, indexName)
+		if cmdErr, isCmdErr := err.(mgo.CommandError); isCmdErr && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound") {
+			return nil
+		}
+		return err
+	}
+
+	expireAfter := int32(ttl.Seconds())
+	_, err := mColl.Indexes().CreateOne(ctx, mgo.IndexModel{
+		Keys: D{{Key: field, Value: 1}},
+		Options: options.Index().
+			SetName(indexName).
+			SetExpireAfterSeconds(expireAfter),
+	})
+	if cmdErr, isCmdErr := err.(mgo.CommandError); isCmdErr && cmdErr.Name == "IndexOptionsConflict" {
+		//Index exists with different expiration, update it in place
+		return mColl.Database().RunCommand(ctx, D{
+			{Key: "collMod", Value: mColl.Name()},
+			{Key: "index", Value: D{
+				{Key: "name", Value: indexName},
+				{Key: "expireAfterSeconds", Value: expireAfter},
+			}},
+		}).Err()
+	}
+	return err
+}
+
+// AcquireLease upserts lease document, which only matches for its holder or once lease has expired.
+// Otherwise upsert collides with lease of another holder on _id.
+func (i *mongoSessionStorage) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	_, err := i.mLeaseColl.UpdateOne(
+		ctx,
+		D{{Key: "_id", Value: name}, {Key: "$or", Value: A{
+			D{{Key: "holder", Value: holder}},
+			D{{Key: "expires_at", Value: D{{Key: "$lt", Value: now}}}},
+		}}},
+		D{{Key: "$set", Value: D{{Key: "holder", Value: holder}, {Key: "expires_at", Value: now.Add(ttl)}}}},
+		options.Update().SetUpsert(true),
+	)
+	if isMongoDuplicateKeyError(err) {
+		return false, nil
+	}
 	if err != nil {
-		log.Fatal("failed to create mongo indexes: ", err)
+		return false, err
 	}
+	return true, nil
+}
 
-	return mColl
+func (i *mongoSessionStorage) ReleaseLease(ctx context.Context, name, holder string) error {
+	_, err := i.mLeaseColl.DeleteOne(ctx, D{{Key: "_id", Value: name}, {Key: "holder", Value: holder}})
+	return err
 }
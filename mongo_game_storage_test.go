@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	"go.mongodb.org/mongo-driver/bson"
+	mgo "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMongoSessionDocumentRoundTrip(t *testing.T) {
+	game := fullTestGame()
+
+	data, err := bson.Marshal(newMongoSessionDocument(game))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := new(mongoSessionDocument)
+	if err = bson.Unmarshal(data, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.SchemaVersion != mongoSchemaVersion {
+		t.Errorf("schema version = %d; want %d", doc.SchemaVersion, mongoSchemaVersion)
+	}
+	if got := doc.GameInstance(); !reflect.DeepEqual(got, game) {
+		t.Errorf("decoded session differs from stored one:\n got: %+v\nwant: %+v", got, game)
+	}
+}
+
+// newTestMongoStorage connects to mongod at $AVALON_TEST_MONGO_URI using a throwaway database.
+// Mongo is started the way .github/workflows/test.yml does it, as a single node replica set for change streams.
+// Tests are skipped without mongo, except on CI, where skipping would hide the whole storage from tests.
+func newTestMongoStorage(t *testing.T) *mongoSessionStorage {
+	t.Helper()
+	uri, exist := os.LookupEnv("AVALON_TEST_MONGO_URI")
+	if !exist {
+		if os.Getenv("CI") != "" {
+			t.Fatal("$AVALON_TEST_MONGO_URI must be set on CI")
+		}
+		t.Skip("$AVALON_TEST_MONGO_URI is not set, skipping mongo integration test")
+	}
+
+	stor, err := NewMongoSessionStorage(MongoConfig{URI: uri, DBName: "avalon_test_" + uuid.New().String()[:8]}, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_ = stor.mColl.Database().Drop(ctx)
+		_ = stor.mClient.Disconnect(ctx)
+	})
+	return stor
+}
+
+func TestMongoSessionStorage(t *testing.T) {
+	ctx := context.Background()
+	stor := newTestMongoStorage(t)
+
+	game := fullTestGame()
+	other := newTestGame(-200, game.CreatedAt.Add(time.Second), 5, 6)
+	other.State = api.GameSession_EVIL_TEAM_WON
+	for _, g := range []*GameInstance{game, other} {
+		if err := stor.StoreSession(ctx, g); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	id := testGameId(game.GameId)
+	got, err := stor.GetSession(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, game) {
+		t.Errorf("fetched session differs from stored one:\n got: %+v\nwant: %+v", got, game)
+	}
+
+	//Storing again must replace the document, not add another one
+	game.State = api.GameSession_MISSION_ENDED
+	if err = stor.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = stor.GetSession(ctx, id); got.State != api.GameSession_MISSION_ENDED {
+		t.Errorf("state after update = %v; want MISSION_ENDED", got.State)
+	}
+	if n, err := stor.NumberOfGames(ctx); err != nil || n != 2 {
+		t.Errorf("NumberOfGames = %d, %v; want 2", n, err)
+	}
+
+	if exist, err := stor.CheckExistence(ctx, id); err != nil || !exist {
+		t.Errorf("CheckExistence = %v, %v; want true", exist, err)
+	}
+
+	byPlayer, err := stor.FindSessionsByPlayer(ctx, 5)
+	if err != nil || len(byPlayer) != 2 || byPlayer[0].GameId.Value != game.GameId.Value {
+		t.Errorf("FindSessionsByPlayer = %d sessions, %v; want both sessions oldest first", len(byPlayer), err)
+	}
+	active, err := stor.ListSessions(ctx, SessionFilter{States: activeGameStates(), ChatId: -100})
+	if err != nil || len(active) != 1 || active[0].GameId.Value != game.GameId.Value {
+		t.Errorf("ListSessions for active games in chat = %d sessions, %v; want 1", len(active), err)
+	}
+	page, err := stor.ListSessions(ctx, SessionFilter{Offset: 1, Limit: 5})
+	if err != nil || len(page) != 1 || page[0].GameId.Value != other.GameId.Value {
+		t.Errorf("paginated ListSessions = %d sessions, %v; want second session only", len(page), err)
+	}
+
+	if err = stor.CloseSession(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stor.GetSession(ctx, id); err != ErrSessionNotFound {
+		t.Errorf("GetSession after CloseSession = %v; want ErrSessionNotFound", err)
+	}
+	if exist, _ := stor.CheckExistence(ctx, id); exist {
+		t.Error("session exists after CloseSession")
+	}
+}
+
+func TestMongoSessionStorageSingleChatGame(t *testing.T) {
+	stor := newTestMongoStorage(t)
+	ctx := context.Background()
+
+	first := newTestGame(-100, time.Now(), 1, 2)
+	if err := stor.CreateChatSession(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	second := newTestGame(-100, time.Now(), 3, 4)
+	if err := stor.CreateChatSession(ctx, second); err != ErrChatAlreadyHasGame {
+		t.Fatalf("second game in chat = %v; want %v", err, ErrChatAlreadyHasGame)
+	}
+	if active, err := stor.ActiveChatSession(ctx, -100); err != nil || active.GetGameId().GetValue() != first.GameId.Value {
+		t.Errorf("active game of chat = %v, %v; want %s", active, err, first.GameId.Value)
+	}
+
+	//Finished game releases the chat
+	first.State = api.GameSession_EVIL_TEAM_WON
+	if err := stor.StoreSession(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if active, err := stor.ActiveChatSession(ctx, -100); err != nil || active != nil {
+		t.Errorf("active game of chat after it finished = %v, %v; want none", active, err)
+	}
+	if err := stor.CreateChatSession(ctx, second); err != nil {
+		t.Errorf("game in chat after previous one finished: %v", err)
+	}
+}
+
+func TestMongoSessionStorageSweepIdle(t *testing.T) {
+	stor := newTestMongoStorage(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	changes, err := stor.WatchSessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game := fullTestGame()
+	if err = stor.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+	<-changes
+
+	//Nothing is idle yet
+	if n, err := stor.sweepIdleSessions(ctx, time.Now().Add(-time.Hour), true); err != nil || n != 0 {
+		t.Fatalf("sweepIdleSessions = %d, %v; want nothing swept", n, err)
+	}
+	if archived, _ := stor.mArchiveColl.CountDocuments(ctx, bson.M{}); archived != 0 {
+		t.Errorf("%d live sessions were archived", archived)
+	}
+
+	n, err := stor.sweepIdleSessions(ctx, time.Now().Add(time.Second), true)
+	if err != nil || n != 1 {
+		t.Fatalf("sweepIdleSessions = %d, %v; want 1", n, err)
+	}
+	if exist, _ := stor.CheckExistence(ctx, testGameId(game.GameId)); exist {
+		t.Error("idle session was not removed")
+	}
+	if archived, _ := stor.mArchiveColl.CountDocuments(ctx, bson.M{"_id": game.GameId.Value}); archived != 1 {
+		t.Error("idle session was not archived")
+	}
+
+	select {
+	case change := <-changes:
+		if !change.Closed || change.GameId != testGameId(game.GameId) {
+			t.Errorf("got change %+v; want session closed", change)
+		}
+	case <-ctx.Done():
+		t.Error("watchers were not notified about swept session")
+	}
+}
+
+func TestMongoSessionStorageWatchOtherClient(t *testing.T) {
+	stor := newTestMongoStorage(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	//Changes come from the database, so they are seen no matter which client made them
+	other, err := NewMongoSessionStorage(MongoConfig{URI: os.Getenv("AVALON_TEST_MONGO_URI"), DBName: stor.mColl.Database().Name()}, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.mClient.Disconnect(context.Background())
+
+	changes, err := stor.WatchSessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game := fullTestGame()
+	if err = other.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+	if err = other.CloseSession(ctx, testGameId(game.GameId)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []SessionChange{
+		{GameId: testGameId(game.GameId), Origin: processId},
+		{GameId: testGameId(game.GameId), Closed: true},
+	} {
+		select {
+		case change := <-changes:
+			if change != want {
+				t.Errorf("got change %+v; want %+v", change, want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("change %+v was not reported", want)
+		}
+	}
+}
+
+func TestMongoTTLIndexes(t *testing.T) {
+	stor := newTestMongoStorage(t)
+	ctx := context.Background()
+
+	ttlIndexes := func(coll *mgo.Collection) map[string]bool {
+		t.Helper()
+		cur, err := coll.Indexes().List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var specs []bson.M
+		if err = cur.All(ctx, &specs); err != nil {
+			t.Fatal(err)
+		}
+		names := make(map[string]bool)
+		for _, spec := range specs {
+			if _, ttl := spec["expireAfterSeconds"]; ttl {
+				names[spec["name"].(string)] = true
+			}
+		}
+		return names
+	}
+
+	if err := ensureTTLIndexes(stor.mColl, stor.mArchiveColl, time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+	if !ttlIndexes(stor.mColl)["last_activity_ttl"] || !ttlIndexes(stor.mArchiveColl)["archived_at_ttl"] {
+		t.Error("sessions must expire by last activity without archiving")
+	}
+
+	//Sessions must not expire before sweeper archives them
+	if err := ensureTTLIndexes(stor.mColl, stor.mArchiveColl, time.Hour, true); err != nil {
+		t.Fatal(err)
+	}
+	if ttlIndexes(stor.mColl)["last_activity_ttl"] || !ttlIndexes(stor.mArchiveColl)["archived_at_ttl"] {
+		t.Error("only archived sessions must expire with archiving enabled")
+	}
+}
+
+func TestMongoSessionStorageUpgradesOldDocuments(t *testing.T) {
+	stor := newTestMongoStorage(t)
+	ctx := context.Background()
+
+	game := fullTestGame()
+	doc := newMongoSessionDocument(game)
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := bson.M{}
+	if err = bson.Unmarshal(raw, &legacy); err != nil {
+		t.Fatal(err)
+	}
+	//Documents of schema version 1 had no activity tracking, locale and chat claims
+	delete(legacy, "last_activity")
+	delete(legacy, "locale")
+	delete(legacy, "active_chat_id")
+	legacy["schema_version"] = 1
+	if _, err = stor.mColl.InsertOne(ctx, legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range stor.mongoMigrations()[1:] {
+		if err = m.Apply(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	upgraded := new(mongoSessionDocument)
+	if err = stor.mColl.FindOne(ctx, bson.M{"_id": doc.Id}).Decode(upgraded); err != nil {
+		t.Fatal(err)
+	}
+	if upgraded.SchemaVersion != mongoSchemaVersion || !upgraded.LastActivity.Equal(game.CreatedAt) || upgraded.Locale != localeRussian || upgraded.ActiveChatId != game.GameSession.ChatId {
+		t.Errorf("document was not upgraded: version %d, last activity %v, locale %q, active chat %d",
+			upgraded.SchemaVersion, upgraded.LastActivity, upgraded.Locale, upgraded.ActiveChatId)
+	}
+
+	applied, err := stor.AppliedMigrations(ctx)
+	if err != nil || len(applied) != len(stor.mongoMigrations()) {
+		t.Errorf("AppliedMigrations = %d records, %v; want every migration recorded", len(applied), err)
+	}
+}
+
+func TestMongoVoteStorage(t *testing.T) {
+	ctx := context.Background()
+	stor := newTestMongoStorage(t)
+	//Every replica has its own vote storage over the same database
+	votes := NewMongoVoteStorage(stor, zaptest.NewLogger(t))
+	otherReplica := NewMongoVoteStorage(stor, zaptest.NewLogger(t))
+	gameId := uuid.New()
+
+	mustVote := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustVote(votes.AddTeamVote(ctx, gameId, &api.Player{Id: 1}, api.VoteContext_POSITIVE))
+	mustVote(otherReplica.AddTeamVote(ctx, gameId, &api.Player{Id: 2}, api.VoteContext_POSITIVE))
+	mustVote(votes.AddTeamVote(ctx, gameId, &api.Player{Id: 3}, api.VoteContext_NEGATIVE))
+	mustVote(otherReplica.AddTeamVote(ctx, gameId, &api.Player{Id: 4}, api.VoteContext_ABSTAIN))
+	if err := otherReplica.AddTeamVote(ctx, gameId, &api.Player{Id: 1}, api.VoteContext_NEGATIVE); err != ErrRepeatedVote {
+		t.Fatalf("repeated vote = %v; want %v", err, ErrRepeatedVote)
+	}
+
+	teamVotes, err := otherReplica.GetTeamVotes(ctx, gameId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &TeamVotes{Approvals: map[uint64]bool{1: true, 2: true}, Rejections: map[uint64]bool{3: true}, Abstentions: map[uint64]bool{4: true}}
+	if !reflect.DeepEqual(teamVotes, want) {
+		t.Errorf("GetTeamVotes = %+v; want %+v", teamVotes, want)
+	}
+
+	mustVote(votes.AddPositiveMissionVote(ctx, gameId, &api.Player{Id: 1}))
+	mustVote(otherReplica.AddNegativeMissionVote(ctx, gameId, &api.Player{Id: 2}))
+	if n, _ := otherReplica.NumberOfPlayersVotedForMission(ctx, gameId); n != 2 {
+		t.Errorf("NumberOfPlayersVotedForMission = %d; want 2", n)
+	}
+	if c, _ := votes.GetMissionVotesCountForGame(ctx, gameId); c != 1 {
+		t.Errorf("GetMissionVotesCountForGame = %d; want 1", c)
+	}
+
+	mustVote(otherReplica.ResetVotes(ctx, gameId))
+	if teamVotes, _ = votes.GetTeamVotes(ctx, gameId); len(teamVotes.Approvals)+len(teamVotes.Rejections)+len(teamVotes.Abstentions) != 0 {
+		t.Errorf("team votes after reset = %+v; want none", teamVotes)
+	}
+	if n, _ := votes.NumberOfPlayersVotedForMission(ctx, gameId); n != 0 {
+		t.Errorf("NumberOfPlayersVotedForMission after reset = %d; want 0", n)
+	}
+}
+
+func TestMongoMigrationClaimsChatOnce(t *testing.T) {
+	stor := newTestMongoStorage(t)
+	ctx := context.Background()
+
+	//Both sessions were created in the same chat before chats were claimed
+	for _, game := range []*GameInstance{newTestGame(-100, time.Now(), 1, 2), newTestGame(-100, time.Now(), 3, 4)} {
+		raw, err := bson.Marshal(newMongoSessionDocument(game))
+		if err != nil {
+			t.Fatal(err)
+		}
+		legacy := bson.M{}
+		if err = bson.Unmarshal(raw, &legacy); err != nil {
+			t.Fatal(err)
+		}
+		delete(legacy, "active_chat_id")
+		legacy["schema_version"] = 3
+		if _, err = stor.mColl.InsertOne(ctx, legacy); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//Replicas starting at the same time migrate concurrently
+	claimChats := stor.mongoMigrations()[3]
+	errs := make(chan error, 2)
+	for n := 0; n < cap(errs); n++ {
+		go func() { errs <- claimChats.Apply(ctx) }()
+	}
+	for n := 0; n < cap(errs); n++ {
+		if err := <-errs; err != nil {
+			t.Errorf("concurrent migration failed: %v", err)
+		}
+	}
+	if n, err := stor.mColl.CountDocuments(ctx, bson.M{"active_chat_id": -100}); err != nil || n != 1 {
+		t.Errorf("%d sessions claimed the chat, %v; want 1", n, err)
+	}
+}
+
+func TestMongoListSessionsChecksSchemaVersion(t *testing.T) {
+	stor := newTestMongoStorage(t)
+	ctx := context.Background()
+
+	doc := newMongoSessionDocument(newTestGame(0, time.Now(), 1))
+	doc.SchemaVersion = mongoSchemaVersion + 1
+	if _, err := stor.mColl.InsertOne(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stor.ListSessions(ctx, SessionFilter{}); err == nil {
+		t.Error("session of newer schema version was listed")
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	. "go.mongodb.org/mongo-driver/bson"
+	mgo "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoMigrations upgrade sessions collection, append new migrations to the end with the next version.
+// Migrations that change mongoSessionDocument layout must bump mongoSchemaVersion and use upgradeMongoDocuments.
+func (i *mongoSessionStorage) mongoMigrations() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "create sessions collection and query indexes",
+			Apply: func(ctx context.Context) error {
+				err := i.mColl.Database().CreateCollection(ctx, i.mColl.Name())
+				if cmdErr, isCmdErr := err.(mgo.CommandError); err != nil && !(isCmdErr && cmdErr.Name == "NamespaceExists") {
+					return fmt.Errorf("failed to create mongo collection: %w", err)
+				}
+
+				//Game UUID is stored in _id, which is always unique and indexed
+				_, err = i.mColl.Indexes().CreateMany(ctx, []mgo.IndexModel{
+					{Keys: D{{Key: "all_players.id", Value: 1}, {Key: "created_at", Value: 1}}},
+					{Keys: D{{Key: "state", Value: 1}, {Key: "created_at", Value: 1}}},
+					{Keys: D{{Key: "chat_id", Value: 1}, {Key: "state", Value: 1}}},
+				})
+				return err
+			},
+		},
+		{
+			Version:     2,
+			Description: "backfill last_activity of sessions stored before it was tracked",
+			Apply: func(ctx context.Context) error {
+				return upgradeMongoDocuments(ctx, i.mColl, 1, func(doc M) error {
+					if _, exist := doc["last_activity"]; !exist {
+						doc["last_activity"] = doc["created_at"]
+					}
+					return nil
+				})
+			},
+		},
+		{
+			Version:     3,
+			Description: "set locale of sessions created when every text was in russian",
+			Apply: func(ctx context.Context) error {
+				return upgradeMongoDocuments(ctx, i.mColl, 2, func(doc M) error {
+					if _, exist := doc["locale"]; !exist {
+						doc["locale"] = localeRussian
+					}
+					return nil
+				})
+			},
+		},
+		{
+			Version:     4,
+			Description: "allow single unfinished session per chat with unique index",
+			Apply: func(ctx context.Context) error {
+				//Index goes first, so chats are claimed through it even by replicas migrating concurrently
+				_, err := i.mColl.Indexes().CreateOne(ctx, mgo.IndexModel{
+					Keys: D{{Key: "active_chat_id", Value: 1}},
+					Options: options.Index().
+						SetName(mongoActiveChatIndexName).
+						SetUnique(true).
+						SetPartialFilterExpression(M{"active_chat_id": M{"$exists": true}}),
+				})
+				if err != nil {
+					return err
+				}
+				err = upgradeMongoDocuments(ctx, i.mColl, 3, func(doc M) error { return nil })
+				if err != nil {
+					return err
+				}
+				return i.claimActiveChats(ctx)
+			},
+		},
+		{
+			Version:     5,
+			Description: "index votes by game and round",
+			Apply: func(ctx context.Context) error {
+				_, err := i.mVoteColl.Indexes().CreateOne(ctx, mgo.IndexModel{
+					Keys: D{{Key: "game_id", Value: 1}, {Key: "round", Value: 1}, {Key: "vote", Value: 1}},
+				})
+				return err
+			},
+		},
+	}
+}
+
+// claimActiveChats binds unfinished sessions to their chats.
+// Chats could already have several unfinished sessions, only the first one claimed keeps the chat,
+// unique index rejects the others. They fail to store until that one is finished.
+func (i *mongoSessionStorage) claimActiveChats(ctx context.Context) error {
+	cur, err := i.mColl.Find(ctx, M{
+		"chat_id":        M{"$exists": true},
+		"active_chat_id": M{"$exists": false},
+		"state":          M{"$in": activeGameStates()},
+	})
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var doc struct {
+			Id     string `bson:"_id"`
+			ChatId int64  `bson:"chat_id"`
+		}
+		if err = cur.Decode(&doc); err != nil {
+			return err
+		}
+		//Session could've been finished or claimed concurrently, leave it be then
+		_, err = i.mColl.UpdateOne(
+			ctx,
+			M{"_id": doc.Id, "active_chat_id": M{"$exists": false}, "state": M{"$in": activeGameStates()}},
+			M{"$set": M{"active_chat_id": doc.ChatId}},
+		)
+		if err != nil && !isMongoDuplicateKeyError(err) {
+			return fmt.Errorf("failed to claim chat %d for session %s: %w", doc.ChatId, doc.Id, err)
+		}
+	}
+	return cur.Err()
+}
+
+// upgradeMongoDocuments rewrites every document of fromVersion schema with upgrade
+// and marks it with the next schema version
+func upgradeMongoDocuments(ctx context.Context, coll *mgo.Collection, fromVersion int, upgrade func(doc M) error) error {
+	cur, err := coll.Find(ctx, M{"schema_version": fromVersion})
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		doc := M{}
+		if err = cur.Decode(&doc); err != nil {
+			return err
+		}
+		if err = upgrade(doc); err != nil {
+			return fmt.Errorf("failed to upgrade document %v: %w", doc["_id"], err)
+		}
+		doc["schema_version"] = fromVersion + 1
+
+		//Document could've been upgraded or replaced concurrently, leave it be then
+		_, err = coll.ReplaceOne(ctx, M{"_id": doc["_id"], "schema_version": fromVersion}, doc)
+		if err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+func (i *mongoSessionStorage) migrationsColl() *mgo.Collection {
+	return i.mColl.Database().Collection("schemaMigrations")
+}
+
+func (i *mongoSessionStorage) AppliedMigrations(ctx context.Context) (map[int]AppliedMigration, error) {
+	cur, err := i.migrationsColl().Find(ctx, M{})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]AppliedMigration, 0)
+	if err = cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]AppliedMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func (i *mongoSessionStorage) RecordMigration(ctx context.Context, applied AppliedMigration) error {
+	_, err := i.migrationsColl().ReplaceOne(ctx, M{"_id": applied.Version}, applied, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (i *mongoSessionStorage) Migrate(ctx context.Context) error {
+	return RunMigrations(ctx, i.logger, i, i.mongoMigrations())
+}
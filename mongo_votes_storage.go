@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	. "go.mongodb.org/mongo-driver/bson"
+	mgo "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+const (
+	mongoMissionVote = "mission"
+	mongoTeamVote    = "team"
+)
+
+// mongoVoteDocument is a single vote, _id makes every player vote once per round of voting
+type mongoVoteDocument struct {
+	Id       string    `bson:"_id"`
+	GameId   string    `bson:"game_id"`
+	Round    string    `bson:"round"` //mongoMissionVote or mongoTeamVote
+	PlayerId uint64    `bson:"player_id"`
+	Vote     int32     `bson:"vote"`     //api.VoteContext_VoteOption
+	VotedAt  time.Time `bson:"voted_at"` //Votes of abandoned games expire by TTL index along with sessions
+}
+
+// mongoVoteStorage keeps votes in the database of sessions, so every replica sees the same votes
+type mongoVoteStorage struct {
+	mColl  *mgo.Collection
+	logger *zap.Logger
+}
+
+// NewMongoVoteStorage keeps votes in votes collection of sessions storage database
+func NewMongoVoteStorage(sessions *mongoSessionStorage, logger *zap.Logger) VoteStorage {
+	return &mongoVoteStorage{mColl: sessions.mVoteColl, logger: logger}
+}
+
+func (v *mongoVoteStorage) recordVote(ctx context.Context, round string, gameId uuid.UUID, player *api.Player, vote api.VoteContext_VoteOption) error {
+	_, err := v.mColl.InsertOne(ctx, mongoVoteDocument{
+		Id:       gameId.String() + ":" + round + ":" + strconv.FormatUint(player.Id, 10),
+		GameId:   gameId.String(),
+		Round:    round,
+		PlayerId: player.Id,
+		Vote:     int32(vote),
+		VotedAt:  time.Now().UTC(),
+	})
+	if isMongoDuplicateKeyError(err) {
+		v.logger.Info("repeated vote attempt", gameIdField(gameId), playerIdField(player))
+		return ErrRepeatedVote
+	}
+	if err != nil {
+		v.logger.Error("failed to store vote in mongo", gameIdField(gameId), playerIdField(player), zap.Error(err))
+	}
+	return err
+}
+
+func (v *mongoVoteStorage) findVotes(ctx context.Context, round string, gameId uuid.UUID) ([]mongoVoteDocument, error) {
+	cur, err := v.mColl.Find(ctx, M{"game_id": gameId.String(), "round": round})
+	if err != nil {
+		v.logger.Error("failed to read votes from mongo", gameIdField(gameId), zap.Error(err))
+		return nil, err
+	}
+	votes := make([]mongoVoteDocument, 0)
+	if err = cur.All(ctx, &votes); err != nil {
+		v.logger.Error("failed to decode votes from mongo", gameIdField(gameId), zap.Error(err))
+		return nil, err
+	}
+	return votes, nil
+}
+
+func (v *mongoVoteStorage) AddPositiveMissionVote(ctx context.Context, gameId uuid.UUID, player *api.Player) error {
+	return v.recordVote(ctx, mongoMissionVote, gameId, player, api.VoteContext_POSITIVE)
+}
+
+func (v *mongoVoteStorage) AddNegativeMissionVote(ctx context.Context, gameId uuid.UUID, player *api.Player) error {
+	return v.recordVote(ctx, mongoMissionVote, gameId, player, api.VoteContext_NEGATIVE)
+}
+
+func (v *mongoVoteStorage) GetMissionVotesCountForGame(ctx context.Context, id uuid.UUID) (int8, error) {
+	n, err := v.mColl.CountDocuments(ctx, M{"game_id": id.String(), "round": mongoMissionVote, "vote": int32(api.VoteContext_POSITIVE)})
+	return int8(n), err
+}
+
+func (v *mongoVoteStorage) NumberOfPlayersVotedForMission(ctx context.Context, id uuid.UUID) (int, error) {
+	n, err := v.mColl.CountDocuments(ctx, M{"game_id": id.String(), "round": mongoMissionVote})
+	return int(n), err
+}
+
+func (v *mongoVoteStorage) AddTeamVote(ctx context.Context, gameId uuid.UUID, player *api.Player, vote api.VoteContext_VoteOption) error {
+	return v.recordVote(ctx, mongoTeamVote, gameId, player, vote)
+}
+
+func (v *mongoVoteStorage) GetTeamVotes(ctx context.Context, id uuid.UUID) (*TeamVotes, error) {
+	stored, err := v.findVotes(ctx, mongoTeamVote, id)
+	if err != nil {
+		return nil, err
+	}
+
+	votes := newTeamVotes()
+	for _, vote := range stored {
+		if !votes.add(vote.PlayerId, api.VoteContext_VoteOption(vote.Vote)) {
+			return nil, fmt.Errorf("malformed team vote %d of player %d", vote.Vote, vote.PlayerId)
+		}
+	}
+	return votes, nil
+}
+
+func (v *mongoVoteStorage) ResetVotes(ctx context.Context, gameId uuid.UUID) error {
+	_, err := v.mColl.DeleteMany(ctx, M{"game_id": gameId.String()})
+	if err != nil {
+		v.logger.Error("failed to reset votes in mongo", gameIdField(gameId), zap.Error(err))
+	}
+	return err
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+const (
+	redisKeyPrefix             = "avalon:"
+	redisSessionsIndexKey      = redisKeyPrefix + "sessions" //Sorted set of game ids, scored by creation time
+	redisSessionChangesChannel = redisKeyPrefix + "session-changes"
+)
+
+func redisSessionKey(id uuid.UUID) string {
+	return redisKeyPrefix + "session:" + id.String()
+}
+
+// redisSessionKeys makes keys of sessions listed in the sessions index
+func redisSessionKeys(ids []string) []string {
+	keys := make([]string, len(ids))
+	for n, id := range ids {
+		keys[n] = redisKeyPrefix + "session:" + id
+	}
+	return keys
+}
+
+// redisChatKey holds id of the latest game created in chat
+func redisChatKey(chatId int64) string {
+	return redisKeyPrefix + "chat:" + strconv.FormatInt(chatId, 10)
+}
+
+// redisSessionStorage keeps sessions as JSON documents that expire after ttl of inactivity,
+// so it could be shared between several backend replicas
+type redisSessionStorage struct {
+	rdb    *redis.Client
+	ttl    time.Duration
+	logger *zap.Logger
+}
+
+func NewRedisSessionStorage(rdb *redis.Client, ttl time.Duration, logger *zap.Logger) *redisSessionStorage {
+	return &redisSessionStorage{rdb: rdb, ttl: ttl, logger: logger}
+}
+
+func (i *redisSessionStorage) Ping(ctx context.Context) error {
+	return i.rdb.Ping(ctx).Err()
+}
+
+// Close closes redis client, that is shared with vote storage
+func (i *redisSessionStorage) Close() error {
+	return i.rdb.Close()
+}
+
+func (i *redisSessionStorage) StoreSession(ctx context.Context, session *GameInstance) error {
+	gameId, err := uuid.Parse(session.GameId.Value)
+	if err != nil {
+		return err
+	}
+
+	_, err = i.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return i.queueStore(ctx, pipe, gameId, session)
+	})
+	if err != nil {
+		i.logger.Error("failed to store session in redis", gameIdField(gameId), stateField(session.State), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// queueStore adds commands storing session to transaction
+func (i *redisSessionStorage) queueStore(ctx context.Context, pipe redis.Pipeliner, gameId uuid.UUID, session *GameInstance) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	change, err := json.Marshal(SessionChange{GameId: gameId, Origin: processId})
+	if err != nil {
+		return err
+	}
+
+	pipe.Set(ctx, redisSessionKey(gameId), data, i.ttl)
+	pipe.ZAdd(ctx, redisSessionsIndexKey, &redis.Z{
+		Score:  float64(session.CreatedAt.UnixNano()),
+		Member: gameId.String(),
+	})
+	pipe.Publish(ctx, redisSessionChangesChannel, change)
+	return nil
+}
+
+// CreateChatSession takes chat over from its previous game, unless that game is still active.
+// Chat key is watched, so only one of concurrent calls for the same chat succeeds.
+func (i *redisSessionStorage) CreateChatSession(ctx context.Context, session *GameInstance) error {
+	gameId, err := uuid.Parse(session.GameId.Value)
+	if err != nil {
+		return err
+	}
+
+	chatKey := redisChatKey(session.GameSession.ChatId)
+	err = i.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		previous, err := tx.Get(ctx, chatKey).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if err == nil {
+			active, err := i.isActive(ctx, previous)
+			if err != nil {
+				return err
+			}
+			if active {
+				return ErrChatAlreadyHasGame
+			}
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			//Previous game is checked on every claim, so the key doesn't need to expire with it
+			pipe.Set(ctx, chatKey, gameId.String(), 0)
+			return i.queueStore(ctx, pipe, gameId, session)
+		})
+		return err
+	}, chatKey)
+	if err == redis.TxFailedErr {
+		//Another replica claimed the chat in between
+		return ErrChatAlreadyHasGame
+	}
+	if err != nil && err != ErrChatAlreadyHasGame {
+		i.logger.Error("failed to store chat session in redis", gameIdField(gameId), zap.Error(err))
+	}
+	return err
+}
+
+// isActive checks that session exists and is not finished
+func (i *redisSessionStorage) isActive(ctx context.Context, gameId string) (bool, error) {
+	game, err := i.activeSession(ctx, gameId)
+	return game != nil, err
+}
+
+// activeSession returns nil if session does not exist or is finished
+func (i *redisSessionStorage) activeSession(ctx context.Context, gameId string) (*GameInstance, error) {
+	id, err := uuid.Parse(gameId)
+	if err != nil {
+		return nil, nil
+	}
+	game, err := i.GetSession(ctx, id)
+	if err == ErrSessionNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isGameOver(game.State) {
+		return nil, nil
+	}
+	return game, nil
+}
+
+// ActiveChatSession reads the latest game of chat from chat key, which CreateChatSession keeps
+func (i *redisSessionStorage) ActiveChatSession(ctx context.Context, chatId int64) (*GameInstance, error) {
+	gameId, err := i.rdb.Get(ctx, redisChatKey(chatId)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		i.logger.Error("failed to read chat game from redis", zap.Int64("chat_id", chatId), zap.Error(err))
+		return nil, err
+	}
+	return i.activeSession(ctx, gameId)
+}
+
+func (i *redisSessionStorage) GetSession(ctx context.Context, id uuid.UUID) (*GameInstance, error) {
+	data, err := i.rdb.Get(ctx, redisSessionKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		i.logger.Error("failed to fetch session data from redis", gameIdField(id), zap.Error(err))
+		return nil, err
+	}
+
+	ret := new(GameInstance)
+	if err = json.Unmarshal(data, ret); err != nil {
+		i.logger.Error("failed to decode session data from redis", gameIdField(id), zap.Error(err))
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (i *redisSessionStorage) CloseSession(ctx context.Context, id uuid.UUID) error {
+	change, err := json.Marshal(SessionChange{GameId: id, Closed: true, Origin: processId})
+	if err != nil {
+		return err
+	}
+
+	_, err = i.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, redisSessionKey(id))
+		pipe.ZRem(ctx, redisSessionsIndexKey, id.String())
+		pipe.Publish(ctx, redisSessionChangesChannel, change)
+		return nil
+	})
+	if err != nil {
+		i.logger.Error("failed to delete session data from redis", gameIdField(id), zap.Error(err))
+	}
+	return err
+}
+
+func (i *redisSessionStorage) CheckExistence(ctx context.Context, id uuid.UUID) (bool, error) {
+	n, err := i.rdb.Exists(ctx, redisSessionKey(id)).Result()
+	return n > 0, err
+}
+
+// NumberOfGames checks which sessions of the index are alive without reading them.
+// Ids of expired sessions are removed from the index along the way.
+func (i *redisSessionStorage) NumberOfGames(ctx context.Context) (uint, error) {
+	ids, err := i.rdb.ZRange(ctx, redisSessionsIndexKey, 0, -1).Result()
+	if err != nil {
+		i.logger.Error("failed to read sessions index from redis", zap.Error(err))
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	exists := make([]*redis.IntCmd, len(ids))
+	_, err = i.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for n, key := range redisSessionKeys(ids) {
+			exists[n] = pipe.Exists(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		i.logger.Error("failed to check sessions existence in redis", zap.Error(err))
+		return 0, err
+	}
+
+	expired := make([]interface{}, 0)
+	for n, cmd := range exists {
+		if cmd.Val() == 0 {
+			expired = append(expired, ids[n])
+		}
+	}
+	i.removeExpired(ctx, expired)
+	return uint(len(ids) - len(expired)), nil
+}
+
+func (i *redisSessionStorage) ListSessions(ctx context.Context, filter SessionFilter) ([]*GameInstance, error) {
+	games, err := i.allSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*GameInstance, 0)
+	for _, game := range games {
+		if filter.Match(game) {
+			matched = append(matched, game)
+		}
+	}
+
+	return filter.Paginate(matched), nil
+}
+
+func (i *redisSessionStorage) FindSessionsByPlayer(ctx context.Context, playerId uint64) ([]*GameInstance, error) {
+	return i.ListSessions(ctx, SessionFilter{PlayerId: playerId})
+}
+
+// allSessions returns every alive session sorted by creation time.
+// Ids of expired sessions are removed from the index along the way.
+func (i *redisSessionStorage) allSessions(ctx context.Context) ([]*GameInstance, error) {
+	ids, err := i.rdb.ZRange(ctx, redisSessionsIndexKey, 0, -1).Result()
+	if err != nil {
+		i.logger.Error("failed to read sessions index from redis", zap.Error(err))
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []*GameInstance{}, nil
+	}
+
+	values, err := i.rdb.MGet(ctx, redisSessionKeys(ids)...).Result()
+	if err != nil {
+		i.logger.Error("failed to fetch sessions from redis", zap.Error(err))
+		return nil, err
+	}
+
+	games := make([]*GameInstance, 0, len(values))
+	expired := make([]interface{}, 0)
+	for n, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			expired = append(expired, ids[n])
+			continue
+		}
+
+		game := new(GameInstance)
+		if err = json.Unmarshal([]byte(data), game); err != nil {
+			i.logger.Error("failed to decode session data from redis", zap.String("game_id", ids[n]), zap.Error(err))
+			return nil, err
+		}
+		games = append(games, game)
+	}
+
+	i.removeExpired(ctx, expired)
+	return games, nil
+}
+
+// removeExpired removes ids of sessions expired by ttl from the index, failure only leaves them for later
+func (i *redisSessionStorage) removeExpired(ctx context.Context, expired []interface{}) {
+	if len(expired) == 0 {
+		return
+	}
+	if err := i.rdb.ZRem(ctx, redisSessionsIndexKey, expired...).Err(); err != nil {
+		i.logger.Warn("failed to remove expired sessions from redis index", zap.Error(err))
+	}
+}
+
+func (i *redisSessionStorage) WatchSessions(ctx context.Context) (<-chan SessionChange, error) {
+	sub := i.rdb.Subscribe(ctx, redisSessionChangesChannel)
+	//Wait for subscription confirmation, so no changes are missed after return
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, err
+	}
+
+	changes := make(chan SessionChange)
+	go func() {
+		defer close(changes)
+		defer sub.Close()
+
+		msgs := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+
+				var change SessionChange
+				if err := json.Unmarshal([]byte(msg.Payload), &change); err != nil {
+					i.logger.Warn("malformed session change notification from redis", zap.Error(err))
+					continue
+				}
+
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+func redisLeaseKey(name string) string {
+	return redisKeyPrefix + "lease:" + name
+}
+
+// acquireLeaseScript renews lease of its holder or takes a free one.
+// Returns 1 if lease is held by ARGV[1] now, 0 otherwise.
+var acquireLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseLeaseScript removes lease only if it is still held by ARGV[1]
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (i *redisSessionStorage) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	held, err := acquireLeaseScript.Run(ctx, i.rdb, []string{redisLeaseKey(name)}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return held == 1, nil
+}
+
+func (i *redisSessionStorage) ReleaseLease(ctx context.Context, name, holder string) error {
+	return releaseLeaseScript.Run(ctx, i.rdb, []string{redisLeaseKey(name)}, holder).Err()
+}
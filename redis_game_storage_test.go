@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to start miniredis: ", err)
+	}
+	t.Cleanup(mr.Close)
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return mr, rdb
+}
+
+func TestRedisSessionStorageExpiration(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	stor := NewRedisSessionStorage(rdb, time.Minute, zaptest.NewLogger(t))
+
+	if err := stor.StoreSession(ctx, newTestGame(0, time.Now(), 1)); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := stor.NumberOfGames(ctx); n != 1 {
+		t.Fatalf("NumberOfGames = %d; want 1", n)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if n, _ := stor.NumberOfGames(ctx); n != 0 {
+		t.Errorf("NumberOfGames after ttl = %d; want 0", n)
+	}
+	if card, _ := rdb.ZCard(context.Background(), redisSessionsIndexKey).Result(); card != 0 {
+		t.Errorf("expired session is still in index")
+	}
+}
+
+func TestRedisSessionStorageWatch(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	stor := NewRedisSessionStorage(rdb, time.Minute, zaptest.NewLogger(t))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	changes, err := stor.WatchSessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game := newTestGame(0, time.Now(), 1)
+	id := testGameId(game.GameId)
+	if err = stor.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+	if err = stor.CloseSession(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []SessionChange{{GameId: id, Origin: processId}, {GameId: id, Closed: true, Origin: processId}} {
+		select {
+		case got := <-changes:
+			if got != want {
+				t.Errorf("got change %+v; want %+v", got, want)
+			}
+		case <-ctx.Done():
+			t.Fatal("no session change notification received")
+		}
+	}
+
+	cancel()
+	for range changes {
+	}
+}
+
+func TestRedisVoteStorage(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	votes := NewRedisVoteStorage(rdb, time.Minute, zaptest.NewLogger(t))
+	gameId := uuid.New()
+
+	mustVote := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustVote(votes.AddTeamVote(ctx, gameId, &api.Player{Id: 1}, api.VoteContext_POSITIVE))
+	mustVote(votes.AddTeamVote(ctx, gameId, &api.Player{Id: 2}, api.VoteContext_POSITIVE))
+	mustVote(votes.AddTeamVote(ctx, gameId, &api.Player{Id: 3}, api.VoteContext_NEGATIVE))
+	mustVote(votes.AddTeamVote(ctx, gameId, &api.Player{Id: 4}, api.VoteContext_ABSTAIN))
+	//Repeated vote must be ignored
+	if err := votes.AddTeamVote(ctx, gameId, &api.Player{Id: 1}, api.VoteContext_NEGATIVE); err != ErrRepeatedVote {
+		t.Fatalf("repeated vote = %v; want %v", err, ErrRepeatedVote)
+	}
+
+	teamVotes, err := votes.GetTeamVotes(ctx, gameId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &TeamVotes{Approvals: map[uint64]bool{1: true, 2: true}, Rejections: map[uint64]bool{3: true}, Abstentions: map[uint64]bool{4: true}}
+	if !reflect.DeepEqual(teamVotes, want) {
+		t.Errorf("GetTeamVotes = %+v; want %+v", teamVotes, want)
+	}
+
+	mustVote(votes.AddPositiveMissionVote(ctx, gameId, &api.Player{Id: 1}))
+	mustVote(votes.AddNegativeMissionVote(ctx, gameId, &api.Player{Id: 2}))
+	if n, _ := votes.NumberOfPlayersVotedForMission(ctx, gameId); n != 2 {
+		t.Errorf("NumberOfPlayersVotedForMission = %d; want 2", n)
+	}
+	if c, _ := votes.GetMissionVotesCountForGame(ctx, gameId); c != 1 {
+		t.Errorf("GetMissionVotesCountForGame = %d; want 1", c)
+	}
+
+	if ttl := mr.TTL(redisTeamVotesKey(gameId)); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("votes ttl = %v; want up to a minute", ttl)
+	}
+
+	mustVote(votes.ResetVotes(ctx, gameId))
+	if teamVotes, _ = votes.GetTeamVotes(ctx, gameId); len(teamVotes.Approvals)+len(teamVotes.Rejections)+len(teamVotes.Abstentions) != 0 {
+		t.Errorf("team votes after reset = %+v; want none", teamVotes)
+	}
+	if n, _ := votes.NumberOfPlayersVotedForMission(ctx, gameId); n != 0 {
+		t.Errorf("NumberOfPlayersVotedForMission after reset = %d; want 0", n)
+	}
+}
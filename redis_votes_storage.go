@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+const (
+	redisVotePositive = "1"
+	redisVoteNegative = "0"
+)
+
+// recordVoteScript stores player's vote only if there is none yet and refreshes votes expiration.
+// Returns 1 if vote was recorded, 0 on repeated vote.
+var recordVoteScript = redis.NewScript(`
+if redis.call("HSETNX", KEYS[1], ARGV[1], ARGV[2]) == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+	return 1
+end
+return 0
+`)
+
+// redisVoteStorage keeps votes of each round in a hash of player id -> vote,
+// so every replica sees the same votes and no one can vote twice
+type redisVoteStorage struct {
+	rdb    *redis.Client
+	ttl    time.Duration
+	logger *zap.Logger
+}
+
+func NewRedisVoteStorage(rdb *redis.Client, ttl time.Duration, logger *zap.Logger) VoteStorage {
+	return &redisVoteStorage{rdb: rdb, ttl: ttl, logger: logger}
+}
+
+func redisMissionVotesKey(gameId uuid.UUID) string {
+	return redisKeyPrefix + "votes:" + gameId.String() + ":mission"
+}
+
+func redisTeamVotesKey(gameId uuid.UUID) string {
+	return redisKeyPrefix + "votes:" + gameId.String() + ":team"
+}
+
+func (v *redisVoteStorage) recordVote(ctx context.Context, key string, gameId uuid.UUID, player *api.Player, vote string) error {
+	recorded, err := recordVoteScript.Run(
+		ctx,
+		v.rdb,
+		[]string{key},
+		strconv.FormatUint(player.Id, 10), vote, v.ttl.Milliseconds(),
+	).Int()
+	if err != nil {
+		v.logger.Error("failed to store vote in redis", gameIdField(gameId), playerIdField(player), zap.Error(err))
+		return err
+	}
+
+	if recorded == 0 {
+		v.logger.Info("repeated vote attempt", gameIdField(gameId), playerIdField(player))
+		return ErrRepeatedVote
+	}
+	return nil
+}
+
+// countVotes returns number of positive votes and total number of votes
+func (v *redisVoteStorage) countVotes(ctx context.Context, key string) (int, int, error) {
+	votes, err := v.rdb.HVals(ctx, key).Result()
+	if err != nil {
+		v.logger.Error("failed to read votes from redis", zap.String("key", key), zap.Error(err))
+		return 0, 0, err
+	}
+
+	positive := 0
+	for _, vote := range votes {
+		if vote == redisVotePositive {
+			positive++
+		}
+	}
+	return positive, len(votes), nil
+}
+
+func (v *redisVoteStorage) AddPositiveMissionVote(ctx context.Context, gameId uuid.UUID, player *api.Player) error {
+	return v.recordVote(ctx, redisMissionVotesKey(gameId), gameId, player, redisVotePositive)
+}
+
+func (v *redisVoteStorage) AddNegativeMissionVote(ctx context.Context, gameId uuid.UUID, player *api.Player) error {
+	return v.recordVote(ctx, redisMissionVotesKey(gameId), gameId, player, redisVoteNegative)
+}
+
+func (v *redisVoteStorage) GetMissionVotesCountForGame(ctx context.Context, id uuid.UUID) (int8, error) {
+	positive, _, err := v.countVotes(ctx, redisMissionVotesKey(id))
+	return int8(positive), err
+}
+
+func (v *redisVoteStorage) NumberOfPlayersVotedForMission(ctx context.Context, id uuid.UUID) (int, error) {
+	n, err := v.rdb.HLen(ctx, redisMissionVotesKey(id)).Result()
+	return int(n), err
+}
+
+// AddTeamVote stores vote option number, positive and negative ones are the same as mission votes
+func (v *redisVoteStorage) AddTeamVote(ctx context.Context, gameId uuid.UUID, player *api.Player, vote api.VoteContext_VoteOption) error {
+	return v.recordVote(ctx, redisTeamVotesKey(gameId), gameId, player, strconv.Itoa(int(vote)))
+}
+
+func (v *redisVoteStorage) GetTeamVotes(ctx context.Context, id uuid.UUID) (*TeamVotes, error) {
+	stored, err := v.rdb.HGetAll(ctx, redisTeamVotesKey(id)).Result()
+	if err != nil {
+		v.logger.Error("failed to read votes from redis", gameIdField(id), zap.Error(err))
+		return nil, err
+	}
+
+	votes := newTeamVotes()
+	for player, vote := range stored {
+		playerId, err := strconv.ParseUint(player, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed voter id %q: %w", player, err)
+		}
+		option, err := strconv.Atoi(vote)
+		if err != nil || !votes.add(playerId, api.VoteContext_VoteOption(option)) {
+			return nil, fmt.Errorf("malformed team vote %q of player %d", vote, playerId)
+		}
+	}
+	return votes, nil
+}
+
+func (v *redisVoteStorage) ResetVotes(ctx context.Context, gameId uuid.UUID) error {
+	err := v.rdb.Del(ctx, redisMissionVotesKey(gameId), redisTeamVotesKey(gameId)).Err()
+	if err != nil {
+		v.logger.Error("failed to reset votes in redis", gameIdField(gameId), zap.Error(err))
+	}
+	return err
+}
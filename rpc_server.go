@@ -1,42 +1,314 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
 	"github.com/justmax437/avalonBacker/api"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
-	"log"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
 func main() {
-	grpcServer := grpc.NewServer()
-	api.RegisterGameServiceServer(grpcServer,
-		NewGameService(
-			//NewMemoryStorage(30*time.Minute),
-			NewMongoSessionStorage(),
-			NewVoteStorage(),
-		),
-	)
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		if err := runSimulation(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
 
-	reflection.Register(grpcServer)
+	cfg, err := LoadServerConfig(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	err := grpcServer.Serve(getServerSocket())
+	logger, err := newLogger(cfg.Log)
 	if err != nil {
-		log.Fatal("failed to start gRPC server: ", err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
+
+	if err = run(cfg, logger); err != nil {
+		logger.Error("server stopped", zap.Error(err))
+		_ = logger.Sync()
+		os.Exit(1)
+	}
+	_ = logger.Sync()
 }
 
-func getServerSocket() net.Listener {
-	var sock net.Listener
-	if port, exist := os.LookupEnv("PORT"); exist {
-		socket, err := net.Listen("tcp", ":"+port)
+// run serves gRPC until SIGTERM or SIGINT, then lets in-flight calls finish and releases storages
+func run(cfg *ServerConfig, logger *zap.Logger) error {
+	//Every shutdown step shares one deadline, started on signal or on failed startup
+	var shutdownCtx context.Context
+	cancelShutdown := context.CancelFunc(func() {})
+	defer func() { cancelShutdown() }()
+	startShutdown := func() context.Context {
+		if shutdownCtx == nil {
+			shutdownCtx, cancelShutdown = context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		}
+		return shutdownCtx
+	}
+
+	shutdownTracing, err := setupTracing(cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(startShutdown()); err != nil {
+			logger.Error("failed to flush tracing spans", zap.Error(err))
+		}
+	}()
+
+	//Cancelled on shutdown to stop background work: sweeper, health checks
+	ctx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	var background sync.WaitGroup
+
+	backend, votes, err := newStorages(ctx, &background, cfg.Storage, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize %s storage: %w", cfg.Storage.Backend, err)
+	}
+	defer func() {
+		//Sweeper must not use storage after it is closed
+		stopBackground()
+		background.Wait()
+		closeStorage(startShutdown(), backend, logger)
+	}()
+
+	votesBackend := cfg.Storage.Backend
+	if _, inMemory := votes.(*memoryVoteStorage); inMemory {
+		votesBackend = storageMemory
+	}
+	sessions := GameSessionStorage(NewInstrumentedSessionStorage(backend, cfg.Storage.Backend))
+	votes = NewInstrumentedVoteStorage(votes, votesBackend)
+	if cfg.Storage.Cache.Enabled() && cfg.Storage.Backend != storageMemory {
+		cache, err := NewCachedSessionStorage(sessions, cfg.Storage.Cache.Size, cfg.Storage.Cache.TTL, logger)
 		if err != nil {
-			log.Fatal("failed to open socket - ", err)
+			return fmt.Errorf("failed to set up session cache: %w", err)
 		}
-		sock = socket
+		defer cache.Close() //Drains watch stream before backend is closed
+		sessions = cache
+	}
+
+	serverOpts, err := newServerOptions(cfg, logger)
+	if err != nil {
+		return fmt.Errorf("failed to set up gRPC server: %w", err)
+	}
+
+	service := NewGameService(sessions, votes, logger)
+	grpcServer := grpc.NewServer(serverOpts...)
+	api.RegisterGameServiceServer(grpcServer, service)
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		watchStorageHealth(ctx, healthServer, backend, cfg.HealthCheckInterval, logger)
+	}()
+
+	if cfg.Features.Reflection {
+		reflection.Register(grpcServer)
+	}
+
+	if cfg.Metrics.ListenAddr != "" {
+		go serveMetrics(cfg.Metrics.ListenAddr, newMetricsRegistry(sessions, logger), logger)
+	}
+
+	var gatewayServer *http.Server
+	events := newSessionEventsBridge(sessions, cfg, logger)
+	if cfg.Gateway.ListenAddr != "" {
+		gateway := NewRESTGateway(service, newUnaryInterceptors(cfg, logger), logger)
+		gatewayServer = &http.Server{
+			Addr:              cfg.Gateway.ListenAddr,
+			Handler:           newWebHandler(cfg.Gateway, grpcServer, events, gateway),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go serveGateway(gatewayServer, cfg.TLS, logger)
+	}
+
+	botStopped := make(chan struct{})
+	botCtx, stopBot := context.WithCancel(ctx)
+	defer stopBot()
+	if cfg.Telegram.Enabled() {
+		bot := NewTelegramBot(cfg.Telegram, service, logger.With(zap.String("component", "telegram")))
+		go func() {
+			runAsLeader(botCtx, backend, telegramLeaseName, processId, telegramLeaseTTL, logger, bot.Run)
+			close(botStopped)
+		}()
 	} else {
-		log.Fatal("server port number env was not found (check $PORT)")
+		close(botStopped)
+	}
+
+	socket, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to open socket on %s: %w", cfg.ListenAddr, err)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("starting gRPC server", zap.String("addr", cfg.ListenAddr), zap.String("storage", cfg.Storage.Backend))
+		serveErr <- grpcServer.Serve(socket)
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(signals)
+
+	select {
+	case err = <-serveErr:
+		err = fmt.Errorf("failed to serve gRPC: %w", err)
+	case sig := <-signals:
+		logger.Info("shutting down", zap.Stringer("signal", sig), zap.Duration("timeout", cfg.ShutdownTimeout))
+	}
+
+	//Load balancers stop sending new calls once server reports NOT_SERVING
+	healthServer.Shutdown()
+	deadlineCtx := startShutdown()
+	if gatewayServer != nil {
+		if err := gatewayServer.Shutdown(deadlineCtx); err != nil {
+			logger.Warn("REST gateway calls did not finish in time", zap.Error(err))
+		}
+	}
+	events.Close() //WebSocket connections are not tracked by gateway server
+	stopBot()
+	<-botStopped //Bot finishes update it is handling
+	deadline, _ := deadlineCtx.Deadline()
+	if !gracefulStop(grpcServer, time.Until(deadline)) {
+		logger.Warn("in-flight calls did not finish in time and were cancelled")
+	}
+	return err
+}
+
+// gracefulStop waits for in-flight calls to finish, cancelling them after timeout.
+// Returns false if calls had to be cancelled.
+func gracefulStop(server *grpc.Server, timeout time.Duration) bool {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+		return true
+	case <-timer.C:
+		server.Stop()
+		<-stopped
+		return false
+	}
+}
+
+// closeStorage releases connections and files held by storage backend, waiting for pending operations until ctx is done
+func closeStorage(ctx context.Context, storage GameSessionStorage, logger *zap.Logger) {
+	var err error
+	switch s := storage.(type) {
+	case interface{ Disconnect(context.Context) error }:
+		err = s.Disconnect(ctx)
+	case interface{ Close() error }:
+		err = s.Close()
+	default:
+		return
+	}
+	if err != nil {
+		logger.Error("failed to close storage", zap.Error(err))
+	}
+}
+
+// newStorages starts background work of storages in background group, it stops once ctx is done
+func newStorages(ctx context.Context, background *sync.WaitGroup, cfg StorageConfig, logger *zap.Logger) (GameSessionStorage, VoteStorage, error) {
+	logger = logger.With(zap.String("storage", cfg.Backend))
+	switch cfg.Backend {
+	case storageMemory:
+		return NewMemoryStorage(cfg.SessionTTL), NewVoteStorage(logger), nil
+	case storageRedis:
+		redisOpts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse redis url: %w", err)
+		}
+		rdb := redis.NewClient(redisOpts)
+		return NewRedisSessionStorage(rdb, cfg.SessionTTL, logger), NewRedisVoteStorage(rdb, cfg.SessionTTL, logger), nil
+	case storageBolt:
+		boltStorage, err := NewBoltStorage(cfg.BoltPath, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return boltStorage, boltStorage, nil
+	case storageMongo:
+		mongoStorage, err := NewMongoSessionStorage(cfg.Mongo, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cfg.Mongo.IdleTimeout > 0 {
+			background.Add(1)
+			go func() {
+				defer background.Done()
+				mongoStorage.RunIdleSessionsSweeper(
+					ctx,
+					cfg.Mongo.SweepInterval,
+					cfg.Mongo.IdleTimeout,
+					cfg.Mongo.ArchiveIdle,
+				)
+			}()
+		}
+		//Votes are kept in mongo too, so replicas sharing sessions share votes of every round
+		return mongoStorage, NewMongoVoteStorage(mongoStorage, logger), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+func newServerOptions(cfg *ServerConfig, logger *zap.Logger) ([]grpc.ServerOption, error) {
+	opts := make([]grpc.ServerOption, 0)
+	if cfg.TLS.Enabled() {
+		creds, err := credentials.NewServerTLSFromFile(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS credentials: %w", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(newUnaryInterceptors(cfg, logger)...),
+		grpc.ChainStreamInterceptor(newStreamInterceptors(cfg, logger)...),
+	)
+	return opts, nil
+}
+
+// newUnaryInterceptors builds interceptors for every unary call, both over gRPC and REST gateway.
+// Tracing, metrics and logging interceptors go first, so rejected unauthenticated calls are traced, counted and logged too.
+func newUnaryInterceptors(cfg *ServerConfig, logger *zap.Logger) []grpc.UnaryServerInterceptor {
+	interceptors := []grpc.UnaryServerInterceptor{
+		otelgrpc.UnaryServerInterceptor(), metricsUnaryInterceptor, loggingInterceptor(logger),
+	}
+	if len(cfg.Auth.Tokens) > 0 {
+		interceptors = append(interceptors, newTokenAuth(cfg.Auth.Tokens).unaryInterceptor)
+	}
+	return interceptors
+}
+
+func newStreamInterceptors(cfg *ServerConfig, logger *zap.Logger) []grpc.StreamServerInterceptor {
+	interceptors := []grpc.StreamServerInterceptor{
+		otelgrpc.StreamServerInterceptor(), metricsStreamInterceptor, streamLoggingInterceptor(logger),
+	}
+	if len(cfg.Auth.Tokens) > 0 {
+		interceptors = append(interceptors, newTokenAuth(cfg.Auth.Tokens).streamInterceptor)
 	}
-	return sock
+	return interceptors
 }
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/justmax437/avalonBacker/api"
+	"sort"
+)
+
+// Every game has this many missions, no matter the rules
+const missionsCount = 5
+
+// RuleSet is the complete set of rules a game is played by
+type RuleSet struct {
+	//Evil team wins when this many team proposals for a mission are rejected
+	MaxRejectedProposals uint32
+	PlayerCounts         map[int]PlayerCountRules //By total number of players
+	Roles                api.GameExtensions       //Special roles from api.GameConfig.Extensions
+}
+
+// PlayerCountRules are rules of a game for specific number of players
+type PlayerCountRules struct {
+	EvilPlayers   int
+	TeamSizes     [missionsCount]int //Number of players sent on each mission
+	FailsRequired [missionsCount]int //Number of fail votes failing each mission
+}
+
+// builtinRules are standard Avalon rules, sessions override them with api.GameConfig.Rules
+var builtinRules = RuleSet{
+	MaxRejectedProposals: 5,
+	PlayerCounts: map[int]PlayerCountRules{
+		5:  {EvilPlayers: 2, TeamSizes: [5]int{2, 3, 2, 3, 3}, FailsRequired: [5]int{1, 1, 1, 1, 1}},
+		6:  {EvilPlayers: 2, TeamSizes: [5]int{2, 3, 4, 3, 4}, FailsRequired: [5]int{1, 1, 1, 1, 1}},
+		7:  {EvilPlayers: 3, TeamSizes: [5]int{2, 3, 3, 4, 4}, FailsRequired: [5]int{1, 1, 1, 2, 1}},
+		8:  {EvilPlayers: 3, TeamSizes: [5]int{3, 4, 4, 5, 5}, FailsRequired: [5]int{1, 1, 1, 2, 1}},
+		9:  {EvilPlayers: 3, TeamSizes: [5]int{3, 4, 4, 5, 5}, FailsRequired: [5]int{1, 1, 1, 2, 1}},
+		10: {EvilPlayers: 4, TeamSizes: [5]int{3, 4, 4, 5, 5}, FailsRequired: [5]int{1, 1, 1, 2, 1}},
+	},
+}
+
+// defaultRules returns a copy of builtinRules, safe to modify
+func defaultRules() *RuleSet {
+	rules := &RuleSet{
+		MaxRejectedProposals: builtinRules.MaxRejectedProposals,
+		PlayerCounts:         make(map[int]PlayerCountRules, len(builtinRules.PlayerCounts)),
+	}
+	for players, countRules := range builtinRules.PlayerCounts {
+		rules.PlayerCounts[players] = countRules
+	}
+	return rules
+}
+
+// newRuleSet applies overrides to built-in rules and deals special roles of extensions, nil overrides nothing
+func newRuleSet(overrides *api.RuleSet, extensions *api.GameExtensions) (*RuleSet, error) {
+	rules := defaultRules()
+	if extensions != nil {
+		rules.Roles = *extensions
+	}
+	if overrides.GetMaxRejectedProposals() != 0 {
+		rules.MaxRejectedProposals = overrides.MaxRejectedProposals
+	}
+	for _, o := range overrides.GetPlayerCounts() {
+		players := int(o.GetPlayers())
+		if len(o.TeamSizes) != missionsCount || len(o.FailsRequired) != missionsCount {
+			return nil, fmt.Errorf("rules for %d players must set team size and fails required for all %d missions", players, missionsCount)
+		}
+		countRules := PlayerCountRules{EvilPlayers: int(o.EvilPlayers)}
+		for m := 0; m < missionsCount; m++ {
+			countRules.TeamSizes[m] = int(o.TeamSizes[m])
+			countRules.FailsRequired[m] = int(o.FailsRequired[m])
+		}
+		if err := countRules.validate(players); err != nil {
+			return nil, err
+		}
+		rules.PlayerCounts[players] = countRules
+	}
+	return rules, nil
+}
+
+func (r PlayerCountRules) validate(players int) error {
+	if r.EvilPlayers < 1 || 2*r.EvilPlayers >= players {
+		return fmt.Errorf("%d evil players out of %d must be a minority", r.EvilPlayers, players)
+	}
+	for m := 0; m < missionsCount; m++ {
+		if r.TeamSizes[m] < 1 || r.TeamSizes[m] > players {
+			return fmt.Errorf("team size %d of mission %d is not possible with %d players", r.TeamSizes[m], m+1, players)
+		}
+		if r.FailsRequired[m] < 1 || r.FailsRequired[m] > r.TeamSizes[m] {
+			return fmt.Errorf("%d fails required for mission %d of team size %d", r.FailsRequired[m], m+1, r.TeamSizes[m])
+		}
+	}
+	return nil
+}
+
+// Proto returns complete rules to be kept in session config, roles are kept in extensions of the config
+func (r *RuleSet) Proto() *api.RuleSet {
+	counts := make([]int, 0, len(r.PlayerCounts))
+	for players := range r.PlayerCounts {
+		counts = append(counts, players)
+	}
+	sort.Ints(counts)
+
+	pb := &api.RuleSet{
+		MaxRejectedProposals: r.MaxRejectedProposals,
+	}
+	for _, players := range counts {
+		countRules := r.PlayerCounts[players]
+		o := &api.PlayerCountRules{
+			Players:       uint32(players),
+			EvilPlayers:   uint32(countRules.EvilPlayers),
+			TeamSizes:     make([]uint32, missionsCount),
+			FailsRequired: make([]uint32, missionsCount),
+		}
+		for m := 0; m < missionsCount; m++ {
+			o.TeamSizes[m] = uint32(countRules.TeamSizes[m])
+			o.FailsRequired[m] = uint32(countRules.FailsRequired[m])
+		}
+		pb.PlayerCounts = append(pb.PlayerCounts, o)
+	}
+	return pb
+}
+
+// TeamSize returns 0 if there is no such mission or number of players is not supported
+func (r *RuleSet) TeamSize(players int, mission uint32) int {
+	countRules, supported := r.PlayerCounts[players]
+	if !supported || mission < 1 || mission > missionsCount {
+		return 0
+	}
+	return countRules.TeamSizes[mission-1]
+}
+
+// FailsRequired returns 0 if there is no such mission or number of players is not supported
+func (r *RuleSet) FailsRequired(players int, mission uint32) int {
+	countRules, supported := r.PlayerCounts[players]
+	if !supported || mission < 1 || mission > missionsCount {
+		return 0
+	}
+	return countRules.FailsRequired[mission-1]
+}
+
+// CanDeal checks that rules support number of players and have enough evil players for every evil role
+func (r *RuleSet) CanDeal(players int) error {
+	countRules, supported := r.PlayerCounts[players]
+	if !supported {
+		return fmt.Errorf("game rules do not support %d players", players)
+	}
+	if roles := r.evilRoles(); roles > countRules.EvilPlayers {
+		return fmt.Errorf("%d evil roles can't be dealt to %d evil players of %d", roles, countRules.EvilPlayers, players)
+	}
+	return nil
+}
+
+// evilRoles counts evil players with a role, assassin included
+func (r *RuleSet) evilRoles() int {
+	roles := 1
+	for _, dealt := range []bool{r.Roles.PercivalAndMorgana, r.Roles.Oberon, r.Roles.Mordred} {
+		if dealt {
+			roles++
+		}
+	}
+	return roles
+}
+
+// CheckTeams checks teams dealt by client: they must be balanced and have a team member for every role of the rules
+func (r *RuleSet) CheckTeams(good *api.VirtuousTeam, evil *api.EvilTeam) error {
+	if !r.TeamsBalanced(len(good.GetMembers()), len(evil.GetMembers())) {
+		return errors.New("provided teams are not balanced by the game rules")
+	}
+	if err := r.CanDeal(len(good.GetMembers()) + len(evil.GetMembers())); err != nil {
+		return err
+	}
+	//Clients dealing teams themselves were always allowed to keep Merlin and assassin to themselves
+	err := checkTeamRoles(good.GetMembers(), []teamRole{
+		{name: "merlin", player: good.GetMerlin(), dealt: good.GetMerlin() != nil},
+		{name: "percival", player: good.GetPercival(), dealt: r.Roles.PercivalAndMorgana},
+	})
+	if err != nil {
+		return errors.New("invalid virtuous team: " + err.Error())
+	}
+	err = checkTeamRoles(evil.GetMembers(), []teamRole{
+		{name: "assassin", player: evil.GetAssassin(), dealt: evil.GetAssassin() != nil},
+		{name: "morgana", player: evil.GetMorgana(), dealt: r.Roles.PercivalAndMorgana},
+		{name: "oberon", player: evil.GetOberon(), dealt: r.Roles.Oberon},
+		{name: "mordred", player: evil.GetMordred(), dealt: r.Roles.Mordred},
+	})
+	if err != nil {
+		return errors.New("invalid evil team: " + err.Error())
+	}
+	return nil
+}
+
+// teamRole is a role of team, dealt to a player when it is in game
+type teamRole struct {
+	name   string
+	player *api.Player
+	dealt  bool
+}
+
+// checkTeamRoles makes sure every dealt role went to a different member of team, and the rest to no one
+func checkTeamRoles(members []*api.Player, roles []teamRole) error {
+	taken := make(map[uint64]string, len(roles))
+	for _, role := range roles {
+		switch {
+		case role.player == nil && role.dealt:
+			return fmt.Errorf("%s is in game, but not dealt", role.name)
+		case role.player == nil:
+			continue
+		case !role.dealt:
+			return fmt.Errorf("%s is dealt to player %d, but not in game", role.name, role.player.Id)
+		case findPlayer(members, role.player.Id) == nil:
+			return fmt.Errorf("%s %d is not a member of team", role.name, role.player.Id)
+		}
+		if other, exist := taken[role.player.Id]; exist {
+			return fmt.Errorf("player %d is both %s and %s", role.player.Id, other, role.name)
+		}
+		taken[role.player.Id] = role.name
+	}
+	return nil
+}
+
+// TeamsBalanced checks that teams of given sizes are allowed by the rules
+func (r *RuleSet) TeamsBalanced(goodPlayers, evilPlayers int) bool {
+	countRules, supported := r.PlayerCounts[goodPlayers+evilPlayers]
+	return supported && countRules.EvilPlayers == evilPlayers
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/justmax437/avalonBacker/api"
+)
+
+func TestRuleSetOverrides(t *testing.T) {
+	rules, err := newRuleSet(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rules, defaultRules()) {
+		t.Errorf("rules without overrides differ from built-in ones: %+v", rules)
+	}
+	if rules.FailsRequired(7, 4) != 2 || rules.FailsRequired(6, 4) != 1 || rules.TeamSize(8, 1) != 3 || rules.TeamSize(11, 1) != 0 {
+		t.Errorf("built-in rules changed: %+v", rules)
+	}
+
+	rules, err = newRuleSet(&api.RuleSet{
+		MaxRejectedProposals: 3,
+		PlayerCounts: []*api.PlayerCountRules{
+			{Players: 4, EvilPlayers: 1, TeamSizes: []uint32{2, 2, 2, 3, 3}, FailsRequired: []uint32{1, 1, 1, 1, 1}},
+			{Players: 7, EvilPlayers: 3, TeamSizes: []uint32{2, 3, 3, 4, 4}, FailsRequired: []uint32{1, 1, 1, 1, 1}},
+		},
+	}, &api.GameExtensions{PercivalAndMorgana: true, Oberon: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.MaxRejectedProposals != 3 || !rules.TeamsBalanced(3, 1) || rules.FailsRequired(7, 4) != 1 || rules.FailsRequired(8, 4) != 2 || !rules.Roles.Oberon {
+		t.Errorf("overrides were not applied: %+v", rules)
+	}
+	//Assassin, Morgana and Oberon need 3 evil players
+	if rules.CanDeal(7) != nil || rules.CanDeal(6) == nil || rules.CanDeal(11) == nil {
+		t.Errorf("roles %+v can be dealt to wrong numbers of players", rules.Roles)
+	}
+	//Complete rules kept in session must give the same rules back
+	if restored, err := newRuleSet(rules.Proto(), &rules.Roles); err != nil || !reflect.DeepEqual(restored, rules) {
+		t.Errorf("rules changed after round trip: %+v, %v", restored, err)
+	}
+
+	for problem, o := range map[string]*api.PlayerCountRules{
+		"all 5 missions": {Players: 5, EvilPlayers: 2, TeamSizes: []uint32{2, 3}, FailsRequired: []uint32{1, 1}},
+		"minority":       {Players: 6, EvilPlayers: 3, TeamSizes: []uint32{2, 3, 4, 3, 4}, FailsRequired: []uint32{1, 1, 1, 1, 1}},
+		"team size":      {Players: 5, EvilPlayers: 2, TeamSizes: []uint32{2, 3, 6, 3, 3}, FailsRequired: []uint32{1, 1, 1, 1, 1}},
+		"fails required": {Players: 5, EvilPlayers: 2, TeamSizes: []uint32{2, 3, 2, 3, 3}, FailsRequired: []uint32{1, 1, 3, 1, 1}},
+	} {
+		_, err := newRuleSet(&api.RuleSet{PlayerCounts: []*api.PlayerCountRules{o}}, nil)
+		if err == nil || !strings.Contains(err.Error(), problem) {
+			t.Errorf("error %v does not mention %q", err, problem)
+		}
+	}
+}
+
+func TestRuleSetCheckTeams(t *testing.T) {
+	rules, err := newRuleSet(nil, &api.GameExtensions{PercivalAndMorgana: true, Oberon: true, Mordred: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rules.CheckTeams(specialRolesTeams()); err != nil {
+		t.Errorf("teams with every role were rejected: %v", err)
+	}
+	good, evil := specialRolesTeams()
+	good.Merlin, evil.Assassin = nil, nil
+	if err = rules.CheckTeams(good, evil); err != nil {
+		t.Errorf("teams without Merlin and assassin were rejected: %v", err)
+	}
+
+	for problem, deal := range map[string]func(good *api.VirtuousTeam, evil *api.EvilTeam){
+		"not balanced":    func(good *api.VirtuousTeam, evil *api.EvilTeam) { good.Members = good.Members[:5] },
+		"not dealt":       func(_ *api.VirtuousTeam, evil *api.EvilTeam) { evil.Mordred = nil },
+		"not a member":    func(good *api.VirtuousTeam, evil *api.EvilTeam) { good.Percival = evil.Morgana },
+		"both oberon and": func(_ *api.VirtuousTeam, evil *api.EvilTeam) { evil.Mordred = evil.Oberon },
+		"both merlin and": func(good *api.VirtuousTeam, _ *api.EvilTeam) { good.Percival = good.Merlin },
+		"4 evil roles": func(good *api.VirtuousTeam, evil *api.EvilTeam) {
+			good.Members, evil.Members = good.Members[:4], evil.Members[:3]
+		},
+		"assassin 13": func(good *api.VirtuousTeam, evil *api.EvilTeam) { evil.Assassin = good.Members[2] },
+	} {
+		good, evil := specialRolesTeams()
+		deal(good, evil)
+		if err := rules.CheckTeams(good, evil); err == nil || !strings.Contains(err.Error(), problem) {
+			t.Errorf("error %v does not mention %q", err, problem)
+		}
+	}
+
+	rules.Roles.Mordred = false
+	if err = rules.CheckTeams(specialRolesTeams()); err == nil || !strings.Contains(err.Error(), "mordred is dealt") {
+		t.Errorf("error %v does not mention mordred out of game", err)
+	}
+}
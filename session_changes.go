@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"sync"
+)
+
+// processId tells apart session changes made by this process from ones made by other replicas
+var processId = uuid.New().String()
+
+// sessionChangeBroadcaster fans out session changes made by this process to every watcher.
+// Zero value is ready to use, embed it to implement SessionChangeNotifier.
+type sessionChangeBroadcaster struct {
+	logger *zap.Logger //Dropped changes are not logged if nil
+
+	lock     sync.Mutex
+	watchers map[chan SessionChange]struct{}
+}
+
+// Buffer size of every watcher channel, changes are dropped for watchers that fall behind
+const sessionChangesBuffer = 64
+
+func (b *sessionChangeBroadcaster) WatchSessions(ctx context.Context) (<-chan SessionChange, error) {
+	changes := make(chan SessionChange, sessionChangesBuffer)
+
+	b.lock.Lock()
+	if b.watchers == nil {
+		b.watchers = make(map[chan SessionChange]struct{})
+	}
+	b.watchers[changes] = struct{}{}
+	b.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.lock.Lock()
+		delete(b.watchers, changes)
+		close(changes)
+		b.lock.Unlock()
+	}()
+
+	return changes, nil
+}
+
+func (b *sessionChangeBroadcaster) notify(change SessionChange) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for watcher := range b.watchers {
+		select {
+		case watcher <- change:
+		default:
+			if b.logger != nil {
+				b.logger.Warn("session change dropped for slow watcher", gameIdField(change.GameId))
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSessionChangeBroadcaster(t *testing.T) {
+	var b sessionChangeBroadcaster
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first, _ := b.WatchSessions(ctx)
+	second, _ := b.WatchSessions(context.Background())
+
+	change := SessionChange{GameId: uuid.New(), Closed: true}
+	b.notify(change)
+	for _, watcher := range []<-chan SessionChange{first, second} {
+		if got := <-watcher; got != change {
+			t.Errorf("got %+v; want %+v", got, change)
+		}
+	}
+
+	cancel()
+	if _, open := <-first; open {
+		t.Error("watcher channel must be closed after its context is done")
+	}
+
+	//Slow watchers must not block others
+	for n := 0; n < sessionChangesBuffer+1; n++ {
+		b.notify(change)
+	}
+	if len(second) != sessionChangesBuffer {
+		t.Errorf("second watcher has %d pending changes; want %d", len(second), sessionChangesBuffer)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	sessionEventsPingInterval = 30 * time.Second
+	sessionEventsWriteTimeout = 10 * time.Second
+
+	//Subprotocol of session events, browsers offer it along with bearer one
+	sessionEventsProtocol = "avalon-events"
+	//Browsers can't set headers of WebSocket handshake, so token is offered as "bearer.<token>" subprotocol.
+	//Unlike query params, Sec-WebSocket-Protocol header doesn't end up in access logs.
+	bearerProtocolPrefix = "bearer."
+)
+
+// sessionEvent is a JSON message sent to WebSocket clients watching a session
+type sessionEvent struct {
+	Session *api.GameSession `json:"session,omitempty"` //Session state after the change
+	Closed  bool             `json:"closed,omitempty"`  //Session was terminated or expired, no events will follow
+}
+
+// sessionEventsBridge streams changes of a single session to browsers over WebSocket on /v1/sessions/{id}/events.
+// Client gets current session state right after connection and then a new state after every change.
+// Changes come from storage change feed, so changes made through other replicas are streamed too.
+type sessionEventsBridge struct {
+	sessions GameSessionStorage
+	auth     *tokenAuth //nil if authentication is disabled
+	upgrader websocket.Upgrader
+	logger   *zap.Logger
+
+	lock    sync.Mutex
+	closed  bool
+	done    chan struct{} //Closed on shutdown to end all streams
+	streams sync.WaitGroup
+}
+
+func newSessionEventsBridge(sessions GameSessionStorage, cfg *ServerConfig, logger *zap.Logger) *sessionEventsBridge {
+	b := &sessionEventsBridge{
+		sessions: sessions,
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+	if len(cfg.Auth.Tokens) > 0 {
+		b.auth = newTokenAuth(cfg.Auth.Tokens)
+	}
+
+	b.upgrader.Subprotocols = []string{sessionEventsProtocol}
+	isAllowed := originAllowed(cfg.Gateway.AllowedOrigins)
+	b.upgrader.CheckOrigin = func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true //Not a browser
+		}
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		return isAllowed(origin)
+	}
+	return b
+}
+
+// sessionEventsPath extracts game id from /v1/sessions/{id}/events
+func sessionEventsPath(path string) (string, bool) {
+	vars, ok := matchGatewayPath("/v1/sessions/{id}/events", path)
+	return vars["id"], ok
+}
+
+// Close ends every stream and waits for them to finish
+func (b *sessionEventsBridge) Close() {
+	b.lock.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	b.lock.Unlock()
+	b.streams.Wait()
+}
+
+// beginStream registers new stream, unless bridge is closed
+func (b *sessionEventsBridge) beginStream() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.closed {
+		return false
+	}
+	b.streams.Add(1)
+	return true
+}
+
+func (b *sessionEventsBridge) ServeSession(w http.ResponseWriter, r *http.Request, id string) {
+	gameId, err := uuid.Parse(id)
+	if err != nil {
+		writeGatewayError(w, http.StatusBadRequest, codes.InvalidArgument, "invalid game id")
+		return
+	}
+	if b.auth != nil {
+		md := gatewayMetadata(r)
+		for _, protocol := range websocket.Subprotocols(r) {
+			if strings.HasPrefix(protocol, bearerProtocolPrefix) {
+				md.Append("authorization", "Bearer "+strings.TrimPrefix(protocol, bearerProtocolPrefix))
+			}
+		}
+		if err = b.auth.authorize(metadata.NewIncomingContext(r.Context(), md)); err != nil {
+			writeGatewayError(w, http.StatusUnauthorized, codes.Unauthenticated, "missing or invalid auth token")
+			return
+		}
+	}
+	//Decorators forward changes of their backend, if it reports any
+	if _, ok := unwrapStorage(b.sessions).(SessionChangeNotifier); !ok {
+		writeGatewayError(w, http.StatusNotImplemented, codes.Unimplemented, "session storage does not report changes")
+		return
+	}
+	notifier := b.sessions.(SessionChangeNotifier)
+
+	if !b.beginStream() {
+		writeGatewayError(w, http.StatusServiceUnavailable, codes.Unavailable, "server is shutting down")
+		return
+	}
+	defer b.streams.Done()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	//Subscribe before reading current state, so changes made in between are not missed
+	changes, err := notifier.WatchSessions(ctx)
+	if err != nil {
+		b.logger.Error("failed to watch session changes", gameIdField(gameId), zap.Error(err))
+		writeGatewayError(w, http.StatusServiceUnavailable, codes.Unavailable, "failed to watch session changes")
+		return
+	}
+
+	conn, err := b.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return //Upgrader already replied with error
+	}
+	defer conn.Close()
+
+	//Client messages are ignored, but they must be read to process pongs and close frames
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if err = b.stream(ctx, conn, gameId, changes); err != nil {
+		b.logger.Debug("session events stream failed", gameIdField(gameId), zap.Error(err))
+	}
+}
+
+func (b *sessionEventsBridge) stream(ctx context.Context, conn *websocket.Conn, gameId uuid.UUID, changes <-chan SessionChange) error {
+	if closed, err := b.sendSession(ctx, conn, gameId); err != nil || closed {
+		return err
+	}
+
+	ping := time.NewTicker(sessionEventsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-b.done:
+			return closeWebSocket(conn, websocket.CloseGoingAway, "server is shutting down")
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(sessionEventsWriteTimeout)); err != nil {
+				return err
+			}
+		case change, ok := <-changes:
+			if !ok {
+				return closeWebSocket(conn, websocket.CloseGoingAway, "session changes are not available")
+			}
+			if change.GameId != gameId {
+				continue
+			}
+			if change.Closed {
+				if err := writeSessionEvent(conn, sessionEvent{Closed: true}); err != nil {
+					return err
+				}
+				return closeWebSocket(conn, websocket.CloseNormalClosure, "session closed")
+			}
+			if closed, err := b.sendSession(ctx, conn, gameId); err != nil || closed {
+				return err
+			}
+		}
+	}
+}
+
+// sendSession sends current session state, closes connection if session doesn't exist anymore
+func (b *sessionEventsBridge) sendSession(ctx context.Context, conn *websocket.Conn, gameId uuid.UUID) (closed bool, err error) {
+	game, err := b.sessions.GetSession(ctx, gameId)
+	if errors.Is(err, ErrSessionNotFound) {
+		if err = writeSessionEvent(conn, sessionEvent{Closed: true}); err != nil {
+			return true, err
+		}
+		return true, closeWebSocket(conn, websocket.CloseNormalClosure, "session closed")
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, writeSessionEvent(conn, sessionEvent{Session: &game.GameSession})
+}
+
+func writeSessionEvent(conn *websocket.Conn, event sessionEvent) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(sessionEventsWriteTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(event)
+}
+
+func closeWebSocket(conn *websocket.Conn, code int, reason string) error {
+	return conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(sessionEventsWriteTimeout))
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap"
+)
+
+// simulationMaxSteps stops games that never finish, the longest legal game takes about a hundred steps
+const simulationMaxSteps = 500
+
+// SimulationConfig describes a batch of games played by bots against simpleGameService
+type SimulationConfig struct {
+	PlayerCounts  []int //Each count from 5 to 10
+	GamesPerCount int
+	GoodStrategy  string //Name of strategy from botStrategies for virtuous players
+	EvilStrategy  string
+	Seed          int64 //Same seed gives the same games
+}
+
+// SimulationStats sums up games with the same number of players
+type SimulationStats struct {
+	Games        int
+	VirtuousWins int
+	Reasons      map[api.EndgameOutcome_Reason]int
+	RoleGames    map[botRole]int //Number of players of each role in all games
+	RoleWins     map[botRole]int
+}
+
+func newSimulationStats() *SimulationStats {
+	return &SimulationStats{
+		Reasons:   make(map[api.EndgameOutcome_Reason]int),
+		RoleGames: make(map[botRole]int),
+		RoleWins:  make(map[botRole]int),
+	}
+}
+
+func (s *SimulationStats) WinRate(role botRole) float64 {
+	if s.RoleGames[role] == 0 {
+		return 0
+	}
+	return float64(s.RoleWins[role]) / float64(s.RoleGames[role])
+}
+
+type SimulationReport struct {
+	Config    SimulationConfig
+	ByPlayers map[int]*SimulationStats
+}
+
+func (r *SimulationReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (good) vs %s (evil), seed %d\n", r.Config.GoodStrategy, r.Config.EvilStrategy, r.Config.Seed)
+	counts := make([]int, 0, len(r.ByPlayers))
+	for players := range r.ByPlayers {
+		counts = append(counts, players)
+	}
+	sort.Ints(counts)
+	for _, players := range counts {
+		stats := r.ByPlayers[players]
+		fmt.Fprintf(&b, "%2d players, %d games, win rates:", players, stats.Games)
+		for _, role := range []botRole{roleMerlin, roleServant, roleAssassin, roleMinion} {
+			fmt.Fprintf(&b, " %s %.1f%%", role, 100*stats.WinRate(role))
+		}
+		b.WriteString("; endings:")
+		for reason := api.EndgameOutcome_MISSIONS_PASSED; reason <= api.EndgameOutcome_ASSASSINATION_FAILED; reason++ {
+			fmt.Fprintf(&b, " %s %d", reason, stats.Reasons[reason])
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// runSimulation serves `avalonBacker simulate` subcommand, writing report of simulated games to out
+func runSimulation(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("avalonBacker simulate", flag.ContinueOnError)
+	players := flags.String("players", "5,6,7,8,9,10", "comma separated numbers of players to simulate games of")
+	games := flags.Int("games", 100, "number of games per number of players")
+	good := flags.String("good", "random", "strategy of virtuous bots: "+strings.Join(botStrategyNames(), ", "))
+	evil := flags.String("evil", "random", "strategy of evil bots")
+	seed := flags.Int64("seed", 0, "seed of simulated games, random if not set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := SimulationConfig{GamesPerCount: *games, GoodStrategy: *good, EvilStrategy: *evil, Seed: *seed}
+	for _, count := range strings.Split(*players, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil {
+			return fmt.Errorf("invalid number of players %q", count)
+		}
+		cfg.PlayerCounts = append(cfg.PlayerCounts, n)
+	}
+	if cfg.Seed == 0 {
+		var err error
+		if cfg.Seed, err = newGameSeed(); err != nil {
+			return fmt.Errorf("failed to seed simulation: %w", err)
+		}
+	}
+
+	report, err := Simulate(context.Background(), cfg, zap.NewNop())
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(out, report)
+	return err
+}
+
+// Simulate plays games with bots and reports how they ended.
+// Any error returned by GameService or broken game invariant fails the simulation,
+// so it is also a regression suite for the game state machine.
+func Simulate(ctx context.Context, cfg SimulationConfig, logger *zap.Logger) (*SimulationReport, error) {
+	good, goodExist := botStrategies[cfg.GoodStrategy]
+	evil, evilExist := botStrategies[cfg.EvilStrategy]
+	if !goodExist || !evilExist {
+		return nil, fmt.Errorf("unknown bot strategy, expected one of: %s", strings.Join(botStrategyNames(), ", "))
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	service := NewGameService(NewMemoryStorage(time.Hour), NewVoteStorage(logger), logger)
+	service.newSeed = func() (int64, error) {
+		return rng.Int63(), nil
+	}
+
+	report := &SimulationReport{Config: cfg, ByPlayers: make(map[int]*SimulationStats)}
+	for _, players := range cfg.PlayerCounts {
+		if _, supported := builtinRules.PlayerCounts[players]; !supported {
+			return nil, fmt.Errorf("can't simulate games of %d players", players)
+		}
+		stats := newSimulationStats()
+		report.ByPlayers[players] = stats
+		for n := 0; n < cfg.GamesPerCount; n++ {
+			sim := newSimulatedGame(players, good, evil, rng)
+			if err := sim.play(ctx, service); err != nil {
+				return nil, fmt.Errorf("game %d of %d players: %w", n+1, players, err)
+			}
+			sim.addTo(stats)
+			//Finished games are of no use anymore
+			if _, err := service.TerminateSession(ctx, sim.session); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return report, nil
+}
+
+// simulatedGame drives single game session with bots, one bot per player
+type simulatedGame struct {
+	config     *api.GameConfig
+	seats      []*api.Player //Bots act in this order, so games are reproducible
+	views      map[uint64]*botView
+	strategies map[uint64]BotStrategy
+	session    *api.GameSession
+	proposal   *teamProposal //Team of current round
+}
+
+func newSimulatedGame(players int, good, evil BotStrategy, rng *rand.Rand) *simulatedGame {
+	seats := make([]*api.Player, 0, players)
+	for id := 1; id <= players; id++ {
+		seats = append(seats, &api.Player{Id: uint64(id), UserName: fmt.Sprint("bot", id)})
+	}
+	sim := &simulatedGame{
+		config:     dealRoles(0, seats, rng),
+		seats:      seats,
+		views:      make(map[uint64]*botView),
+		strategies: make(map[uint64]BotStrategy),
+	}
+	for _, p := range seats {
+		view := newBotView(p, seats, sim.config.GoodTeam, sim.config.EvilTeam, rng)
+		sim.views[p.Id] = view
+		sim.strategies[p.Id] = good
+		if view.role.evil() {
+			sim.strategies[p.Id] = evil
+		}
+	}
+	return sim
+}
+
+func (s *simulatedGame) play(ctx context.Context, service api.GameServiceServer) error {
+	var err error
+	if s.session, err = service.CreateSession(ctx, s.config); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	for step := 0; step < simulationMaxSteps; step++ {
+		if err = checkSessionInvariants(s.session, s.seats); err != nil {
+			return err
+		}
+		if isGameOver(s.session.State) {
+			return nil
+		}
+		if err = s.act(ctx, service); err != nil {
+			return fmt.Errorf("%v: %w", s.session.State, err)
+		}
+	}
+	return fmt.Errorf("game did not finish in %d steps", simulationMaxSteps)
+}
+
+// act makes bots do what current state requires and pushes game to the next state
+func (s *simulatedGame) act(ctx context.Context, service api.GameServiceServer) error {
+	switch s.session.State {
+	case api.GameSession_MISSION_TEAM_PICKING:
+		mission, err := service.GetPendingMission(ctx, s.session)
+		if err != nil {
+			return err
+		}
+		leader := s.views[s.session.Leader.GetId()]
+		team := s.strategies[leader.self.Id].ProposeTeam(leader, int(mission.TeamSize))
+		s.proposal = &teamProposal{team: team, votes: make(map[uint64]bool)}
+		_, err = service.AssignMissionTeam(ctx, &api.AssignTeamContext{Session: s.session, Team: &api.MissionTeam{Members: team}})
+		if err != nil {
+			return err
+		}
+	case api.GameSession_MISSION_TEAM_VOTING:
+		for _, p := range s.seats {
+			view := s.views[p.Id]
+			approve := s.strategies[p.Id].VoteForTeam(view, s.proposal.team)
+			if _, err := service.VoteForMissionTeam(ctx, &api.VoteContext{Session: s.session, Voter: view.self, Vote: voteOption(approve)}); err != nil {
+				return err
+			}
+			s.proposal.votes[p.Id] = approve
+		}
+		for _, view := range s.views {
+			view.proposals = append(view.proposals, s.proposal)
+		}
+	case api.GameSession_MISSION_SUCCESS_VOTING:
+		for _, p := range s.proposal.team {
+			view := s.views[p.Id]
+			success := !view.role.evil() || s.strategies[p.Id].VoteForMission(view)
+			if _, err := service.VoteForMissionSuccess(ctx, &api.VoteContext{Session: s.session, Voter: p, Vote: voteOption(success)}); err != nil {
+				return err
+			}
+		}
+	case api.GameSession_MISSION_ENDED:
+		s.proposal.failed = s.session.GetLastMissionResult().GetFailed()
+	case api.GameSession_POST_MISSIONS_ACTIONS:
+		if s.session.MissionsPassed >= s.session.MissionsFailed {
+			assassin := s.views[s.config.EvilTeam.Assassin.Id]
+			target := s.strategies[assassin.self.Id].GuessMerlin(assassin)
+			outcome, err := service.AssassinateAllegedMerlin(ctx, &api.AssassinationContext{Session: s.session, Target: target})
+			if err != nil {
+				return err
+			}
+			if outcome.MerlinWasKilled != (target.Id == s.config.GoodTeam.Merlin.Id) {
+				return errors.New("assassination outcome does not match Merlin identity")
+			}
+			s.session = outcome.Session
+			return nil
+		}
+	}
+
+	session, err := service.PushGameState(ctx, s.session)
+	if err != nil {
+		return err
+	}
+	s.session = session
+	return nil
+}
+
+func voteOption(positive bool) api.VoteContext_VoteOption {
+	if positive {
+		return api.VoteContext_POSITIVE
+	}
+	return api.VoteContext_NEGATIVE
+}
+
+func (s *simulatedGame) addTo(stats *SimulationStats) {
+	virtuousWon := s.session.State == api.GameSession_VIRTUOUS_TEAM_WON
+	stats.Games++
+	if virtuousWon {
+		stats.VirtuousWins++
+	}
+	stats.Reasons[s.session.EndgameOutcome.GetReason()]++
+	for _, view := range s.views {
+		stats.RoleGames[view.role]++
+		if view.role.evil() != virtuousWon {
+			stats.RoleWins[view.role]++
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap"
+)
+
+// Run with -v -simulation.games=1000 to get meaningful win rates
+var simulationGames = flag.Int("simulation.games", 5, "number of simulated games per strategies pair and player count")
+
+func TestSimulation(t *testing.T) {
+	for _, good := range botStrategyNames() {
+		for _, evil := range botStrategyNames() {
+			t.Run(good+"/"+evil, func(t *testing.T) {
+				report, err := Simulate(context.Background(), SimulationConfig{
+					PlayerCounts:  []int{5, 6, 7, 8, 9, 10},
+					GamesPerCount: *simulationGames,
+					GoodStrategy:  good,
+					EvilStrategy:  evil,
+					Seed:          437,
+				}, zap.NewNop())
+				if err != nil {
+					t.Fatal(err)
+				}
+				for players, stats := range report.ByPlayers {
+					if stats.Games != *simulationGames || stats.RoleGames[roleMerlin] != stats.Games {
+						t.Errorf("%d players: %d games, %d merlins; want %d", players, stats.Games, stats.RoleGames[roleMerlin], *simulationGames)
+					}
+				}
+				t.Log(report)
+			})
+		}
+	}
+}
+
+func TestBotViewOfSpecialRoles(t *testing.T) {
+	good, evil := specialRolesTeams()
+	players := append(append([]*api.Player(nil), good.Members...), evil.Members...)
+	view := func(p *api.Player) *botView {
+		return newBotView(p, players, good, evil, rand.New(rand.NewSource(1)))
+	}
+
+	for self, want := range map[*api.Player]map[*api.Player]bool{
+		good.Merlin:   {evil.Assassin: true, evil.Oberon: true, evil.Mordred: false},
+		good.Percival: {evil.Assassin: false, evil.Morgana: false},
+		evil.Assassin: {evil.Morgana: true, evil.Mordred: true, evil.Oberon: false},
+		evil.Oberon:   {evil.Oberon: true, evil.Assassin: false, evil.Mordred: false},
+	} {
+		v := view(self)
+		for p, known := range want {
+			if v.isKnownEvil(p) != known {
+				t.Errorf("player %d knows %d is evil: %v; want %v", self.Id, p.Id, !known, known)
+			}
+		}
+	}
+	if known := view(good.Percival).knownMerlin; !reflect.DeepEqual(known, []*api.Player{good.Merlin, evil.Morgana}) {
+		t.Errorf("Percival knows %v as Merlin; want Merlin and Morgana", known)
+	}
+	if known := view(good.Merlin).knownMerlin; known != nil {
+		t.Errorf("Merlin got Percival knowledge %v", known)
+	}
+}
+
+func TestSimulationIsReproducible(t *testing.T) {
+	cfg := SimulationConfig{PlayerCounts: []int{5, 7}, GamesPerCount: 20, GoodStrategy: "random", EvilStrategy: "merlin-hinting", Seed: 1}
+	first, err := Simulate(context.Background(), cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Simulate(context.Background(), cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("simulations with the same seed differ:\n%v\n%v", first, second)
+	}
+
+	cfg.GoodStrategy = "cheating"
+	if _, err = Simulate(context.Background(), cfg, zap.NewNop()); err == nil {
+		t.Error("simulation with unknown strategy succeeded")
+	}
+}
+
+func TestRunSimulation(t *testing.T) {
+	var out bytes.Buffer
+	if err := runSimulation([]string{"-players", "5, 7", "-games", "3", "-good", "merlin-hinting", "-seed", "437"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	report := out.String()
+	if !strings.Contains(report, "seed 437") || !strings.Contains(report, " 5 players, 3 games") || !strings.Contains(report, " 7 players, 3 games") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+
+	for _, args := range [][]string{{"-players", "5,x"}, {"-players", "4"}, {"-evil", "cheating"}} {
+		if err := runSimulation(args, &out); err == nil {
+			t.Errorf("simulation with %v succeeded", args)
+		}
+	}
+}
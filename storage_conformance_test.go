@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestGame(chatId int64, createdAt time.Time, players ...uint64) *GameInstance {
+	game := new(GameInstance)
+	game.GameId = &api.UUID{Value: uuid.New().String()}
+	game.State = api.GameSession_GAME_CREATED
+	game.GameSession.ChatId = chatId
+	game.CreatedAt = createdAt.UTC()
+	for _, id := range players {
+		game.AllPlayers = append(game.AllPlayers, &api.Player{Id: id})
+	}
+	return game
+}
+
+// fullTestGame returns a game with every GameInstance field set
+func fullTestGame() *GameInstance {
+	players := []*api.Player{{Id: 1, UserName: "merlin"}, {Id: 2}, {Id: 3}, {Id: 4, UserName: "assassin"}, {Id: 5, UserName: "bot", Bot: true}}
+
+	game := newTestGame(-100, time.Now().Truncate(time.Millisecond), 1, 2, 3, 4, 5)
+	game.AllPlayers = players
+	game.State = api.GameSession_MISSION_SUCCESS_VOTING
+	game.EndgameReason = "reason"
+	game.EndgameOutcome = &api.EndgameOutcome{Reason: api.EndgameOutcome_ASSASSINATION_FAILED, MissionsPassed: 1, MissionsFailed: 2, RejectedProposals: 3, Assassinated: players[1]}
+	game.GameSession.Locale = localeEnglish
+	game.Leader = players[2]
+	game.LastMissionResult = &api.MissionResult{Failed: true, PositiveVotes: 1, NegativeVotes: 2}
+	game.LastTeamVote = &api.TeamVoteResult{Approved: true, Approvals: 3, Rejections: 1, Abstentions: 1}
+	game.MissionsPassed = 1
+	game.MissionsFailed = 2
+	game.GoodTeam = &api.VirtuousTeam{Members: players[:3], Merlin: players[0], Percival: players[1]}
+	game.EvilTeam = &api.EvilTeam{Members: players[3:], Assassin: players[3], Oberon: players[4], Morgana: players[4], Mordred: players[4]}
+	game.GameConfig.ChatId = -100
+	game.GameConfig.Locale = localeEnglish
+	game.GameConfig.Rules = defaultRules().Proto()
+	game.Extensions = &api.GameExtensions{PercivalAndMorgana: true, Oberon: true, Mordred: true}
+	game.MissionTeam = api.MissionTeam{Members: players[1:3]}
+	game.Mission = api.PendingMission{MissionNumber: 3, TeamPickingAttempts: 2}
+	game.CurrentLeaderIndex = 2
+	game.Seed = 42
+	return game
+}
+
+// testGameSessionStorage checks behaviour every GameSessionStorage implementation must share.
+// newStorage must return an empty storage for every call.
+// Storages are allowed to keep CreatedAt with millisecond precision only.
+func testGameSessionStorage(t *testing.T, newStorage func(t *testing.T) GameSessionStorage) {
+	ctx := context.Background()
+	t.Run("RoundTrip", func(t *testing.T) {
+		stor := newStorage(t)
+		game := fullTestGame()
+		//Memory storage keeps the stored pointer, so compare with a copy made before storing
+		want := cloneGameInstance(game)
+		if err := stor.StoreSession(ctx, game); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := stor.GetSession(ctx, testGameId(game.GameId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("fetched session differs from stored one:\n got: %+v\nwant: %+v", got, want)
+		}
+	})
+
+	t.Run("BotPlayer", func(t *testing.T) {
+		stor := newStorage(t)
+		game := fullTestGame()
+		bot := seatBot(1, localeEnglish)
+		game.AllPlayers[4], game.EvilTeam.Members[1] = bot, bot
+		game.EvilTeam.Oberon, game.EvilTeam.Morgana, game.EvilTeam.Mordred = bot, bot, bot
+		game.Leader = bot
+		want := cloneGameInstance(game)
+		if err := stor.StoreSession(ctx, game); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := stor.GetSession(ctx, testGameId(game.GameId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("fetched session differs from stored one:\n got: %+v\nwant: %+v", got, want)
+		}
+		if found, err := stor.FindSessionsByPlayer(ctx, bot.Id); err != nil || len(found) != 1 {
+			t.Errorf("FindSessionsByPlayer(%d) = %d sessions, %v; want 1", bot.Id, len(found), err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		stor := newStorage(t)
+		game := fullTestGame()
+		if err := stor.StoreSession(ctx, game); err != nil {
+			t.Fatal(err)
+		}
+
+		game.State = api.GameSession_MISSION_ENDED
+		game.MissionsPassed++
+		if err := stor.StoreSession(ctx, game); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := stor.GetSession(ctx, testGameId(game.GameId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.State != api.GameSession_MISSION_ENDED || got.MissionsPassed != game.MissionsPassed {
+			t.Errorf("session was not updated: %+v", got)
+		}
+		if n, _ := stor.NumberOfGames(ctx); n != 1 {
+			t.Errorf("NumberOfGames after update = %d; want 1", n)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		stor := newStorage(t)
+		id := uuid.New()
+
+		if _, err := stor.GetSession(ctx, id); err != ErrSessionNotFound {
+			t.Errorf("GetSession of unknown session = %v; want ErrSessionNotFound", err)
+		}
+		if exist, err := stor.CheckExistence(ctx, id); err != nil || exist {
+			t.Errorf("CheckExistence of unknown session = %v, %v; want false, nil", exist, err)
+		}
+		if err := stor.CloseSession(ctx, id); err != nil {
+			t.Errorf("CloseSession of unknown session = %v; want nil", err)
+		}
+	})
+
+	t.Run("CloseSession", func(t *testing.T) {
+		stor := newStorage(t)
+		game := fullTestGame()
+		other := newTestGame(0, time.Now(), 1)
+		for _, g := range []*GameInstance{game, other} {
+			if err := stor.StoreSession(ctx, g); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		id := testGameId(game.GameId)
+		if exist, err := stor.CheckExistence(ctx, id); err != nil || !exist {
+			t.Errorf("CheckExistence = %v, %v; want true, nil", exist, err)
+		}
+		if err := stor.CloseSession(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if exist, err := stor.CheckExistence(ctx, id); err != nil || exist {
+			t.Errorf("CheckExistence after close = %v, %v; want false, nil", exist, err)
+		}
+		if _, err := stor.GetSession(ctx, id); err != ErrSessionNotFound {
+			t.Errorf("GetSession after close = %v; want ErrSessionNotFound", err)
+		}
+		if games, _ := stor.ListSessions(ctx, SessionFilter{}); len(games) != 1 {
+			t.Errorf("ListSessions after close returned %d sessions; want 1", len(games))
+		}
+		if _, err := stor.GetSession(ctx, testGameId(other.GameId)); err != nil {
+			t.Errorf("closing one session affected another: %v", err)
+		}
+	})
+
+	t.Run("NumberOfGames", func(t *testing.T) {
+		stor := newStorage(t)
+		if n, err := stor.NumberOfGames(ctx); err != nil || n != 0 {
+			t.Errorf("NumberOfGames of empty storage = %d, %v; want 0", n, err)
+		}
+
+		games := []*GameInstance{
+			newTestGame(0, time.Now(), 1), newTestGame(0, time.Now(), 2), newTestGame(0, time.Now(), 3),
+		}
+		for n, game := range games {
+			if err := stor.StoreSession(ctx, game); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := stor.NumberOfGames(ctx); got != uint(n+1) {
+				t.Errorf("NumberOfGames = %d; want %d", got, n+1)
+			}
+		}
+
+		if err := stor.CloseSession(ctx, testGameId(games[0].GameId)); err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := stor.NumberOfGames(ctx); n != 2 {
+			t.Errorf("NumberOfGames after close = %d; want 2", n)
+		}
+	})
+
+	t.Run("ConcurrentWriters", func(t *testing.T) {
+		stor := newStorage(t)
+		const writers, updates = 8, 10
+
+		games := make([]*GameInstance, writers)
+		var wg sync.WaitGroup
+		errs := make(chan error, writers*updates*2)
+		for n := range games {
+			games[n] = newTestGame(int64(n), time.Now(), uint64(n))
+			wg.Add(1)
+			go func(game *GameInstance) {
+				defer wg.Done()
+				for u := 0; u < updates; u++ {
+					update := *game
+					update.MissionsPassed = int32(u)
+					if err := stor.StoreSession(ctx, &update); err != nil {
+						errs <- err
+					}
+					if _, err := stor.GetSession(ctx, testGameId(game.GameId)); err != nil {
+						errs <- err
+					}
+				}
+			}(games[n])
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+
+		if n, _ := stor.NumberOfGames(ctx); n != writers {
+			t.Errorf("NumberOfGames = %d; want %d", n, writers)
+		}
+		for _, game := range games {
+			got, err := stor.GetSession(ctx, testGameId(game.GameId))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.MissionsPassed != updates-1 {
+				t.Errorf("last write was lost: MissionsPassed = %d; want %d", got.MissionsPassed, updates-1)
+			}
+		}
+	})
+
+	t.Run("ListSessions", func(t *testing.T) {
+		stor := newStorage(t)
+		start := time.Now().Truncate(time.Millisecond)
+		games := []*GameInstance{
+			newTestGame(-1, start, 1, 2),
+			newTestGame(-2, start.Add(time.Second), 2, 3),
+			newTestGame(-1, start.Add(2*time.Second), 3, 4),
+		}
+		games[2].State = api.GameSession_VIRTUOUS_TEAM_WON
+		//Store out of order to make sure sessions are sorted by creation time
+		for _, n := range []int{2, 0, 1} {
+			if err := stor.StoreSession(ctx, games[n]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cases := []struct {
+			name   string
+			filter SessionFilter
+			want   []*GameInstance
+		}{
+			{"all", SessionFilter{}, games},
+			{"by player", SessionFilter{PlayerId: 2}, games[:2]},
+			{"by chat", SessionFilter{ChatId: -1}, []*GameInstance{games[0], games[2]}},
+			{"by state", SessionFilter{States: activeGameStates()}, games[:2]},
+			{"created after", SessionFilter{CreatedAfter: start}, games[1:]},
+			{"paginated", SessionFilter{Offset: 1, Limit: 1}, games[1:2]},
+			{"offset past end", SessionFilter{Offset: 5}, nil},
+		}
+		for _, c := range cases {
+			got, err := stor.ListSessions(ctx, c.filter)
+			if err != nil {
+				t.Fatal(c.name, err)
+			}
+			if len(got) != len(c.want) {
+				t.Errorf("%s: got %d sessions; want %d", c.name, len(got), len(c.want))
+				continue
+			}
+			for n := range got {
+				if got[n].GameId.Value != c.want[n].GameId.Value {
+					t.Errorf("%s: session #%d is %s; want %s", c.name, n, got[n].GameId.Value, c.want[n].GameId.Value)
+				}
+			}
+		}
+
+		byPlayer, err := stor.FindSessionsByPlayer(ctx, 3)
+		if err != nil || len(byPlayer) != 2 || byPlayer[0].GameId.Value != games[1].GameId.Value {
+			t.Errorf("FindSessionsByPlayer = %d sessions, %v; want 2 oldest first", len(byPlayer), err)
+		}
+	})
+}
+
+func TestMemoryStorageConformance(t *testing.T) {
+	testGameSessionStorage(t, func(t *testing.T) GameSessionStorage {
+		return NewMemoryStorage(time.Minute)
+	})
+}
+
+func TestRedisSessionStorageConformance(t *testing.T) {
+	testGameSessionStorage(t, func(t *testing.T) GameSessionStorage {
+		_, rdb := newTestRedis(t)
+		return NewRedisSessionStorage(rdb, time.Minute, zaptest.NewLogger(t))
+	})
+}
+
+func TestBoltStorageConformance(t *testing.T) {
+	testGameSessionStorage(t, func(t *testing.T) GameSessionStorage {
+		return newTestBoltStorage(t)
+	})
+}
+
+func TestMongoSessionStorageConformance(t *testing.T) {
+	testGameSessionStorage(t, func(t *testing.T) GameSessionStorage {
+		return newTestMongoStorage(t)
+	})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultTelegramAPIURL = "https://api.telegram.org"
+
+// telegramClient calls Telegram Bot API methods, see https://core.telegram.org/bots/api
+type telegramClient struct {
+	endpoint string //API URL with bot token, method name is appended to it
+	http     *http.Client
+}
+
+func newTelegramClient(apiURL, token string, pollTimeout time.Duration) *telegramClient {
+	return &telegramClient{
+		endpoint: strings.TrimRight(apiURL, "/") + "/bot" + token + "/",
+		//getUpdates holds connection for up to poll timeout, so client timeout must be longer
+		http: &http.Client{Timeout: pollTimeout + 10*time.Second},
+	}
+}
+
+type tgUser struct {
+	Id           int64  `json:"id"`
+	UserName     string `json:"username,omitempty"`
+	FirstName    string `json:"first_name,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"` //IETF language tag of user's Telegram client
+}
+
+type tgChat struct {
+	Id   int64  `json:"id"`
+	Type string `json:"type"` //private, group, supergroup or channel
+}
+
+type tgMessage struct {
+	MessageId int64   `json:"message_id"`
+	From      *tgUser `json:"from,omitempty"`
+	Chat      tgChat  `json:"chat"`
+	Text      string  `json:"text,omitempty"`
+}
+
+type tgCallbackQuery struct {
+	Id      string     `json:"id"`
+	From    tgUser     `json:"from"`
+	Message *tgMessage `json:"message,omitempty"` //Message with the pressed button
+	Data    string     `json:"data"`
+}
+
+type tgUpdate struct {
+	UpdateId      int64            `json:"update_id"`
+	Message       *tgMessage       `json:"message,omitempty"`
+	CallbackQuery *tgCallbackQuery `json:"callback_query,omitempty"`
+}
+
+type tgInlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type tgInlineKeyboardMarkup struct {
+	InlineKeyboard [][]tgInlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type tgSendMessageRequest struct {
+	ChatId      int64                   `json:"chat_id"`
+	Text        string                  `json:"text"`
+	ReplyMarkup *tgInlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type tgEditMessageReplyMarkupRequest struct {
+	ChatId      int64                   `json:"chat_id"`
+	MessageId   int64                   `json:"message_id"`
+	ReplyMarkup *tgInlineKeyboardMarkup `json:"reply_markup,omitempty"` //Keyboard is removed if not set
+}
+
+type tgAnswerCallbackQueryRequest struct {
+	CallbackQueryId string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+}
+
+type tgGetUpdatesRequest struct {
+	Offset         int64    `json:"offset,omitempty"`
+	Timeout        int      `json:"timeout"` //Seconds of long polling
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+// tgResponse is an envelope of every Bot API response
+type tgResponse struct {
+	Ok          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+}
+
+// call invokes Bot API method with JSON encoded params and decodes its result into result, if it's not nil
+func (c *telegramClient) call(ctx context.Context, method string, params, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := c.http.Do(req)
+	if err != nil {
+		//Error of http client contains request URL with bot token
+		return fmt.Errorf("failed to call %s: %w", method, scrubURLError(err))
+	}
+	defer httpResp.Body.Close()
+
+	var resp tgResponse
+	if err = json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return fmt.Errorf("failed to decode %s response, HTTP status %d: %w", method, httpResp.StatusCode, err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("%s failed with code %d: %s", method, resp.ErrorCode, resp.Description)
+	}
+	if result != nil {
+		if err = json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+func (c *telegramClient) getUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]tgUpdate, error) {
+	var updates []tgUpdate
+	err := c.call(ctx, "getUpdates", tgGetUpdatesRequest{
+		Offset:         offset,
+		Timeout:        int(timeout / time.Second),
+		AllowedUpdates: []string{"message", "callback_query"},
+	}, &updates)
+	return updates, err
+}
+
+func (c *telegramClient) sendMessage(ctx context.Context, req tgSendMessageRequest) (*tgMessage, error) {
+	msg := new(tgMessage)
+	return msg, c.call(ctx, "sendMessage", req, msg)
+}
+
+func (c *telegramClient) editMessageReplyMarkup(ctx context.Context, req tgEditMessageReplyMarkupRequest) error {
+	return c.call(ctx, "editMessageReplyMarkup", req, nil)
+}
+
+func (c *telegramClient) answerCallbackQuery(ctx context.Context, req tgAnswerCallbackQueryRequest) error {
+	return c.call(ctx, "answerCallbackQuery", req, nil)
+}
+
+// scrubURLError drops URL from *url.Error, so bot token does not end up in logs
+func scrubURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
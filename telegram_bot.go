@@ -0,0 +1,862 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap"
+)
+
+const (
+	telegramMinPlayers    = 5
+	telegramMaxPlayers    = 10
+	telegramRetryDelay    = 5 * time.Second  //Pause after failed getUpdates call
+	telegramUpdateTimeout = 30 * time.Second //Time to handle single update, including all API calls
+	//Telegram allows a single getUpdates poller per bot, so replicas take turns by lease
+	telegramLeaseName = "telegram-bot"
+	telegramLeaseTTL  = 15 * time.Second
+)
+
+// telegramBot plays games in Telegram group chats, driving GameService through Bot API.
+// Updates are handled one by one in Run, so bot state needs no locking.
+// Lobbies and rounds in progress are kept in memory and are lost on restart or when another replica takes the bot over.
+type telegramBot struct {
+	client      *telegramClient
+	service     api.GameServiceServer
+	pollTimeout time.Duration
+	seatBots    int
+	botStrategy BotStrategy
+	logger      *zap.Logger
+
+	lobbies map[int64]*telegramLobby //Games that are not started yet by chat id
+	games   map[int64]*telegramGame  //Games in progress by chat id
+}
+
+// telegramLobby gathers players for a game
+type telegramLobby struct {
+	players []*api.Player
+	locale  string //Locale of the game, chosen by player opening the lobby
+}
+
+// telegramGame tracks a game session and the round players are acting in
+type telegramGame struct {
+	chatId  int64
+	locale  string           //Session locale, used for every text of the game
+	session *api.GameSession //Session as returned by last GameService call
+	players []*api.Player
+	good    *api.VirtuousTeam
+	evil    *api.EvilTeam
+
+	mission   api.PendingMission
+	picked    []uint64        //Players picked by leader so far, in order of picking
+	team      []*api.Player   //Team assigned to pending mission
+	votes     map[uint64]bool //Votes of current team or mission voting
+	abstained map[uint64]bool //Players who abstained in current team voting, also present in votes
+	keyboard  int64           //Group message with team picking or voting keyboard, 0 if none
+
+	bots map[uint64]*botView //Views of bots seated in game by player id
+}
+
+func NewTelegramBot(cfg TelegramConfig, service api.GameServiceServer, logger *zap.Logger) *telegramBot {
+	return &telegramBot{
+		client:      newTelegramClient(cfg.APIURL, cfg.Token, cfg.PollTimeout),
+		service:     service,
+		pollTimeout: cfg.PollTimeout,
+		seatBots:    cfg.SeatBots,
+		botStrategy: botStrategies[cfg.BotStrategy],
+		logger:      logger,
+		lobbies:     make(map[int64]*telegramLobby),
+		games:       make(map[int64]*telegramGame),
+	}
+}
+
+// Run polls Bot API for updates until ctx is cancelled.
+// Update being handled at that moment is finished with its own timeout.
+func (b *telegramBot) Run(ctx context.Context) {
+	b.logger.Info("starting telegram bot")
+	var offset int64
+	for {
+		updates, err := b.client.getUpdates(ctx, offset, b.pollTimeout)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			b.logger.Warn("failed to get telegram updates", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(telegramRetryDelay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateId + 1
+			updateCtx, cancel := context.WithTimeout(context.Background(), telegramUpdateTimeout)
+			b.handleUpdate(updateCtx, update)
+			cancel()
+		}
+	}
+}
+
+func (b *telegramBot) handleUpdate(ctx context.Context, update tgUpdate) {
+	logger := b.logger.With(zap.Int64("update_id", update.UpdateId))
+	ctx = contextWithLogger(ctx, logger)
+
+	switch {
+	case update.Message != nil && update.Message.From != nil:
+		if err := b.handleCommand(ctx, update.Message); err != nil {
+			logger.Error("failed to handle telegram command", zap.String("text", update.Message.Text), zap.Error(err))
+			b.send(ctx, update.Message.Chat.Id, botText(b.chatLocale(update.Message.Chat.Id, update.Message.From), msgFailed), nil)
+		}
+	case update.CallbackQuery != nil:
+		query := update.CallbackQuery
+		answer, err := b.handleCallback(ctx, query)
+		if err != nil {
+			logger.Error("failed to handle telegram callback", zap.String("data", query.Data), zap.Error(err))
+			answer = botText(userLocale(&query.From), msgFailed)
+		}
+		if err = b.client.answerCallbackQuery(ctx, tgAnswerCallbackQueryRequest{CallbackQueryId: query.Id, Text: answer}); err != nil {
+			logger.Warn("failed to answer telegram callback", zap.Error(err))
+		}
+	}
+}
+
+// send posts a message, failures are only logged since there is no one to report them to
+func (b *telegramBot) send(ctx context.Context, chatId int64, text string, keyboard *tgInlineKeyboardMarkup) int64 {
+	msg, err := b.client.sendMessage(ctx, tgSendMessageRequest{ChatId: chatId, Text: text, ReplyMarkup: keyboard})
+	if err != nil {
+		loggerFromContext(ctx, b.logger).Warn("failed to send telegram message", zap.Int64("chat_id", chatId), zap.Error(err))
+		return 0
+	}
+	return msg.MessageId
+}
+
+// removeKeyboard makes buttons of already finished round disappear
+func (b *telegramBot) removeKeyboard(ctx context.Context, chatId, messageId int64) {
+	if messageId == 0 {
+		return
+	}
+	err := b.client.editMessageReplyMarkup(ctx, tgEditMessageReplyMarkupRequest{ChatId: chatId, MessageId: messageId})
+	if err != nil {
+		loggerFromContext(ctx, b.logger).Warn("failed to remove telegram keyboard", zap.Int64("chat_id", chatId), zap.Error(err))
+	}
+}
+
+// userLocale is the locale of user's Telegram client if it is supported, default one otherwise
+func userLocale(user *tgUser) string {
+	if locale, err := normalizeLocale(user.LanguageCode); err == nil {
+		return locale
+	}
+	return defaultLocale
+}
+
+// chatLocale is the locale of game or lobby in chat, locale of user otherwise
+func (b *telegramBot) chatLocale(chatId int64, user *tgUser) string {
+	if game, exist := b.games[chatId]; exist {
+		return game.locale
+	}
+	if lobby, exist := b.lobbies[chatId]; exist {
+		return lobby.locale
+	}
+	return userLocale(user)
+}
+
+func (b *telegramBot) handleCommand(ctx context.Context, msg *tgMessage) error {
+	fields := strings.Fields(msg.Text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return nil
+	}
+	//In groups commands are sent as /command@BotName
+	command := strings.ToLower(strings.SplitN(fields[0], "@", 2)[0])
+
+	locale := b.chatLocale(msg.Chat.Id, msg.From)
+	if msg.Chat.Type == "private" {
+		b.send(ctx, msg.Chat.Id, botText(locale, msgPrivateChat)+"\n\n"+botText(locale, msgHelp), nil)
+		return nil
+	}
+
+	player := telegramPlayer(msg.From)
+	switch command {
+	case "/newgame":
+		//Locale could be chosen explicitly, e.g. /newgame en
+		if len(fields) > 1 {
+			if chosen, err := normalizeLocale(fields[1]); err == nil {
+				locale = chosen
+			}
+		}
+		return b.openLobby(ctx, msg.Chat.Id, player, locale)
+	case "/join":
+		b.joinLobby(ctx, msg.Chat.Id, player, locale)
+	case "/leave":
+		b.leaveLobby(ctx, msg.Chat.Id, player)
+	case "/startgame":
+		return b.startGame(ctx, msg.Chat.Id, player, locale)
+	case "/cancel":
+		return b.cancel(ctx, msg.Chat.Id, player, locale)
+	case "/help", "/start":
+		b.send(ctx, msg.Chat.Id, botText(locale, msgHelp), nil)
+	}
+	return nil
+}
+
+func telegramPlayer(user *tgUser) *api.Player {
+	name := user.FirstName
+	if user.UserName != "" {
+		name = "@" + user.UserName
+	}
+	return &api.Player{Id: uint64(user.Id), UserName: name}
+}
+
+func playerName(p *api.Player) string {
+	if p.GetUserName() != "" {
+		return p.GetUserName()
+	}
+	return strconv.FormatUint(p.GetId(), 10)
+}
+
+func playerNames(players []*api.Player) string {
+	names := make([]string, 0, len(players))
+	for _, p := range players {
+		names = append(names, playerName(p))
+	}
+	return strings.Join(names, ", ")
+}
+
+// isRole tells if player was dealt a role, role is nil when it is not in game
+func isRole(p *api.Player, role *api.Player) bool {
+	return role != nil && p.GetId() == role.Id
+}
+
+func findPlayer(players []*api.Player, id uint64) *api.Player {
+	for _, p := range players {
+		if p.GetId() == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (b *telegramBot) openLobby(ctx context.Context, chatId int64, player *api.Player, locale string) error {
+	if lobby, exist := b.lobbies[chatId]; exist {
+		b.send(ctx, chatId, botText(lobby.locale, msgLobbyAlreadyOpen), nil)
+		return nil
+	}
+	session, err := b.service.GetActiveSessionForChat(ctx, &api.Chat{Id: chatId})
+	if err == nil {
+		b.send(ctx, chatId, botText(session.Locale, msgGameInProgress), nil)
+		return nil
+	}
+	if !errors.Is(err, ErrNoActiveGameInChat) {
+		return fmt.Errorf("failed to check active game in chat: %w", err)
+	}
+
+	b.lobbies[chatId] = &telegramLobby{players: []*api.Player{player}, locale: locale}
+	b.send(ctx, chatId, botText(locale, msgLobbyOpened, playerName(player), telegramMinPlayers, telegramMaxPlayers), nil)
+	return nil
+}
+
+func (b *telegramBot) joinLobby(ctx context.Context, chatId int64, player *api.Player, locale string) {
+	lobby, exist := b.lobbies[chatId]
+	switch {
+	case !exist:
+		b.send(ctx, chatId, botText(locale, msgNoLobby), nil)
+	case findPlayer(lobby.players, player.Id) != nil:
+		b.send(ctx, chatId, botText(locale, msgAlreadyJoined, playerName(player)), nil)
+	case len(lobby.players) >= telegramMaxPlayers:
+		b.send(ctx, chatId, botText(locale, msgLobbyFull), nil)
+	default:
+		lobby.players = append(lobby.players, player)
+		b.send(ctx, chatId, botText(locale, msgJoined, playerName(player), len(lobby.players), telegramMaxPlayers), nil)
+	}
+}
+
+func (b *telegramBot) leaveLobby(ctx context.Context, chatId int64, player *api.Player) {
+	lobby, exist := b.lobbies[chatId]
+	if !exist {
+		return
+	}
+	for i, p := range lobby.players {
+		if p.Id == player.Id {
+			lobby.players = append(lobby.players[:i:i], lobby.players[i+1:]...)
+			b.send(ctx, chatId, botText(lobby.locale, msgLeft, playerName(player), len(lobby.players), telegramMaxPlayers), nil)
+			return
+		}
+	}
+}
+
+func (b *telegramBot) startGame(ctx context.Context, chatId int64, player *api.Player, locale string) error {
+	lobby, exist := b.lobbies[chatId]
+	if !exist {
+		b.send(ctx, chatId, botText(locale, msgNoLobby), nil)
+		return nil
+	}
+	if findPlayer(lobby.players, player.Id) == nil {
+		return nil
+	}
+	if len(lobby.players)+b.seatBots < telegramMinPlayers {
+		b.send(ctx, chatId, botText(lobby.locale, msgNotEnoughPlayers, telegramMinPlayers, len(lobby.players)), nil)
+		return nil
+	}
+	config := &api.GameConfig{ChatId: chatId, Locale: lobby.locale, Players: lobby.players}
+	if len(lobby.players) < telegramMinPlayers {
+		config.BotPlayers = uint32(telegramMinPlayers - len(lobby.players))
+	}
+
+	//Roles are dealt and bots are seated by the service
+	session, err := b.service.CreateSession(ctx, config)
+	if errors.Is(err, ErrChatAlreadyHasGame) {
+		b.send(ctx, chatId, botText(lobby.locale, msgGameInProgress), nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	delete(b.lobbies, chatId)
+
+	game := &telegramGame{chatId: chatId, locale: session.Locale, session: session, bots: make(map[uint64]*botView)}
+	if game.good, err = b.service.GetVirtuousTeam(ctx, session); err != nil {
+		return fmt.Errorf("failed to get virtuous team: %w", err)
+	}
+	if game.evil, err = b.service.GetEvilTeam(ctx, session); err != nil {
+		return fmt.Errorf("failed to get evil team: %w", err)
+	}
+	game.players = append(game.players, lobby.players...)
+	game.players = append(game.players, seatedBots(game.good, game.evil)...)
+	players := game.players
+	//Bots only get to know what their role reveals, same as players from private messages.
+	//Their choices are moves of players, not a part of game randomness kept by session.
+	seed, err := newGameSeed()
+	if err != nil {
+		return fmt.Errorf("failed to seed bot choices: %w", err)
+	}
+	rng := rand.New(rand.NewSource(seed))
+	for _, p := range players {
+		if p.Bot {
+			game.bots[p.Id] = newBotView(p, players, game.good, game.evil, rng)
+		}
+	}
+	b.games[chatId] = game
+
+	b.send(ctx, chatId, botText(game.locale, msgGameStarted, playerNames(players), len(game.evil.Members)), nil)
+	b.revealRoles(ctx, game)
+	return b.pushGameState(ctx, game)
+}
+
+// seatedBots finds bots seated by the service, in order of seating
+func seatedBots(good *api.VirtuousTeam, evil *api.EvilTeam) []*api.Player {
+	var bots []*api.Player
+	for _, p := range append(append([]*api.Player(nil), good.GetMembers()...), evil.GetMembers()...) {
+		if p.GetBot() {
+			bots = append(bots, p)
+		}
+	}
+	sort.Slice(bots, func(a, b int) bool { return bots[a].Id < bots[b].Id })
+	return bots
+}
+
+// revealRoles tells every player their role in private messages
+func (b *telegramBot) revealRoles(ctx context.Context, game *telegramGame) {
+	good, evil := game.good, game.evil
+	for _, p := range good.Members {
+		text := botText(game.locale, msgRoleServant)
+		switch {
+		case isRole(p, good.GetMerlin()):
+			text = botText(game.locale, msgRoleMerlin, playerNames(evilKnownToMerlin(evil)))
+		case isRole(p, good.GetPercival()):
+			text = botText(game.locale, msgRolePercival, playerNames(merlinKnownToPercival(good, evil)))
+		}
+		b.sendRole(ctx, game, p, text)
+	}
+	evilNames := playerNames(evilKnownToEvil(evil))
+	for _, p := range evil.Members {
+		if isRole(p, evil.GetOberon()) {
+			b.sendRole(ctx, game, p, botText(game.locale, msgRoleOberon))
+			continue
+		}
+		text := botText(game.locale, msgRoleMinion, evilNames)
+		if isRole(p, evil.GetAssassin()) {
+			text += botText(game.locale, msgRoleAssassin)
+		}
+		if isRole(p, evil.GetMorgana()) {
+			text += botText(game.locale, msgRoleMorgana)
+		}
+		if isRole(p, evil.GetMordred()) {
+			text += botText(game.locale, msgRoleMordred)
+		}
+		b.sendRole(ctx, game, p, text)
+	}
+}
+
+func (b *telegramBot) sendRole(ctx context.Context, game *telegramGame, p *api.Player, text string) {
+	if p.Bot {
+		return
+	}
+	//Bots can't start private chats, player must write to the bot first
+	if b.send(ctx, int64(p.Id), text, nil) == 0 {
+		b.send(ctx, game.chatId, botText(game.locale, msgCantSendRole, playerName(p)), nil)
+	}
+}
+
+func (b *telegramBot) cancel(ctx context.Context, chatId int64, player *api.Player, locale string) error {
+	if lobby, exist := b.lobbies[chatId]; exist {
+		if findPlayer(lobby.players, player.Id) == nil {
+			return nil
+		}
+		delete(b.lobbies, chatId)
+		b.send(ctx, chatId, botText(lobby.locale, msgLobbyCancelled), nil)
+		return nil
+	}
+
+	session, err := b.service.GetActiveSessionForChat(ctx, &api.Chat{Id: chatId})
+	if errors.Is(err, ErrNoActiveGameInChat) {
+		b.send(ctx, chatId, botText(locale, msgNoGame), nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get active game in chat: %w", err)
+	}
+	//Game could be started by another bot instance or client, so only check players known to this bot
+	if game, exist := b.games[chatId]; exist {
+		if findPlayer(game.players, player.Id) == nil {
+			return nil
+		}
+		b.removeKeyboard(ctx, chatId, game.keyboard)
+	}
+	if _, err = b.service.TerminateSession(ctx, session); err != nil {
+		return fmt.Errorf("failed to terminate session: %w", err)
+	}
+	delete(b.games, chatId)
+	b.send(ctx, chatId, botText(session.Locale, msgGameCancelled), nil)
+	return nil
+}
+
+// pushGameState moves game to the next state and announces it
+func (b *telegramBot) pushGameState(ctx context.Context, game *telegramGame) error {
+	session, err := b.service.PushGameState(ctx, game.session)
+	if err != nil {
+		return fmt.Errorf("failed to push game state: %w", err)
+	}
+	game.session = session
+	return b.announceState(ctx, game)
+}
+
+// announceState shows players what to do in current state.
+// States that need no player actions are pushed further right away.
+func (b *telegramBot) announceState(ctx context.Context, game *telegramGame) error {
+	session := game.session
+	game.votes = make(map[uint64]bool)
+	game.abstained = make(map[uint64]bool)
+	game.keyboard = 0
+
+	switch session.GetState() {
+	case api.GameSession_MISSION_TEAM_PICKING:
+		mission, err := b.service.GetPendingMission(ctx, session)
+		if err != nil {
+			return fmt.Errorf("failed to get pending mission: %w", err)
+		}
+		game.mission = *mission
+		game.picked = nil
+		game.team = nil
+		text := botText(game.locale, msgTeamPicking,
+			mission.MissionNumber, mission.TeamPickingAttempts+1, mission.MaxTeamPickingAttempts, playerName(session.Leader), game.teamSize())
+		if mission.FailsRequired > 1 {
+			text += botText(game.locale, msgFailsRequired, mission.FailsRequired)
+		}
+		if session.Leader.GetBot() {
+			b.send(ctx, game.chatId, text, nil)
+		} else {
+			game.keyboard = b.send(ctx, game.chatId, text, game.pickingKeyboard())
+		}
+	case api.GameSession_MISSION_TEAM_VOTING:
+		game.keyboard = b.send(ctx, game.chatId, botText(game.locale, msgTeamVoting, playerName(session.Leader), playerNames(game.team)), game.teamVotingKeyboard())
+	case api.GameSession_MISSION_SUCCESS_VOTING:
+		b.send(ctx, game.chatId, botText(game.locale, msgTeamOnMission, playerNames(game.team)), nil)
+		for _, p := range game.team {
+			if p.Bot {
+				continue
+			}
+			b.send(ctx, int64(p.Id), botText(game.locale, msgMissionQuestion, game.mission.MissionNumber),
+				game.missionVotingKeyboard(findPlayer(game.evil.Members, p.Id) != nil))
+		}
+	case api.GameSession_MISSION_ENDED:
+		result := session.GetLastMissionResult()
+		for _, view := range game.bots {
+			if n := len(view.proposals); n > 0 {
+				view.proposals[n-1].failed = result.GetFailed()
+			}
+		}
+		outcome := msgMissionPassed
+		if result.GetFailed() {
+			outcome = msgMissionFailed
+		}
+		b.send(ctx, game.chatId, botText(game.locale, outcome,
+			game.mission.MissionNumber, result.GetNegativeVotes(), session.MissionsPassed, session.MissionsFailed), nil)
+		return b.pushGameState(ctx, game)
+	case api.GameSession_POST_MISSIONS_ACTIONS:
+		if session.MissionsPassed < session.MissionsFailed {
+			return b.pushGameState(ctx, game)
+		}
+		assassin := game.evil.GetAssassin()
+		b.send(ctx, game.chatId, botText(game.locale, msgLastChance, playerName(assassin)), nil)
+		if !assassin.GetBot() {
+			b.send(ctx, int64(assassin.GetId()), botText(game.locale, msgWhoIsMerlin), game.assassinationKeyboard())
+		}
+	case api.GameSession_VIRTUOUS_TEAM_WON, api.GameSession_EVIL_TEAM_WON:
+		winner := botText(game.locale, msgGoodWon)
+		if session.State == api.GameSession_EVIL_TEAM_WON {
+			winner = botText(game.locale, msgEvilWon)
+		}
+		b.send(ctx, game.chatId, botText(game.locale, msgGameOver,
+			winner, session.EndgameReason, playerName(game.good.GetMerlin()), playerName(game.evil.GetAssassin()), playerNames(game.evil.Members)), nil)
+		delete(b.games, game.chatId)
+	default:
+		return fmt.Errorf("unexpected game state %v", session.GetState())
+	}
+	return b.playBots(ctx, game)
+}
+
+// playBots makes bots do what current state requires of them.
+// Bots act through the same handlers as players pressing buttons,
+// the action completing a round moves the game on, so bots stop once session changes.
+func (b *telegramBot) playBots(ctx context.Context, game *telegramGame) error {
+	session := game.session
+	switch session.GetState() {
+	case api.GameSession_MISSION_TEAM_PICKING:
+		leader, isBot := game.bots[session.Leader.GetId()]
+		if !isBot {
+			return nil
+		}
+		for _, p := range b.botStrategy.ProposeTeam(leader, game.teamSize()) {
+			game.picked = append(game.picked, p.Id)
+		}
+		_, err := b.proposeTeam(ctx, game)
+		return err
+	case api.GameSession_MISSION_TEAM_VOTING:
+		for _, p := range game.players {
+			view, isBot := game.bots[p.Id]
+			if !isBot {
+				continue
+			}
+			if _, err := b.voteForTeam(ctx, game, p, voteOption(b.botStrategy.VoteForTeam(view, game.team))); err != nil || game.session != session {
+				return err
+			}
+		}
+	case api.GameSession_MISSION_SUCCESS_VOTING:
+		for _, p := range game.team {
+			view, isBot := game.bots[p.Id]
+			if !isBot {
+				continue
+			}
+			success := !view.role.evil() || b.botStrategy.VoteForMission(view)
+			if _, err := b.voteForMission(ctx, game, p, voteOption(success), nil); err != nil || game.session != session {
+				return err
+			}
+		}
+	case api.GameSession_POST_MISSIONS_ACTIONS:
+		if assassin, isBot := game.bots[game.evil.GetAssassin().GetId()]; isBot {
+			_, err := b.assassinate(ctx, game, b.botStrategy.GuessMerlin(assassin), nil)
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *telegramGame) teamSize() int {
+	return int(g.mission.TeamSize)
+}
+
+func (g *telegramGame) isPicked(id uint64) bool {
+	for _, picked := range g.picked {
+		if picked == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Callback data of inline keyboard buttons is "<action>:<game id>[:<argument>]"
+const (
+	callbackPick     = "pick" //Leader toggles player in mission team
+	callbackTeamDone = "team" //Leader proposes picked team
+	callbackTeamVote = "tv"
+	callbackMission  = "mv"
+	callbackKill     = "kill" //Assassin picks alleged Merlin
+)
+
+func (g *telegramGame) callbackData(action string, arg interface{}) string {
+	data := action + ":" + g.session.GetGameId().GetValue()
+	if arg != nil {
+		data += fmt.Sprint(":", arg)
+	}
+	return data
+}
+
+func (g *telegramGame) pickingKeyboard() *tgInlineKeyboardMarkup {
+	keyboard := new(tgInlineKeyboardMarkup)
+	for _, p := range g.players {
+		text := playerName(p)
+		if g.isPicked(p.Id) {
+			text = "✅ " + text
+		}
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgInlineKeyboardButton{{Text: text, CallbackData: g.callbackData(callbackPick, p.Id)}})
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgInlineKeyboardButton{{
+		Text:         botText(g.locale, msgProposeTeamButton, len(g.picked), g.teamSize()),
+		CallbackData: g.callbackData(callbackTeamDone, nil),
+	}})
+	return keyboard
+}
+
+func (g *telegramGame) teamVotingKeyboard() *tgInlineKeyboardMarkup {
+	return &tgInlineKeyboardMarkup{InlineKeyboard: [][]tgInlineKeyboardButton{{
+		{Text: botText(g.locale, msgApproveButton), CallbackData: g.callbackData(callbackTeamVote, int32(api.VoteContext_POSITIVE))},
+		{Text: botText(g.locale, msgRejectButton), CallbackData: g.callbackData(callbackTeamVote, int32(api.VoteContext_NEGATIVE))},
+		{Text: botText(g.locale, msgAbstainButton), CallbackData: g.callbackData(callbackTeamVote, int32(api.VoteContext_ABSTAIN))},
+	}}}
+}
+
+// missionVotingKeyboard only lets evil players fail the mission
+func (g *telegramGame) missionVotingKeyboard(evil bool) *tgInlineKeyboardMarkup {
+	row := []tgInlineKeyboardButton{{Text: botText(g.locale, msgSuccessButton), CallbackData: g.callbackData(callbackMission, int32(api.VoteContext_POSITIVE))}}
+	if evil {
+		row = append(row, tgInlineKeyboardButton{Text: botText(g.locale, msgFailButton), CallbackData: g.callbackData(callbackMission, int32(api.VoteContext_NEGATIVE))})
+	}
+	return &tgInlineKeyboardMarkup{InlineKeyboard: [][]tgInlineKeyboardButton{row}}
+}
+
+func (g *telegramGame) assassinationKeyboard() *tgInlineKeyboardMarkup {
+	keyboard := new(tgInlineKeyboardMarkup)
+	for _, p := range g.players {
+		if findPlayer(g.evil.Members, p.Id) == nil {
+			keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgInlineKeyboardButton{{Text: playerName(p), CallbackData: g.callbackData(callbackKill, p.Id)}})
+		}
+	}
+	return keyboard
+}
+
+func (b *telegramBot) gameById(id string) *telegramGame {
+	for _, game := range b.games {
+		if game.session.GetGameId().GetValue() == id {
+			return game
+		}
+	}
+	return nil
+}
+
+// handleCallback processes inline keyboard button press, returns text shown to player who pressed it
+func (b *telegramBot) handleCallback(ctx context.Context, query *tgCallbackQuery) (string, error) {
+	parts := strings.SplitN(query.Data, ":", 3)
+	if len(parts) < 2 {
+		return "", nil
+	}
+	action, arg := parts[0], ""
+	if len(parts) == 3 {
+		arg = parts[2]
+	}
+	game := b.gameById(parts[1])
+	if game == nil {
+		return botText(userLocale(&query.From), msgGameIsOver), nil
+	}
+	player := findPlayer(game.players, uint64(query.From.Id))
+	if player == nil {
+		return botText(game.locale, msgNotInGame), nil
+	}
+
+	switch action {
+	case callbackPick, callbackTeamDone:
+		if game.session.State != api.GameSession_MISSION_TEAM_PICKING || query.Message == nil || query.Message.MessageId != game.keyboard {
+			return botText(game.locale, msgTeamAlreadyPicked), nil
+		}
+		if player.Id != game.session.Leader.GetId() {
+			return botText(game.locale, msgLeaderPicks, playerName(game.session.Leader)), nil
+		}
+		if action == callbackPick {
+			return b.togglePick(ctx, game, arg)
+		}
+		return b.proposeTeam(ctx, game)
+	case callbackTeamVote:
+		if game.session.State != api.GameSession_MISSION_TEAM_VOTING {
+			return botText(game.locale, msgTeamVotingOver), nil
+		}
+		vote, ok := parseVoteOption(arg)
+		if !ok {
+			return "", nil
+		}
+		return b.voteForTeam(ctx, game, player, vote)
+	case callbackMission:
+		if game.session.State != api.GameSession_MISSION_SUCCESS_VOTING || findPlayer(game.team, player.Id) == nil {
+			return botText(game.locale, msgNotOnMission), nil
+		}
+		vote, ok := parseVoteOption(arg)
+		if !ok {
+			return "", nil
+		}
+		return b.voteForMission(ctx, game, player, vote, query.Message)
+	case callbackKill:
+		if game.session.State != api.GameSession_POST_MISSIONS_ACTIONS || player.Id != game.evil.GetAssassin().GetId() {
+			return botText(game.locale, msgAssassinPicks), nil
+		}
+		id, err := strconv.ParseUint(arg, 10, 64)
+		target := findPlayer(game.players, id)
+		if err != nil || target == nil {
+			return "", nil
+		}
+		return b.assassinate(ctx, game, target, query.Message)
+	}
+	return "", nil
+}
+
+func (b *telegramBot) togglePick(ctx context.Context, game *telegramGame, arg string) (string, error) {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil || findPlayer(game.players, id) == nil {
+		return "", nil
+	}
+	if game.isPicked(id) {
+		for i, picked := range game.picked {
+			if picked == id {
+				game.picked = append(game.picked[:i], game.picked[i+1:]...)
+				break
+			}
+		}
+	} else {
+		if len(game.picked) >= game.teamSize() {
+			return botText(game.locale, msgTeamSizeLimit, game.teamSize()), nil
+		}
+		game.picked = append(game.picked, id)
+	}
+
+	err = b.client.editMessageReplyMarkup(ctx, tgEditMessageReplyMarkupRequest{
+		ChatId: game.chatId, MessageId: game.keyboard, ReplyMarkup: game.pickingKeyboard(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to update team picking keyboard: %w", err)
+	}
+	return "", nil
+}
+
+func (b *telegramBot) proposeTeam(ctx context.Context, game *telegramGame) (string, error) {
+	if len(game.picked) != game.teamSize() {
+		return botText(game.locale, msgPickPlayers, game.teamSize()), nil
+	}
+	team := &api.MissionTeam{}
+	for _, id := range game.picked {
+		team.Members = append(team.Members, findPlayer(game.players, id))
+	}
+	if _, err := b.service.AssignMissionTeam(ctx, &api.AssignTeamContext{Session: game.session, Team: team}); err != nil {
+		return "", fmt.Errorf("failed to assign mission team: %w", err)
+	}
+	game.team = team.Members
+	b.removeKeyboard(ctx, game.chatId, game.keyboard)
+	return botText(game.locale, msgTeamProposed), b.pushGameState(ctx, game)
+}
+
+func (b *telegramBot) voteForTeam(ctx context.Context, game *telegramGame, player *api.Player, vote api.VoteContext_VoteOption) (string, error) {
+	if _, voted := game.votes[player.Id]; voted {
+		return botText(game.locale, msgAlreadyVoted), nil
+	}
+	_, err := b.service.VoteForMissionTeam(ctx, &api.VoteContext{Session: game.session, Voter: player, Vote: vote})
+	if err != nil {
+		return "", fmt.Errorf("failed to vote for mission team: %w", err)
+	}
+	game.votes[player.Id] = vote == api.VoteContext_POSITIVE
+	if vote == api.VoteContext_ABSTAIN {
+		game.abstained[player.Id] = true
+	}
+	if len(game.votes) < len(game.players) {
+		return botText(game.locale, msgVoteCounted), nil
+	}
+
+	//Team votes are public in Avalon
+	var approved, rejected, abstained []*api.Player
+	for _, p := range game.players {
+		switch {
+		case game.votes[p.Id]:
+			approved = append(approved, p)
+		case game.abstained[p.Id]:
+			abstained = append(abstained, p)
+		default:
+			rejected = append(rejected, p)
+		}
+	}
+	proposal := &teamProposal{team: game.team, votes: game.votes}
+	for _, view := range game.bots {
+		view.proposals = append(view.proposals, proposal)
+	}
+	b.removeKeyboard(ctx, game.chatId, game.keyboard)
+
+	session, err := b.service.PushGameState(ctx, game.session)
+	if err != nil {
+		return "", fmt.Errorf("failed to push game state: %w", err)
+	}
+	game.session = session
+	verdict := botText(game.locale, msgTeamRejected)
+	if session.GetLastTeamVote().GetApproved() {
+		verdict = botText(game.locale, msgTeamApproved)
+	}
+	b.send(ctx, game.chatId, botText(game.locale, msgTeamVotes,
+		orNobody(game.locale, approved), orNobody(game.locale, rejected), orNobody(game.locale, abstained), verdict), nil)
+	return botText(game.locale, msgVoteCounted), b.announceState(ctx, game)
+}
+
+func orNobody(locale string, players []*api.Player) string {
+	if len(players) == 0 {
+		return botText(locale, msgNobody)
+	}
+	return playerNames(players)
+}
+
+func (b *telegramBot) voteForMission(ctx context.Context, game *telegramGame, player *api.Player, vote api.VoteContext_VoteOption, msg *tgMessage) (string, error) {
+	if _, voted := game.votes[player.Id]; voted {
+		return botText(game.locale, msgAlreadyVoted), nil
+	}
+	if vote == api.VoteContext_NEGATIVE && findPlayer(game.evil.Members, player.Id) == nil {
+		return botText(game.locale, msgServantsCantFail), nil
+	}
+	_, err := b.service.VoteForMissionSuccess(ctx, &api.VoteContext{Session: game.session, Voter: player, Vote: vote})
+	if err != nil {
+		return "", fmt.Errorf("failed to vote for mission success: %w", err)
+	}
+	game.votes[player.Id] = vote == api.VoteContext_POSITIVE
+	if msg != nil {
+		b.removeKeyboard(ctx, msg.Chat.Id, msg.MessageId)
+	}
+	if len(game.votes) < len(game.team) {
+		return botText(game.locale, msgVoteCounted), nil
+	}
+	return botText(game.locale, msgVoteCounted), b.pushGameState(ctx, game)
+}
+
+func parseVoteOption(arg string) (api.VoteContext_VoteOption, bool) {
+	option, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, false
+	}
+	_, known := api.VoteContext_VoteOption_name[int32(option)]
+	return api.VoteContext_VoteOption(option), known
+}
+
+func (b *telegramBot) assassinate(ctx context.Context, game *telegramGame, target *api.Player, msg *tgMessage) (string, error) {
+	outcome, err := b.service.AssassinateAllegedMerlin(ctx, &api.AssassinationContext{Session: game.session, Target: target})
+	if err != nil {
+		return "", fmt.Errorf("failed to assassinate: %w", err)
+	}
+	if msg != nil {
+		b.removeKeyboard(ctx, msg.Chat.Id, msg.MessageId)
+	}
+
+	text := botText(game.locale, msgMerlinMissed, playerName(target))
+	if outcome.MerlinWasKilled {
+		text = botText(game.locale, msgMerlinKilled, playerName(target))
+	}
+	b.send(ctx, game.chatId, text, nil)
+	game.session = outcome.Session
+	return "", b.announceState(ctx, game)
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap/zaptest"
+)
+
+const (
+	testBotToken = "123:secret"
+	testGroupId  = int64(-100)
+)
+
+// fakeBotAPI is a local Bot API server, it records sent messages and serves queued updates
+type fakeBotAPI struct {
+	*httptest.Server
+	updates chan tgUpdate
+
+	lock     sync.Mutex
+	messages []fakeSentMessage
+	answers  []tgAnswerCallbackQueryRequest
+}
+
+type fakeSentMessage struct {
+	tgSendMessageRequest
+	MessageId int64
+}
+
+func newFakeBotAPI(t *testing.T) *fakeBotAPI {
+	t.Helper()
+	api := &fakeBotAPI{updates: make(chan tgUpdate, 10)}
+	api.Server = httptest.NewServer(http.HandlerFunc(api.serve))
+	t.Cleanup(api.Close)
+	return api
+}
+
+func (f *fakeBotAPI) serve(w http.ResponseWriter, r *http.Request) {
+	prefix := "/bot" + testBotToken + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`))
+		return
+	}
+
+	var result interface{} = true
+	f.lock.Lock()
+	switch strings.TrimPrefix(r.URL.Path, prefix) {
+	case "getUpdates":
+		f.lock.Unlock()
+		var updates []tgUpdate
+		select {
+		case update := <-f.updates:
+			updates = append(updates, update)
+		case <-time.After(50 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		f.lock.Lock()
+		result = updates
+	case "sendMessage":
+		var req tgSendMessageRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		msg := fakeSentMessage{tgSendMessageRequest: req, MessageId: int64(len(f.messages) + 1)}
+		f.messages = append(f.messages, msg)
+		result = tgMessage{MessageId: msg.MessageId, Chat: tgChat{Id: req.ChatId}, Text: req.Text}
+	case "answerCallbackQuery":
+		var req tgAnswerCallbackQueryRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.answers = append(f.answers, req)
+	}
+	f.lock.Unlock()
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+// sentTo returns messages sent to chat, oldest first
+func (f *fakeBotAPI) sentTo(chatId int64) []fakeSentMessage {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var sent []fakeSentMessage
+	for _, msg := range f.messages {
+		if msg.ChatId == chatId {
+			sent = append(sent, msg)
+		}
+	}
+	return sent
+}
+
+func (f *fakeBotAPI) lastAnswer() string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if len(f.answers) == 0 {
+		return ""
+	}
+	return f.answers[len(f.answers)-1].Text
+}
+
+func newTestTelegramBot(t *testing.T) (*telegramBot, *fakeBotAPI, api.GameServiceServer) {
+	t.Helper()
+	logger := zaptest.NewLogger(t)
+	fake := newFakeBotAPI(t)
+	service := NewGameService(NewMemoryStorage(time.Minute), NewVoteStorage(logger), logger)
+	bot := NewTelegramBot(TelegramConfig{Token: testBotToken, APIURL: fake.URL, PollTimeout: time.Second}, service, logger)
+	return bot, fake, service
+}
+
+func command(from int64, text string) tgUpdate {
+	return tgUpdate{Message: &tgMessage{
+		From: &tgUser{Id: from, UserName: fmt.Sprint("player", from)},
+		Chat: tgChat{Id: testGroupId, Type: "supergroup"},
+		Text: text,
+	}}
+}
+
+// press finds the latest button sent to chat with callback data starting with prefix and presses it
+func press(t *testing.T, bot *telegramBot, fake *fakeBotAPI, chatId, from int64, prefix string) {
+	t.Helper()
+	sent := fake.sentTo(chatId)
+	for i := len(sent) - 1; i >= 0; i-- {
+		if sent[i].ReplyMarkup == nil {
+			continue
+		}
+		for _, row := range sent[i].ReplyMarkup.InlineKeyboard {
+			for _, button := range row {
+				if strings.HasPrefix(button.CallbackData, prefix) {
+					bot.handleUpdate(context.Background(), tgUpdate{CallbackQuery: &tgCallbackQuery{
+						Id:      "query",
+						From:    tgUser{Id: from},
+						Message: &tgMessage{MessageId: sent[i].MessageId, Chat: tgChat{Id: chatId}},
+						Data:    button.CallbackData,
+					}})
+					return
+				}
+			}
+		}
+	}
+	t.Fatalf("no %q button was sent to chat %d", prefix, chatId)
+}
+
+func startTestTelegramGame(t *testing.T, bot *telegramBot) {
+	t.Helper()
+	ctx := context.Background()
+	bot.handleUpdate(ctx, command(1, "/newgame@AvalonBot"))
+	for id := int64(2); id <= 5; id++ {
+		bot.handleUpdate(ctx, command(id, "/join"))
+	}
+	bot.handleUpdate(ctx, command(1, "/startgame"))
+	if bot.games[testGroupId] == nil {
+		t.Fatal("game was not started")
+	}
+}
+
+func TestTelegramBotPlaysGame(t *testing.T) {
+	bot, fake, service := newTestTelegramBot(t)
+	ctx := context.Background()
+	startTestTelegramGame(t, bot)
+
+	session, err := service.GetActiveSessionForChat(ctx, &api.Chat{Id: testGroupId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	evil, _ := service.GetEvilTeam(ctx, session)
+	good, _ := service.GetVirtuousTeam(ctx, session)
+	isEvil := func(id uint64) bool { return findPlayer(evil.Members, id) != nil }
+	for id := int64(1); id <= 5; id++ {
+		if len(fake.sentTo(id)) != 1 {
+			t.Errorf("player %d got %d private messages; want role reveal", id, len(fake.sentTo(id)))
+		}
+	}
+	if merlinRole := fake.sentTo(int64(good.Merlin.Id))[0].Text; !strings.Contains(merlinRole, playerNames(evil.Members)) {
+		t.Errorf("Merlin was not told who is evil: %q", merlinRole)
+	}
+
+	for mission := 1; mission <= 5; mission++ {
+		game := bot.games[testGroupId]
+		leader := int64(game.session.Leader.Id)
+		for _, p := range game.players[:game.teamSize()] {
+			press(t, bot, fake, testGroupId, leader, fmt.Sprintf("%s:%s:%d", callbackPick, game.session.GameId.Value, p.Id))
+		}
+		press(t, bot, fake, testGroupId, leader, callbackTeamDone)
+		if game.session.State != api.GameSession_MISSION_TEAM_VOTING {
+			t.Fatalf("mission %d: state after team proposal = %v", mission, game.session.State)
+		}
+		for id := int64(1); id <= 5; id++ {
+			press(t, bot, fake, testGroupId, id, fmt.Sprintf("%s:%s:%d", callbackTeamVote, game.session.GameId.Value, api.VoteContext_POSITIVE))
+		}
+		for _, p := range game.team {
+			if !isEvil(p.Id) {
+				//Keyboard of good players has no fail button, but crafted callback must be rejected too
+				bot.handleUpdate(ctx, tgUpdate{CallbackQuery: &tgCallbackQuery{
+					From: tgUser{Id: int64(p.Id)},
+					Data: game.callbackData(callbackMission, int32(api.VoteContext_NEGATIVE)),
+				}})
+				if answer := fake.lastAnswer(); !strings.Contains(answer, "не могут") {
+					t.Errorf("good player failed mission, answer %q", answer)
+				}
+			}
+			press(t, bot, fake, int64(p.Id), int64(p.Id), game.callbackData(callbackMission, int32(api.VoteContext_POSITIVE)))
+		}
+	}
+
+	game := bot.games[testGroupId]
+	if game == nil || game.session.State != api.GameSession_POST_MISSIONS_ACTIONS {
+		t.Fatalf("game did not reach assassination, bot state %+v", game)
+	}
+	assassin := int64(evil.Assassin.Id)
+	press(t, bot, fake, assassin, assassin, fmt.Sprintf("%s:%s:%d", callbackKill, game.session.GameId.Value, good.Merlin.Id))
+
+	if bot.games[testGroupId] != nil {
+		t.Error("finished game is still tracked by bot")
+	}
+	group := fake.sentTo(testGroupId)
+	if last := group[len(group)-1].Text; !strings.Contains(last, "Победа зла") {
+		t.Errorf("last announcement = %q; want evil victory", last)
+	}
+}
+
+func TestTelegramBotRevealsSpecialRoles(t *testing.T) {
+	bot, fake, _ := newTestTelegramBot(t)
+	good, evil := specialRolesTeams()
+	bot.revealRoles(context.Background(), &telegramGame{chatId: testGroupId, locale: localeEnglish, good: good, evil: evil})
+
+	role := func(p *api.Player) string {
+		sent := fake.sentTo(int64(p.Id))
+		if len(sent) != 1 {
+			t.Fatalf("player %d got %d private messages; want role reveal", p.Id, len(sent))
+		}
+		return sent[0].Text
+	}
+	for text, want := range map[string]map[*api.Player]bool{
+		role(good.Merlin):   {evil.Assassin: true, evil.Morgana: true, evil.Oberon: true, evil.Mordred: false},
+		role(good.Percival): {good.Merlin: true, evil.Morgana: true, evil.Assassin: false},
+		role(evil.Assassin): {evil.Morgana: true, evil.Mordred: true, evil.Oberon: false},
+		role(evil.Mordred):  {evil.Assassin: true, evil.Oberon: false},
+	} {
+		for p, known := range want {
+			if strings.Contains(text, playerName(p)) != known {
+				t.Errorf("player %d is known: %v; want %v in %q", p.Id, !known, known, text)
+			}
+		}
+	}
+	if text := role(evil.Oberon); text != botText(localeEnglish, msgRoleOberon) {
+		t.Errorf("Oberon was told %q", text)
+	}
+	if text := role(evil.Morgana); !strings.HasSuffix(text, botText(localeEnglish, msgRoleMorgana)) {
+		t.Errorf("Morgana was told %q", text)
+	}
+	if text := role(evil.Mordred); !strings.HasSuffix(text, botText(localeEnglish, msgRoleMordred)) {
+		t.Errorf("Mordred was told %q", text)
+	}
+}
+
+func TestTelegramBotRejectedTeam(t *testing.T) {
+	bot, fake, _ := newTestTelegramBot(t)
+	startTestTelegramGame(t, bot)
+	game := bot.games[testGroupId]
+	leader := game.session.Leader.Id
+
+	notLeader := int64(leader%5 + 1)
+	press(t, bot, fake, testGroupId, notLeader, callbackPick)
+	if answer := fake.lastAnswer(); !strings.Contains(answer, "лидер") || len(game.picked) != 0 {
+		t.Errorf("not a leader picked team, answer %q", answer)
+	}
+	press(t, bot, fake, testGroupId, int64(leader), callbackTeamDone)
+	if answer := fake.lastAnswer(); !strings.Contains(answer, "Выберите 2") {
+		t.Errorf("empty team was proposed, answer %q", answer)
+	}
+
+	for _, p := range game.players[:2] {
+		press(t, bot, fake, testGroupId, int64(leader), fmt.Sprintf("%s:%s:%d", callbackPick, game.session.GameId.Value, p.Id))
+	}
+	press(t, bot, fake, testGroupId, int64(leader), callbackTeamDone)
+	for id := int64(1); id <= 5; id++ {
+		press(t, bot, fake, testGroupId, id, fmt.Sprintf("%s:%s:%d", callbackTeamVote, game.session.GameId.Value, api.VoteContext_NEGATIVE))
+	}
+	press(t, bot, fake, testGroupId, 1, callbackTeamVote)
+	if answer := fake.lastAnswer(); !strings.Contains(answer, "закончилось") {
+		t.Errorf("vote after voting ended was accepted, answer %q", answer)
+	}
+
+	if game.session.State != api.GameSession_MISSION_TEAM_PICKING || game.mission.TeamPickingAttempts != 1 {
+		t.Errorf("state after rejection = %v, attempts %d", game.session.State, game.mission.TeamPickingAttempts)
+	}
+	if game.session.Leader.Id == leader {
+		t.Error("leader did not change after rejected team")
+	}
+}
+
+func TestTelegramBotSeatsBots(t *testing.T) {
+	bot, fake, service := newTestTelegramBot(t)
+	bot.seatBots, bot.botStrategy = 2, botStrategies["merlin-hinting"]
+	ctx := context.Background()
+	bot.handleUpdate(ctx, command(1, "/newgame"))
+	bot.handleUpdate(ctx, command(2, "/join"))
+	bot.handleUpdate(ctx, command(1, "/startgame"))
+	if bot.games[testGroupId] != nil {
+		t.Fatal("game of 2 players was started with 2 bots")
+	}
+	bot.handleUpdate(ctx, command(3, "/join"))
+	bot.handleUpdate(ctx, command(1, "/startgame"))
+
+	game := bot.games[testGroupId]
+	if game == nil || len(game.players) != 5 || len(game.bots) != 2 {
+		t.Fatalf("game = %+v; want 3 players and 2 bots", game)
+	}
+	for _, p := range game.players[3:] {
+		if !p.Bot || p.Id <= seatBotIdBase {
+			t.Errorf("seat bot %v is not marked as bot", p)
+		}
+	}
+	session, err := service.GetActiveSessionForChat(ctx, &api.Chat{Id: testGroupId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	good, _ := service.GetVirtuousTeam(ctx, session)
+	evil, _ := service.GetEvilTeam(ctx, session)
+	bots := 0
+	for _, p := range append(good.Members, evil.Members...) {
+		if p.Bot {
+			bots++
+		}
+	}
+	if bots != 2 {
+		t.Errorf("service knows %d bots; want 2", bots)
+	}
+
+	//Humans act whenever game waits for them, bots do the rest
+	for step := 0; bot.games[testGroupId] != nil; step++ {
+		if step > 100 {
+			t.Fatalf("game did not finish, state %v", game.session.State)
+		}
+		gameId := game.session.GameId.Value
+		switch game.session.State {
+		case api.GameSession_MISSION_TEAM_PICKING:
+			leader := int64(game.session.Leader.Id)
+			for _, p := range game.players[:game.teamSize()] {
+				press(t, bot, fake, testGroupId, leader, fmt.Sprintf("%s:%s:%d", callbackPick, gameId, p.Id))
+			}
+			press(t, bot, fake, testGroupId, leader, callbackTeamDone)
+		case api.GameSession_MISSION_TEAM_VOTING:
+			for id := int64(1); id <= 3; id++ {
+				press(t, bot, fake, testGroupId, id, fmt.Sprintf("%s:%s:%d", callbackTeamVote, gameId, api.VoteContext_POSITIVE))
+			}
+		case api.GameSession_MISSION_SUCCESS_VOTING:
+			for _, p := range game.team {
+				if _, voted := game.votes[p.Id]; !voted && !p.Bot {
+					press(t, bot, fake, int64(p.Id), int64(p.Id), game.callbackData(callbackMission, int32(api.VoteContext_POSITIVE)))
+				}
+			}
+		case api.GameSession_POST_MISSIONS_ACTIONS:
+			assassin := int64(evil.Assassin.Id)
+			press(t, bot, fake, assassin, assassin, fmt.Sprintf("%s:%s:%d", callbackKill, gameId, good.Merlin.Id))
+		default:
+			t.Fatalf("game waits for no one in state %v", game.session.State)
+		}
+	}
+
+	if _, err = service.GetActiveSessionForChat(ctx, &api.Chat{Id: testGroupId}); err == nil {
+		t.Error("game with bots did not finish")
+	}
+	for _, p := range game.players[3:] {
+		if sent := fake.sentTo(int64(p.Id)); len(sent) != 0 {
+			t.Errorf("bot %v was sent messages %v", p, sent)
+		}
+	}
+}
+
+func TestTelegramBotLobby(t *testing.T) {
+	bot, fake, service := newTestTelegramBot(t)
+	ctx := context.Background()
+
+	bot.handleUpdate(ctx, command(1, "/join"))
+	bot.handleUpdate(ctx, command(1, "/newgame"))
+	bot.handleUpdate(ctx, command(2, "/join"))
+	bot.handleUpdate(ctx, command(2, "/join"))
+	bot.handleUpdate(ctx, command(1, "/startgame"))
+	if len(bot.lobbies[testGroupId].players) != 2 || bot.games[testGroupId] != nil {
+		t.Fatalf("lobby = %v; want 2 players and no game", bot.lobbies[testGroupId].players)
+	}
+	bot.handleUpdate(ctx, command(3, "/cancel"))
+	bot.handleUpdate(ctx, command(2, "/leave"))
+	bot.handleUpdate(ctx, command(1, "/cancel"))
+	if _, exist := bot.lobbies[testGroupId]; exist {
+		t.Error("lobby was not cancelled")
+	}
+
+	startTestTelegramGame(t, bot)
+	bot.handleUpdate(ctx, command(1, "/newgame"))
+	bot.handleUpdate(ctx, command(1, "/cancel"))
+	if _, err := service.GetActiveSessionForChat(ctx, &api.Chat{Id: testGroupId}); err == nil {
+		t.Error("game was not terminated")
+	}
+
+	var texts []string
+	for _, msg := range fake.sentTo(testGroupId) {
+		texts = append(texts, msg.Text)
+	}
+	for _, want := range []string{"не открыт", "уже в игре", "хотя бы 5", "отменён", "уже идёт игра", "прервана"} {
+		if !strings.Contains(strings.Join(texts, "\n"), want) {
+			t.Errorf("no message containing %q in %q", want, texts)
+		}
+	}
+}
+
+func TestTelegramBotLocale(t *testing.T) {
+	bot, fake, service := newTestTelegramBot(t)
+	ctx := context.Background()
+
+	bot.handleUpdate(ctx, command(1, "/newgame en"))
+	for id := int64(2); id <= 5; id++ {
+		//Lobby locale wins over locale of players' clients
+		update := command(id, "/join")
+		update.Message.From.LanguageCode = "ru"
+		bot.handleUpdate(ctx, update)
+	}
+	bot.handleUpdate(ctx, command(1, "/startgame"))
+	game := bot.games[testGroupId]
+	if game == nil {
+		t.Fatal("game was not started")
+	}
+	session, err := service.GetActiveSessionForChat(ctx, &api.Chat{Id: testGroupId})
+	if err != nil || session.Locale != localeEnglish {
+		t.Fatalf("session locale = %q, %v; want %q", session.GetLocale(), err, localeEnglish)
+	}
+
+	sent := fake.sentTo(testGroupId)
+	for _, msg := range sent {
+		if strings.ContainsAny(msg.Text, "абвгдеёжзийклмнопрстуфхцчшщъыьэюя") {
+			t.Errorf("russian text %q in english game", msg.Text)
+		}
+	}
+	if last := sent[len(sent)-1].Text; !strings.Contains(last, "picks 2 players") {
+		t.Errorf("last message = %q; want team picking announcement", last)
+	}
+
+	//Without explicit choice lobby speaks the language of player opening it
+	bot.handleUpdate(ctx, command(1, "/cancel"))
+	update := command(2, "/newgame")
+	update.Message.From.LanguageCode = "en-US"
+	bot.handleUpdate(ctx, update)
+	if lobby := bot.lobbies[testGroupId]; lobby == nil || lobby.locale != localeEnglish {
+		t.Errorf("lobby = %+v; want english lobby", lobby)
+	}
+}
+
+func TestTelegramBotRun(t *testing.T) {
+	bot, fake, _ := newTestTelegramBot(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		bot.Run(ctx)
+		close(stopped)
+	}()
+
+	update := command(1, "/newgame")
+	update.UpdateId = 7
+	fake.updates <- update
+	deadline := time.Now().Add(5 * time.Second)
+	for len(fake.sentTo(testGroupId)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sent := fake.sentTo(testGroupId); len(sent) != 1 || !strings.Contains(sent[0].Text, "открыл набор") {
+		t.Errorf("bot replied %v; want lobby announcement", sent)
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("bot did not stop after context cancellation")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/export/trace/tracetest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap/zaptest"
+)
+
+// recordSpans installs tracer provider keeping finished spans in memory for the duration of the test
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(prevProvider) })
+	return exporter
+}
+
+func TestStorageSpansAreChildrenOfRequestSpan(t *testing.T) {
+	spans := recordSpans(t)
+	ctx := context.Background()
+
+	backend := newTestBoltStorage(t)
+	service := &simpleGameService{
+		sessions: NewInstrumentedSessionStorage(backend, storageBolt),
+		votes:    NewInstrumentedVoteStorage(backend, storageBolt),
+		logger:   zaptest.NewLogger(t),
+	}
+	game := fullTestGame()
+	if err := backend.StoreSession(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+
+	rpcCtx, rpcSpan := startSpan(ctx, "rpc")
+	if _, err := service.sessions.GetSession(rpcCtx, testGameId(game.GameId)); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.storeSessionAndResetVotes(rpcCtx, game); err != nil {
+		t.Fatal(err)
+	}
+	rpcSpan.End()
+
+	names := make(map[string]bool)
+	for _, span := range spans.GetSpans() {
+		names[span.Name] = true
+		if span.Name == "rpc" {
+			continue
+		}
+		if span.ParentSpanID != rpcSpan.SpanContext().SpanID {
+			t.Errorf("span %s is not a child of request span", span.Name)
+		}
+	}
+	for _, name := range []string{"storage.get", "storage.store_and_reset_votes"} {
+		if !names[name] {
+			t.Errorf("span %s was not recorded, got %v", name, names)
+		}
+	}
+	//Bolt keeps sessions and votes together, so instrumented wrappers must not hide that
+	if names["storage.reset_votes"] {
+		t.Error("votes were reset outside of session transaction")
+	}
+}
+
+func TestMongoTracingMonitor(t *testing.T) {
+	spans := recordSpans(t)
+	monitor := newMongoTracingMonitor()
+
+	ctx, parent := startSpan(context.Background(), "storage.get")
+	monitor.Started(ctx, &event.CommandStartedEvent{CommandName: "find", DatabaseName: "avalon", RequestID: 1})
+	monitor.Started(ctx, &event.CommandStartedEvent{CommandName: "delete", DatabaseName: "avalon", RequestID: 2})
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 1}})
+	monitor.Failed(ctx, &event.CommandFailedEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 2},
+		Failure:              "not primary",
+	})
+	parent.End()
+
+	finished := spans.GetSpans()
+	if len(finished) != 3 {
+		t.Fatalf("got %d spans; want 3", len(finished))
+	}
+	for _, span := range finished[:2] {
+		if span.ParentSpanID != parent.SpanContext().SpanID {
+			t.Errorf("span %s is not a child of storage span", span.Name)
+		}
+	}
+	if finished[0].Name != "mongo.find" || finished[0].StatusCode == codes.Error {
+		t.Errorf("first span is %s with status %v; want successful mongo.find", finished[0].Name, finished[0].StatusCode)
+	}
+	if finished[1].Name != "mongo.delete" || finished[1].StatusCode != codes.Error {
+		t.Errorf("second span is %s with status %v; want failed mongo.delete", finished[1].Name, finished[1].StatusCode)
+	}
+}
@@ -1,82 +1,198 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/google/uuid"
 	"github.com/justmax437/avalonBacker/api"
-	"log"
+	"go.uber.org/zap"
+	"sync"
 )
 
-type VoteStorage struct {
-	missionVotes         map[uuid.UUID]int8
-	teamVotes            map[uuid.UUID]int8
-	playersVotedMissions map[uuid.UUID]map[uint64]bool
-	playersVotedTeams    map[uuid.UUID]map[uint64]bool
+// ErrRepeatedVote is returned when player votes again in the same round, the vote is ignored
+var ErrRepeatedVote = errors.New("player already voted in this round")
+
+// VoteStorage keeps votes of the current voting round for every game.
+// Repeated votes by the same player are ignored with ErrRepeatedVote until ResetVotes is called.
+type VoteStorage interface {
+	AddPositiveMissionVote(ctx context.Context, gameId uuid.UUID, player *api.Player) error
+	AddNegativeMissionVote(ctx context.Context, gameId uuid.UUID, player *api.Player) error
+	GetMissionVotesCountForGame(ctx context.Context, id uuid.UUID) (int8, error)
+	NumberOfPlayersVotedForMission(ctx context.Context, id uuid.UUID) (int, error)
+
+	AddTeamVote(ctx context.Context, gameId uuid.UUID, player *api.Player, vote api.VoteContext_VoteOption) error
+	GetTeamVotes(ctx context.Context, id uuid.UUID) (*TeamVotes, error)
+
+	ResetVotes(ctx context.Context, gameId uuid.UUID) error
+}
+
+// TeamVotes are votes for the current mission team proposal, every player is in one set at most
+type TeamVotes struct {
+	Approvals   map[uint64]bool
+	Rejections  map[uint64]bool
+	Abstentions map[uint64]bool
 }
 
-func NewVoteStorage() *VoteStorage {
-	return &VoteStorage{
-		make(map[uuid.UUID]int8, 0),
-		make(map[uuid.UUID]int8, 0),
-		make(map[uuid.UUID]map[uint64]bool),
-		make(map[uuid.UUID]map[uint64]bool),
+func newTeamVotes() *TeamVotes {
+	return &TeamVotes{
+		Approvals:   make(map[uint64]bool),
+		Rejections:  make(map[uint64]bool),
+		Abstentions: make(map[uint64]bool),
 	}
 }
 
-func (v *VoteStorage) AddPositiveMissionVote(gameId uuid.UUID, player *api.Player) {
-	if _, alreadyVoted := v.playersVotedMissions[gameId][player.Id]; alreadyVoted {
-		log.Println(gameId, "repeated vote attempt by", player)
-		return
+// add returns false if player already voted or vote is unknown
+func (tv *TeamVotes) add(playerId uint64, vote api.VoteContext_VoteOption) bool {
+	if tv.Voted(playerId) {
+		return false
 	}
-	v.missionVotes[gameId]++
-	v.playersVotedMissions[gameId][player.Id] = true
+	switch vote {
+	case api.VoteContext_POSITIVE:
+		tv.Approvals[playerId] = true
+	case api.VoteContext_NEGATIVE:
+		tv.Rejections[playerId] = true
+	case api.VoteContext_ABSTAIN:
+		tv.Abstentions[playerId] = true
+	default:
+		return false
+	}
+	return true
+}
+
+func (tv *TeamVotes) Voted(playerId uint64) bool {
+	return tv.Approvals[playerId] || tv.Rejections[playerId] || tv.Abstentions[playerId]
 }
 
-func (v *VoteStorage) AddNegativeMissionVote(gameId uuid.UUID, player *api.Player) {
-	if _, alreadyVoted := v.playersVotedMissions[gameId][player.Id]; alreadyVoted {
-		log.Println(gameId, "repeated vote attempt by", player)
-		return
+// Result counts votes of given players only, returns number of players who did not vote yet.
+// Team is approved by strict majority of all players, so ties and abstentions don't approve it.
+func (tv *TeamVotes) Result(players []*api.Player) (*api.TeamVoteResult, int) {
+	result, missing := new(api.TeamVoteResult), 0
+	for _, p := range players {
+		switch {
+		case tv.Approvals[p.Id]:
+			result.Approvals++
+		case tv.Rejections[p.Id]:
+			result.Rejections++
+		case tv.Abstentions[p.Id]:
+			result.Abstentions++
+		default:
+			missing++
+		}
 	}
-	v.playersVotedMissions[gameId][player.Id] = true
+	result.Approved = 2*int(result.Approvals) > len(players)
+	return result, missing
 }
 
-func (v *VoteStorage) GetMissionVotesCountForGame(id uuid.UUID) int8 {
-	return v.missionVotes[id]
+// unwrapVoteStorage returns the innermost vote storage behind decorators like instrumentedVoteStorage
+func unwrapVoteStorage(s VoteStorage) VoteStorage {
+	for {
+		wrapper, ok := s.(interface{ Unwrap() VoteStorage })
+		if !ok {
+			return s
+		}
+		s = wrapper.Unwrap()
+	}
 }
 
-func (v *VoteStorage) NumberOfPlayersVotedForMission(id uuid.UUID) int {
-	return len(v.playersVotedMissions[id])
+type memoryVoteStorage struct {
+	logger *zap.Logger
+
+	lock                 sync.Mutex
+	missionVotes         map[uuid.UUID]int8
+	playersVotedMissions map[uuid.UUID]map[uint64]bool
+	teamVotes            map[uuid.UUID]*TeamVotes
 }
 
-func (v *VoteStorage) AddPositiveTeamVote(gameId uuid.UUID, player *api.Player) {
-	if _, alreadyVoted := v.playersVotedMissions[gameId][player.Id]; alreadyVoted {
-		log.Println(gameId, "repeated vote attempt by", player)
-		return
+func NewVoteStorage(logger *zap.Logger) VoteStorage {
+	return &memoryVoteStorage{
+		logger:               logger,
+		missionVotes:         make(map[uuid.UUID]int8, 0),
+		playersVotedMissions: make(map[uuid.UUID]map[uint64]bool),
+		teamVotes:            make(map[uuid.UUID]*TeamVotes),
 	}
+}
 
-	v.teamVotes[gameId]++
-	v.playersVotedTeams[gameId][player.Id] = true
+// registerVoter returns false if player already voted in this round
+func (v *memoryVoteStorage) registerVoter(voted map[uuid.UUID]map[uint64]bool, gameId uuid.UUID, player *api.Player) bool {
+	if _, alreadyVoted := voted[gameId][player.Id]; alreadyVoted {
+		v.logger.Info("repeated vote attempt", gameIdField(gameId), playerIdField(player))
+		return false
+	}
+	if voted[gameId] == nil {
+		voted[gameId] = make(map[uint64]bool)
+	}
+	voted[gameId][player.Id] = true
+	return true
+}
+
+func (v *memoryVoteStorage) AddPositiveMissionVote(_ context.Context, gameId uuid.UUID, player *api.Player) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if !v.registerVoter(v.playersVotedMissions, gameId, player) {
+		return ErrRepeatedVote
+	}
+	v.missionVotes[gameId]++
+	return nil
 }
 
-func (v *VoteStorage) AddNegativeTeamVote(gameId uuid.UUID, player *api.Player) {
-	if _, alreadyVoted := v.playersVotedTeams[gameId][player.Id]; alreadyVoted {
-		log.Println(gameId, "repeated vote attempt by", player)
-		return
+func (v *memoryVoteStorage) AddNegativeMissionVote(_ context.Context, gameId uuid.UUID, player *api.Player) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if !v.registerVoter(v.playersVotedMissions, gameId, player) {
+		return ErrRepeatedVote
 	}
-	v.teamVotes[gameId]--
-	v.playersVotedTeams[gameId][player.Id] = true
+	return nil
 }
 
-func (v *VoteStorage) GetTeamVotesCountForGame(id uuid.UUID) int8 {
-	return v.teamVotes[id]
+func (v *memoryVoteStorage) GetMissionVotesCountForGame(_ context.Context, id uuid.UUID) (int8, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return v.missionVotes[id], nil
 }
 
-func (v *VoteStorage) NumberOfPlayersVotedForTeam(id uuid.UUID) int {
-	return len(v.playersVotedTeams[id])
+func (v *memoryVoteStorage) NumberOfPlayersVotedForMission(_ context.Context, id uuid.UUID) (int, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return len(v.playersVotedMissions[id]), nil
+}
+
+func (v *memoryVoteStorage) AddTeamVote(_ context.Context, gameId uuid.UUID, player *api.Player, vote api.VoteContext_VoteOption) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if v.teamVotes[gameId] == nil {
+		v.teamVotes[gameId] = newTeamVotes()
+	}
+	if !v.teamVotes[gameId].add(player.Id, vote) {
+		v.logger.Info("repeated vote attempt", gameIdField(gameId), playerIdField(player))
+		return ErrRepeatedVote
+	}
+	return nil
+}
+
+func (v *memoryVoteStorage) GetTeamVotes(_ context.Context, id uuid.UUID) (*TeamVotes, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	votes := newTeamVotes()
+	if stored := v.teamVotes[id]; stored != nil {
+		//Copy, so votes can't change while caller counts them
+		for playerId := range stored.Approvals {
+			votes.Approvals[playerId] = true
+		}
+		for playerId := range stored.Rejections {
+			votes.Rejections[playerId] = true
+		}
+		for playerId := range stored.Abstentions {
+			votes.Abstentions[playerId] = true
+		}
+	}
+	return votes, nil
 }
 
-func (v *VoteStorage) ResetVotes(gameId uuid.UUID) {
-	v.missionVotes[gameId] = 0
-	v.missionVotes[gameId] = 0
-	v.playersVotedMissions[gameId] = make(map[uint64]bool)
-	v.playersVotedTeams[gameId] = make(map[uint64]bool)
+func (v *memoryVoteStorage) ResetVotes(_ context.Context, gameId uuid.UUID) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	delete(v.missionVotes, gameId)
+	delete(v.playersVotedMissions, gameId)
+	delete(v.teamVotes, gameId)
+	return nil
 }
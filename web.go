@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"github.com/rs/cors"
+	"google.golang.org/grpc"
+)
+
+// webHandler serves browser clients on the gateway listener:
+// gRPC-Web calls, WebSocket session events and REST gateway, in that order
+type webHandler struct {
+	grpcWeb *grpcweb.WrappedGrpcServer //nil if gRPC-Web is disabled
+	events  *sessionEventsBridge
+	rest    http.Handler
+}
+
+func newWebHandler(cfg GatewayConfig, grpcServer *grpc.Server, events *sessionEventsBridge, gateway http.Handler) *webHandler {
+	h := &webHandler{
+		events: events,
+		rest: cors.New(cors.Options{
+			AllowOriginFunc: originAllowed(cfg.AllowedOrigins),
+			AllowedMethods:  []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+			AllowedHeaders:  append([]string{"Content-Type"}, gatewayForwardedHeaders...),
+			MaxAge:          600,
+		}).Handler(gateway),
+	}
+	if cfg.GRPCWeb {
+		h.grpcWeb = grpcweb.WrapServer(grpcServer,
+			grpcweb.WithOriginFunc(originAllowed(cfg.AllowedOrigins)),
+			grpcweb.WithAllowedRequestHeaders(gatewayForwardedHeaders),
+		)
+	}
+	return h
+}
+
+func (h *webHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.grpcWeb != nil && (h.grpcWeb.IsGrpcWebRequest(r) || h.grpcWeb.IsAcceptableGrpcCorsRequest(r)) {
+		h.grpcWeb.ServeHTTP(w, r)
+		return
+	}
+	if gameId, ok := sessionEventsPath(r.URL.Path); ok && r.Method == http.MethodGet {
+		h.events.ServeSession(w, r, gameId)
+		return
+	}
+	h.rest.ServeHTTP(w, r)
+}
+
+// originAllowed checks browser origin against configured list, "*" allows any origin
+func originAllowed(allowed []string) func(origin string) bool {
+	return func(origin string) bool {
+		for _, o := range allowed {
+			if o = strings.TrimSpace(o); o == "*" || strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/websocket"
+	"github.com/justmax437/avalonBacker/api"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+)
+
+const testOrigin = "https://webapp.example"
+
+func newTestWebServer(t *testing.T, cfg *ServerConfig) (*httptest.Server, api.GameServiceServer) {
+	t.Helper()
+	logger := zaptest.NewLogger(t)
+	sessions := NewMemoryStorage(time.Minute)
+	service := NewGameService(sessions, NewVoteStorage(logger), logger)
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(newUnaryInterceptors(cfg, logger)...))
+	api.RegisterGameServiceServer(grpcServer, service)
+	events := newSessionEventsBridge(sessions, cfg, logger)
+	gateway := NewRESTGateway(service, newUnaryInterceptors(cfg, logger), logger)
+
+	server := httptest.NewServer(newWebHandler(cfg.Gateway, grpcServer, events, gateway))
+	t.Cleanup(func() {
+		events.Close()
+		server.Close()
+	})
+	return server, service
+}
+
+func testWebConfig() *ServerConfig {
+	cfg := defaultServerConfig()
+	cfg.Gateway.AllowedOrigins = []string{testOrigin}
+	return &cfg
+}
+
+func TestGRPCWebCall(t *testing.T) {
+	server, _ := newTestWebServer(t, testWebConfig())
+
+	msg, err := testGameConfig(0).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	frame = append(frame, msg...)
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/proto.GameService/CreateSession", bytes.NewReader(frame))
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	req.Header.Set("Origin", testOrigin)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != testOrigin {
+		t.Errorf("Access-Control-Allow-Origin = %q; want %q", got, testOrigin)
+	}
+	if len(body) < 5 || body[0] != 0 {
+		t.Fatalf("response has no data frame: %q", body)
+	}
+	size := binary.BigEndian.Uint32(body[1:5])
+	session := new(api.GameSession)
+	if err = session.Unmarshal(body[5 : 5+size]); err != nil {
+		t.Fatal(err)
+	}
+	if session.GetGameId().GetValue() == "" {
+		t.Error("created session has no id")
+	}
+	if trailer := string(body[5+size:]); !strings.Contains(trailer, "grpc-status: 0") {
+		t.Errorf("unexpected trailer frame %q", trailer)
+	}
+}
+
+func TestRESTGatewayCORS(t *testing.T) {
+	server, _ := newTestWebServer(t, testWebConfig())
+
+	for origin, wantAllowed := range map[string]bool{testOrigin: true, "https://evil.example": false} {
+		req, _ := http.NewRequest(http.MethodOptions, server.URL+"/v1/sessions", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if allowed := resp.Header.Get("Access-Control-Allow-Origin") == origin; allowed != wantAllowed {
+			t.Errorf("origin %s allowed = %v; want %v", origin, allowed, wantAllowed)
+		}
+	}
+}
+
+func TestSessionEventsWebSocket(t *testing.T) {
+	ctx := context.Background()
+	cfg := testWebConfig()
+	cfg.Auth.Tokens = []string{"secret"}
+	server, service := newTestWebServer(t, cfg)
+
+	created, err := service.CreateSession(ctx, testGameConfig(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/sessions/" + created.GameId.Value + "/events"
+	header := http.Header{"Origin": {testOrigin}}
+	if _, resp, err := websocket.DefaultDialer.Dial(eventsURL, header); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated stream was not rejected: %v", err)
+	}
+	if _, resp, err := websocket.DefaultDialer.Dial(eventsURL+"?access_token=secret", header); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("token in query was accepted: %v", err)
+	}
+	dialer := websocket.Dialer{Subprotocols: []string{sessionEventsProtocol, bearerProtocolPrefix + "secret"}}
+	conn, resp, err := dialer.Dial(eventsURL, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if protocol := resp.Header.Get("Sec-WebSocket-Protocol"); protocol != sessionEventsProtocol {
+		t.Errorf("selected subprotocol = %q; want %q", protocol, sessionEventsProtocol)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var event sessionEvent
+	if err = conn.ReadJSON(&event); err != nil || event.Session.GetState() != api.GameSession_GAME_CREATED {
+		t.Fatalf("initial event = %+v, %v; want session in GAME_CREATED state", event, err)
+	}
+
+	pushed, err := service.PushGameState(ctx, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.ReadJSON(&event); err != nil || event.Session.GetState() != pushed.State {
+		t.Fatalf("event after push = %+v, %v; want session in %v state", event, err, pushed.State)
+	}
+
+	if _, err = service.TerminateSession(ctx, created); err != nil {
+		t.Fatal(err)
+	}
+	event = sessionEvent{}
+	if err = conn.ReadJSON(&event); err != nil || !event.Closed {
+		t.Fatalf("event after termination = %+v, %v; want closed", event, err)
+	}
+	if _, _, err = conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("stream was not closed normally: %v", err)
+	}
+}
+
+func TestSessionEventsFromOtherReplica(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	mr, rdb := newTestRedis(t)
+	events := newSessionEventsBridge(NewRedisSessionStorage(rdb, time.Minute, logger), testWebConfig(), logger)
+	server := httptest.NewServer(newWebHandler(testWebConfig().Gateway, grpc.NewServer(), events, http.NotFoundHandler()))
+	t.Cleanup(func() {
+		events.Close()
+		server.Close()
+	})
+
+	//Another replica has its own connection and its own service
+	otherRdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer otherRdb.Close()
+	other := NewGameService(NewRedisSessionStorage(otherRdb, time.Minute, logger), NewRedisVoteStorage(otherRdb, time.Minute, logger), logger)
+	created, err := other.CreateSession(ctx, testGameConfig(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/sessions/" + created.GameId.Value + "/events"
+	conn, _, err := websocket.DefaultDialer.Dial(eventsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var event sessionEvent
+	if err = conn.ReadJSON(&event); err != nil || event.Session.GetState() != api.GameSession_GAME_CREATED {
+		t.Fatalf("initial event = %+v, %v; want session in GAME_CREATED state", event, err)
+	}
+	pushed, err := other.PushGameState(ctx, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.ReadJSON(&event); err != nil || event.Session.GetState() != pushed.State {
+		t.Fatalf("event after push by other replica = %+v, %v; want session in %v state", event, err, pushed.State)
+	}
+}